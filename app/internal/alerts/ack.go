@@ -0,0 +1,79 @@
+package alerts
+
+import (
+	"fmt"
+	"net/url"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AckTokenPurpose scopes the signed tokens carried in acknowledgement links
+const AckTokenPurpose = "ack"
+
+// ackLinkTTL is how long the acknowledgement link in an alert stays valid
+const ackLinkTTL = 24 * time.Hour
+
+// TokenSigner signs expiring tokens; *auth.Auth implements it with the session HMAC secret
+type TokenSigner interface {
+	SignToken(purpose, subject string, ttl time.Duration) string
+}
+
+// SetTokenSigner enables acknowledgement links in outbound alerts
+func (m *Manager) SetTokenSigner(s TokenSigner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signer = s
+}
+
+// syncIncident opens an incident when a service is down without one and resolves it on recovery
+func syncIncident(serviceKey, serviceName string, ok bool) {
+	if ok {
+		_ = database.ResolveIncident(serviceKey)
+		return
+	}
+	if inc, err := database.GetOpenIncident(serviceKey); err == nil && inc == nil {
+		_, _ = database.OpenIncident(serviceKey, serviceName, EventDown)
+	}
+}
+
+// incidentAcknowledged reports whether a service's ongoing incident has been acknowledged
+func incidentAcknowledged(serviceKey string) bool {
+	inc, _ := database.GetOpenIncident(serviceKey)
+	return inc != nil && inc.AcknowledgedAt != ""
+}
+
+// ackURL returns a signed link that acknowledges the service's ongoing incident, or ""
+// when there is nothing to acknowledge or no dashboard URL to link to.
+func (m *Manager) ackURL(serviceKey string) string {
+	m.mu.RLock()
+	signer := m.signer
+	m.mu.RUnlock()
+	if signer == nil {
+		return ""
+	}
+	base := m.ResolveStatusPageURL("")
+	if base == "" {
+		return ""
+	}
+	inc, _ := database.GetOpenIncident(serviceKey)
+	if inc == nil || inc.AcknowledgedAt != "" {
+		return ""
+	}
+	token := signer.SignToken(AckTokenPurpose, strconv.FormatInt(inc.ID, 10), ackLinkTTL)
+	return strings.TrimRight(base, "/") + "/?ack=" + url.QueryEscape(token)
+}
+
+// Acknowledge records who acknowledged an incident, which stops repeat and escalation
+// alerts for it. It returns nil if the incident does not exist.
+func (m *Manager) Acknowledge(id int64, by string) (*models.Incident, error) {
+	inc, err := database.AcknowledgeIncident(id, by)
+	if err != nil || inc == nil {
+		return inc, err
+	}
+	_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, inc.ServiceKey, "Incident acknowledged",
+		fmt.Sprintf("incident=%d, by=%s", inc.ID, inc.AcknowledgedBy))
+	return inc, nil
+}
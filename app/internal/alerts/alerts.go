@@ -6,18 +6,41 @@ import (
 	"status/app/internal/database"
 	"status/app/internal/models"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Manager handles alert notification functionality
 type Manager struct {
 	config        *models.AlertConfig
 	statusPageURL string
+
+	mu             sync.RWMutex
+	channels       []models.NotificationChannel
+	channelsLoaded bool
+	signer         TokenSigner
+
+	groupMu sync.Mutex
+	grouped map[string][]groupedAlert // Alerts held for a digest, by event
+
+	latencyMu sync.Mutex
+	latency   map[string]*latencyState // Latency anomaly tracking, by service
+
+	deps dependencyCache
+
+	resourceAlerts resourcePoller
+	telegram       telegramBots
+
+	sim *simulation // Set on the throwaway manager that runs an alert simulation
 }
 
 // NewManager creates a new alerts manager
 func NewManager(statusPageURL string) *Manager {
 	config, _ := database.LoadAlertConfig()
-	return &Manager{config: config, statusPageURL: statusPageURL}
+	m := &Manager{config: config, statusPageURL: statusPageURL}
+	applyFlapPolicy(config)
+	_ = m.ReloadChannels()
+	return m
 }
 
 // ReloadConfig reloads the alert configuration from database
@@ -27,7 +50,8 @@ func (m *Manager) ReloadConfig() error {
 		return err
 	}
 	m.config = config
-	return nil
+	applyFlapPolicy(config)
+	return m.ReloadChannels()
 }
 
 // GetConfig returns the current alert configuration
@@ -56,114 +80,220 @@ func (m *Manager) ResolveStatusPageURL(fallback string) string {
 // SetConfig updates the alert configuration
 func (m *Manager) SetConfig(config *models.AlertConfig) {
 	m.config = config
+	applyFlapPolicy(config)
 }
 
 // CheckAndSendAlerts checks for service status changes and sends alerts across all configured channels
 func (m *Manager) CheckAndSendAlerts(serviceKey, serviceName string, ok, degraded bool) {
+	m.CheckAndSendAlertsWithWarnings(serviceKey, serviceName, ok, degraded, nil)
+}
+
+// CheckAndSendAlertsWithWarnings is CheckAndSendAlerts with the app health warnings
+// that caused a degraded state, which are included in the degraded alert.
+func (m *Manager) CheckAndSendAlertsWithWarnings(serviceKey, serviceName string, ok, degraded bool, warnings []string) {
+	m.CheckAndSendAlertsWithDetails(serviceKey, serviceName, ok, degraded, CheckDetails{Warnings: warnings})
+}
+
+// CheckDetails describes the check result behind an alert, for use in its template
+type CheckDetails struct {
+	LatencyMS int
+	Error     string
+	Warnings  []string
+}
+
+// CheckAndSendAlertsWithDetails is CheckAndSendAlerts with the latest check's details,
+// which notification templates can include.
+func (m *Manager) CheckAndSendAlertsWithDetails(serviceKey, serviceName string, ok, degraded bool, details CheckDetails) {
 	if m.config == nil || !m.config.Enabled {
 		return
 	}
 
-	// Dependency-aware suppression: if upstream dependency is down, suppress
 	svc, _ := database.GetServiceByKey(serviceKey)
-	if svc != nil && svc.DependsOn != "" {
-		depKeys := strings.Split(svc.DependsOn, ",")
-		for _, dk := range depKeys {
-			dk = strings.TrimSpace(dk)
-			if dk == "" {
-				continue
-			}
-			// Check if the upstream dependency is currently marked as down
-			var depOK int
-			err := database.DB.QueryRow(`SELECT ok FROM service_status_history WHERE service_key = ?`, dk).Scan(&depOK)
-			if err == nil && depOK == 0 {
-				_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, serviceKey,
-					"Alert suppressed — upstream dependency down", fmt.Sprintf("depends_on=%s", dk))
-				m.updateStatusHistory(serviceKey, ok, degraded)
-				return
-			}
-		}
+	if m.sim == nil {
+		syncIncident(serviceKey, serviceName, ok)
 	}
 
 	// Get previous status
-	var prevOK, prevDegraded int
-	err := database.DB.QueryRow(`SELECT ok, degraded FROM service_status_history WHERE service_key = ?`, serviceKey).
-		Scan(&prevOK, &prevDegraded)
+	prevOKBool, prevDegradedBool, found := m.previousStatus(serviceKey)
 
-	if err == sql.ErrNoRows {
+	// A down alert still held back by quiet hours is stale once the service is back up
+	if ok && found && !prevOKBool {
+		if dropped := m.cancelDeferred(0, serviceKey, EventDown, TemplateRepeat, TemplateEscalation); dropped > 0 {
+			m.logAlert(database.LogLevelInfo, serviceKey, "Deferred alert dropped — service recovered",
+				fmt.Sprintf("service=%s, dropped=%d", serviceName, dropped))
+		}
+	}
+
+	// Dependency-aware suppression: if a direct or transitive upstream dependency is down,
+	// only the root cause alerts
+	if roots := m.upstreamRootCauses(serviceKey); len(roots) > 0 {
+		m.logAlert(database.LogLevelInfo, serviceKey,
+			"Alert suppressed — upstream dependency down", fmt.Sprintf("root_cause=%s", strings.Join(roots, ",")))
+		if !ok && (!found || prevOKBool) && m.eventEnabled(svc, EventDown) {
+			m.holdSuppressed(svc, EventDown, m.alertData(svc, EventDown, serviceKey, serviceName, details), roots)
+		} else if ok && found && !prevOKBool && m.eventEnabled(svc, EventUp) {
+			m.holdSuppressed(svc, EventUp, m.alertData(svc, EventUp, serviceKey, serviceName, details), roots)
+		}
+		m.updateStatusHistory(serviceKey, ok, degraded)
+		return
+	}
+
+	if !found {
 		// First time
-		if !ok && m.config.AlertOnDown {
-			_ = database.InsertLog(database.LogLevelError, database.LogCategoryEmail, serviceKey, "Service went DOWN - sending alert (first status)", serviceName)
-			subject := fmt.Sprintf("🔴 Service Down: %s", serviceName)
-			message := fmt.Sprintf("The service <strong>%s</strong> is currently unreachable and not responding to health checks. Please investigate immediately.", serviceName)
-			m.dispatchAll(subject, "down", serviceName, serviceKey, message)
-		} else if ok && degraded && m.config.AlertOnDegraded {
-			_ = database.InsertLog(database.LogLevelWarn, database.LogCategoryEmail, serviceKey, "Service DEGRADED - sending alert (first status)", serviceName)
-			subject := fmt.Sprintf("⚠️ Service Degraded: %s", serviceName)
-			message := fmt.Sprintf("The service <strong>%s</strong> is responding but experiencing high latency (over 200ms). Performance may be impacted.", serviceName)
-			m.dispatchAll(subject, "degraded", serviceName, serviceKey, message)
+		notifiedDown := false
+		if !ok && m.eventEnabled(svc, EventDown) {
+			m.logAlert(database.LogLevelError, serviceKey, "Service went DOWN - sending alert (first status)", serviceName)
+			m.notify(svc, EventDown, m.alertData(svc, EventDown, serviceKey, serviceName, details))
+			notifiedDown = true
+		} else if ok && degraded && m.eventEnabled(svc, EventDegraded) {
+			m.logAlert(database.LogLevelWarn, serviceKey, "Service DEGRADED - sending alert (first status)", serviceName)
+			m.dispatchAll(svc, EventDegraded, m.alertData(svc, EventDegraded, serviceKey, serviceName, details))
 		}
 
-		_, _ = database.DB.Exec(`INSERT INTO service_status_history (service_key, ok, degraded, updated_at) VALUES (?, ?, ?, datetime('now'))`,
-			serviceKey, boolToInt(ok), boolToInt(degraded))
+		m.updateStatusHistory(serviceKey, ok, degraded)
+		if notifiedDown {
+			m.markOutageNotified(serviceKey, false)
+		}
 		return
 	}
 
-	prevOKBool := prevOK == 1
-	prevDegradedBool := prevDegraded == 1
+	switch m.checkFlapping(svc, serviceKey, serviceName, ok != prevOKBool) {
+	case flapSuppress:
+		m.updateStatusHistory(serviceKey, ok, degraded)
+		return
+	case flapSettled:
+		// Report the status the service settled on as if it had just changed to it
+		prevOKBool = !ok
+	}
 
 	// Check for status changes
-	if !ok && prevOKBool && m.config.AlertOnDown {
-		_ = database.InsertLog(database.LogLevelError, database.LogCategoryEmail, serviceKey, "Service went DOWN - sending alert", serviceName)
-		subject := fmt.Sprintf("🔴 Service Down: %s", serviceName)
-		message := fmt.Sprintf("The service <strong>%s</strong> is currently unreachable and not responding to health checks. Please investigate immediately.", serviceName)
-		m.dispatchAll(subject, "down", serviceName, serviceKey, message)
-	} else if ok && !prevOKBool && m.config.AlertOnUp {
-		_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, serviceKey, "Service RECOVERED - sending alert", serviceName)
-		subject := fmt.Sprintf("✅ Service Recovered: %s", serviceName)
-		message := fmt.Sprintf("Great news! The service <strong>%s</strong> has recovered and is now responding normally to health checks.", serviceName)
-		m.dispatchAll(subject, "up", serviceName, serviceKey, message)
-	} else if ok && degraded && !prevDegradedBool && m.config.AlertOnDegraded {
-		_ = database.InsertLog(database.LogLevelWarn, database.LogCategoryEmail, serviceKey, "Service DEGRADED - sending alert", serviceName)
-		subject := fmt.Sprintf("⚠️ Service Degraded: %s", serviceName)
-		message := fmt.Sprintf("The service <strong>%s</strong> is responding but experiencing high latency (over 200ms). Performance may be impacted.", serviceName)
-		m.dispatchAll(subject, "degraded", serviceName, serviceKey, message)
+	notifiedDown := false
+	if !ok && prevOKBool && m.eventEnabled(svc, EventDown) {
+		m.logAlert(database.LogLevelError, serviceKey, "Service went DOWN - sending alert", serviceName)
+		m.notify(svc, EventDown, m.alertData(svc, EventDown, serviceKey, serviceName, details))
+		notifiedDown = true
+	} else if ok && !prevOKBool && m.eventEnabled(svc, EventUp) {
+		m.logAlert(database.LogLevelInfo, serviceKey, "Service RECOVERED - sending alert", serviceName)
+		m.notify(svc, EventUp, m.alertData(svc, EventUp, serviceKey, serviceName, details))
+	} else if ok && degraded && !prevDegradedBool && m.eventEnabled(svc, EventDegraded) {
+		m.logAlert(database.LogLevelWarn, serviceKey, "Service DEGRADED - sending alert", serviceName)
+		m.dispatchAll(svc, EventDegraded, m.alertData(svc, EventDegraded, serviceKey, serviceName, details))
 	}
 
 	// Update status history
 	m.updateStatusHistory(serviceKey, ok, degraded)
-}
 
-// updateStatusHistory persists the current status for comparison on next check
-func (m *Manager) updateStatusHistory(serviceKey string, ok, degraded bool) {
-	_, _ = database.DB.Exec(`INSERT INTO service_status_history (service_key, ok, degraded, updated_at) VALUES (?, ?, ?, datetime('now'))
-		ON CONFLICT(service_key) DO UPDATE SET ok=?, degraded=?, updated_at=datetime('now')`,
-		serviceKey, boolToInt(ok), boolToInt(degraded), boolToInt(ok), boolToInt(degraded))
+	if notifiedDown {
+		m.markOutageNotified(serviceKey, false)
+	} else if !ok && !prevOKBool {
+		m.checkOngoingOutage(svc, serviceKey, serviceName, m.now())
+	}
 }
 
-// dispatchAll sends a notification across all enabled channels
-func (m *Manager) dispatchAll(subject, statusType, serviceName, serviceKey, message string) {
-	statusPageURL := m.ResolveStatusPageURL("")
+// alertData is the template data for a status alert, including the check's details
+func (m *Manager) alertData(svc *models.ServiceConfig, event, serviceKey, serviceName string, details CheckDetails) TemplateData {
+	data := m.templateData(svc, event, serviceKey, serviceName)
+	data.LatencyMS = details.LatencyMS
+	data.Error = details.Error
+	data.Warnings = strings.Join(details.Warnings, "; ")
+	return data
+}
 
-	// Email
-	if m.config.SMTPHost != "" && m.config.AlertEmail != "" {
-		body := CreateHTMLEmail(subject, statusType, serviceName, serviceKey, message, statusPageURL)
-		go m.SendEmail(subject, body)
+// previousStatus returns the status recorded by the last check; found is false for a
+// service that has not been checked before
+func (m *Manager) previousStatus(serviceKey string) (ok, degraded, found bool) {
+	if m.sim != nil {
+		st := m.sim.history[serviceKey]
+		if st == nil {
+			return false, false, false
+		}
+		return st.ok, st.degraded, true
 	}
+	var prevOK, prevDegraded int
+	err := database.DB.QueryRow(`SELECT ok, degraded FROM service_status_history WHERE service_key = ?`, serviceKey).
+		Scan(&prevOK, &prevDegraded)
+	if err == sql.ErrNoRows {
+		return false, false, false
+	}
+	return prevOK == 1, prevDegraded == 1, true
+}
 
-	// Discord
-	if m.config.DiscordEnabled && m.config.DiscordWebhookURL != "" {
-		go m.SendDiscord(subject, statusType, serviceName, message, statusPageURL)
+// updateStatusHistory persists the current status for comparison on next check. It also
+// tracks when an outage started and clears the repeat/escalation state once the service is up.
+func (m *Manager) updateStatusHistory(serviceKey string, ok, degraded bool) {
+	if m.sim != nil {
+		st := m.sim.history[serviceKey]
+		if st == nil {
+			st = &simStatus{}
+			m.sim.history[serviceKey] = st
+		}
+		st.ok, st.degraded = ok, degraded
+		if ok {
+			st.downSince, st.lastNotified, st.escalatedAt = time.Time{}, time.Time{}, time.Time{}
+		} else if st.downSince.IsZero() {
+			st.downSince = m.now()
+		}
+		return
 	}
+	_, _ = database.DB.Exec(`INSERT INTO service_status_history (service_key, ok, degraded, updated_at, down_since)
+		VALUES (?, ?, ?, datetime('now'), CASE WHEN ? = 0 THEN datetime('now') END)
+		ON CONFLICT(service_key) DO UPDATE SET ok=excluded.ok, degraded=excluded.degraded, updated_at=excluded.updated_at,
+			down_since = CASE WHEN excluded.ok = 1 THEN NULL ELSE COALESCE(service_status_history.down_since, excluded.updated_at) END,
+			last_notified_at = CASE WHEN excluded.ok = 1 THEN NULL ELSE service_status_history.last_notified_at END,
+			escalated_at = CASE WHEN excluded.ok = 1 THEN NULL ELSE service_status_history.escalated_at END`,
+		serviceKey, boolToInt(ok), boolToInt(degraded), boolToInt(ok))
+	m.setDown(serviceKey, !ok)
+}
+
+// dispatchAll sends a notification across the enabled channels the service is routed to
+func (m *Manager) dispatchAll(svc *models.ServiceConfig, event string, data TemplateData) {
+	m.dispatchTo(m.channelsFor(svc), event, data)
+}
 
-	// Telegram
-	if m.config.TelegramEnabled && m.config.TelegramBotToken != "" && m.config.TelegramChatID != "" {
-		go m.SendTelegram(subject, statusType, serviceName, message)
+// dispatchTo renders the event's template for each channel and queues the notification.
+// Nothing is sent for a service paused by a maintenance window.
+func (m *Manager) dispatchTo(channels []models.NotificationChannel, event string, data TemplateData) {
+	if data.ServiceKey != "" {
+		if w, _ := database.GetActiveMaintenance(data.ServiceKey); w != nil {
+			m.logAlert(database.LogLevelInfo, data.ServiceKey, "Alert suppressed — service paused",
+				fmt.Sprintf("event=%s, service=%s, until=%s, reason=%s", event, data.ServiceName, w.EndTime, w.Title))
+			return
+		}
+	}
+	statusType := statusForEvent(event)
+	if statusType == EventDown {
+		data.AckURL = m.ackURL(data.ServiceKey)
 	}
+	for _, ch := range channels {
+		action, until := QuietDeliver, time.Time{}
+		if !data.Critical {
+			action, until = m.quietHoursFor(ch, event, m.now())
+		}
+		if action == QuietSuppress {
+			m.logAlert(database.LogLevelInfo, data.ServiceKey, "Alert suppressed — quiet hours",
+				fmt.Sprintf("channel=%s, event=%s, service=%s", ch.Name, event, data.ServiceName))
+			continue
+		}
 
-	// Generic webhook
-	if m.config.WebhookEnabled && m.config.WebhookURL != "" {
-		go m.SendWebhook(subject, statusType, serviceName, serviceKey, message)
+		subject, message := RenderTemplate(event, templateFor(event, ch.Type), data)
+		n := Notification{
+			Event:         event,
+			Subject:       subject,
+			StatusType:    statusType,
+			ServiceName:   data.ServiceName,
+			ServiceKey:    data.ServiceKey,
+			Message:       message,
+			StatusPageURL: data.StatusPageURL,
+			AckURL:        data.AckURL,
+			DayURL:        data.DayURL,
+		}
+		if action == QuietDelay {
+			m.logAlert(database.LogLevelInfo, data.ServiceKey, "Alert deferred — quiet hours",
+				fmt.Sprintf("channel=%s, event=%s, service=%s, until=%s", ch.Name, event, data.ServiceName, until.Format(time.RFC3339)))
+			m.deferDelivery(ch, n, until)
+			continue
+		}
+		m.enqueue(ch, n)
 	}
 }
 
@@ -0,0 +1,329 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"status/app/internal/crypto"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification is one alert, rendered independently by every channel it is delivered to
+type Notification struct {
+	Event         string `json:"event,omitempty"` // Template event: down, repeat, escalation, up, ...
+	Subject       string `json:"subject"`
+	StatusType    string `json:"status_type"` // down, degraded, up, changed
+	ServiceName   string `json:"service_name"`
+	ServiceKey    string `json:"service_key"`
+	Message       string `json:"message"` // HTML fragment; channels convert <strong> as needed
+	StatusPageURL string `json:"status_page_url"`
+	AckURL        string `json:"ack_url,omitempty"` // Signed link acknowledging the outage (down alerts only)
+	DayURL        string `json:"day_url,omitempty"` // Dashboard link opening today's detail view for the service
+
+	// DeliveryID is the outbox row being attempted (0 for direct sends); retries of one
+	// delivery share it, so channels can use it to make retries idempotent
+	DeliveryID int64 `json:"-"`
+}
+
+// channelType describes the settings a channel type needs and how it delivers a notification
+type channelType struct {
+	Label    string
+	Required []string // settings that must be non-empty
+	Secret   []string // settings masked in API responses
+	send     func(settings map[string]string, n Notification) error
+}
+
+var channelTypes = map[string]channelType{
+	models.ChannelEmail: {
+		Label:    "Email",
+		Required: []string{"smtp_host", "to"},
+		Secret:   []string{"smtp_password"},
+		send:     sendEmailNotification,
+	},
+	models.ChannelDiscord: {
+		Label:    "Discord",
+		Required: []string{"webhook_url"},
+		send:     sendDiscord,
+	},
+	models.ChannelTelegram: {
+		Label:    "Telegram",
+		Required: []string{"bot_token", "chat_id"},
+		Secret:   []string{"bot_token"},
+		send:     sendTelegram,
+	},
+	models.ChannelWebhook: {
+		Label:    "Webhook",
+		Required: []string{"url"},
+		Secret:   []string{"secret"},
+		send:     sendWebhook,
+	},
+	models.ChannelSlack: {
+		Label:    "Slack",
+		Required: []string{"webhook_url"},
+		send:     sendSlack,
+	},
+	models.ChannelTeams: {
+		Label:    "Teams",
+		Required: []string{"webhook_url"},
+		send:     sendTeams,
+	},
+	models.ChannelNtfy: {
+		Label:    "ntfy",
+		Required: []string{"topic"},
+		Secret:   []string{"token"},
+		send:     sendNtfy,
+	},
+	models.ChannelGotify: {
+		Label:    "Gotify",
+		Required: []string{"server_url", "token"},
+		Secret:   []string{"token"},
+		send:     sendGotify,
+	},
+	models.ChannelPushover: {
+		Label:    "Pushover",
+		Required: []string{"user_key", "token"},
+		Secret:   []string{"user_key", "token"},
+		send:     sendPushover,
+	},
+	models.ChannelMatrix: {
+		Label:    "Matrix",
+		Required: []string{"homeserver", "access_token", "room_id"},
+		Secret:   []string{"access_token"},
+		send:     sendMatrix,
+	},
+}
+
+// statusColors and statusEmoji are the colour and emoji each status is shown with in chat channels
+var (
+	statusColors = map[string]int{"down": 0xef4444, "degraded": 0xeab308, "up": 0x22c55e, "changed": 0x3b82f6}
+	statusEmoji  = map[string]string{"down": "🔴", "degraded": "⚠️", "up": "✅", "changed": "📝"}
+)
+
+// clickURL picks the link a push notification opens: the service's day-detail view when the
+// channel's "click" setting is "day", otherwise the status page
+func clickURL(settings map[string]string, n Notification) string {
+	if settings["click"] == "day" && n.DayURL != "" {
+		return n.DayURL
+	}
+	return n.StatusPageURL
+}
+
+// plainMessage converts a notification's HTML fragment to text, keeping <strong> as markdown bold
+func plainMessage(message string) string {
+	return html.UnescapeString(strings.ReplaceAll(strings.ReplaceAll(message, "<strong>", "**"), "</strong>", "**"))
+}
+
+// statusLabel renders a status for display, e.g. "🔴 DOWN"
+func statusLabel(statusType string) string {
+	label := strings.ToUpper(statusType)
+	if emoji := statusEmoji[statusType]; emoji != "" {
+		label = emoji + " " + label
+	}
+	return label
+}
+
+// ChannelTypes returns the supported channel type names in sorted order
+func ChannelTypes() []string {
+	types := make([]string, 0, len(channelTypes))
+	for t := range channelTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ValidateChannel checks the channel type, name and required settings
+func ValidateChannel(ch *models.NotificationChannel) error {
+	ct, ok := channelTypes[ch.Type]
+	if !ok {
+		return fmt.Errorf("unknown channel type %q (supported: %s)", ch.Type, strings.Join(ChannelTypes(), ", "))
+	}
+	if strings.TrimSpace(ch.Name) == "" {
+		return errors.New("channel name is required")
+	}
+	for _, key := range ct.Required {
+		if strings.TrimSpace(ch.Settings[key]) == "" {
+			return fmt.Errorf("%s channel requires %q", ct.Label, key)
+		}
+	}
+	if ch.Type == models.ChannelEmail && !slices.Contains(SMTPSecurityModes, ch.Settings["smtp_security"]) {
+		return fmt.Errorf("unknown SMTP security %q (supported: starttls, tls, none)", ch.Settings["smtp_security"])
+	}
+	return nil
+}
+
+// MaskChannel returns a copy of the channel with secret settings masked for display
+func MaskChannel(ch models.NotificationChannel) models.NotificationChannel {
+	settings := make(map[string]string, len(ch.Settings))
+	for k, v := range ch.Settings {
+		settings[k] = v
+	}
+	for _, key := range channelTypes[ch.Type].Secret {
+		if settings[key] != "" {
+			settings[key] = crypto.MaskToken(settings[key])
+		}
+	}
+	ch.Settings = settings
+	return ch
+}
+
+// StripSecrets returns a copy of the channel without its secret settings, for backups
+func StripSecrets(ch models.NotificationChannel) models.NotificationChannel {
+	settings := make(map[string]string, len(ch.Settings))
+	for k, v := range ch.Settings {
+		if !slices.Contains(channelTypes[ch.Type].Secret, k) {
+			settings[k] = v
+		}
+	}
+	ch.Settings = settings
+	return ch
+}
+
+// KeepMaskedSecrets restores secret settings that were submitted still masked
+// (i.e. left untouched in the UI) from the stored channel.
+func KeepMaskedSecrets(ch *models.NotificationChannel, existing *models.NotificationChannel) {
+	if existing == nil || ch.Settings == nil {
+		return
+	}
+	for _, key := range channelTypes[ch.Type].Secret {
+		if strings.HasPrefix(ch.Settings[key], "•") {
+			ch.Settings[key] = existing.Settings[key]
+		}
+	}
+}
+
+// ReloadChannels reloads the notification channels from database
+func (m *Manager) ReloadChannels() error {
+	channels, err := database.GetNotificationChannels()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.channels = channels
+	m.channelsLoaded = true
+	m.mu.Unlock()
+	return nil
+}
+
+// Channels returns all loaded notification channels, enabled or not
+func (m *Manager) Channels() []models.NotificationChannel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]models.NotificationChannel(nil), m.channels...)
+}
+
+// activeChannels returns the enabled channels. A manager that never loaded channels
+// from the database (e.g. one built directly from an AlertConfig) uses the legacy
+// single-target fields of its config instead.
+func (m *Manager) activeChannels() []models.NotificationChannel {
+	m.mu.RLock()
+	channels, loaded := m.channels, m.channelsLoaded
+	m.mu.RUnlock()
+	if !loaded && m.config != nil {
+		channels = database.LegacyChannels(m.config)
+	}
+
+	active := make([]models.NotificationChannel, 0, len(channels))
+	for _, ch := range channels {
+		if ch.Enabled {
+			active = append(active, ch)
+		}
+	}
+	return active
+}
+
+// Deliver sends a notification through a single channel and logs the outcome
+func (m *Manager) Deliver(ch models.NotificationChannel, n Notification) error {
+	ct, ok := channelTypes[ch.Type]
+	if !ok {
+		return fmt.Errorf("unknown channel type %q", ch.Type)
+	}
+	if err := ValidateChannel(&ch); err != nil {
+		return err
+	}
+
+	category := "notification"
+	if ch.Type == models.ChannelEmail {
+		category = database.LogCategoryEmail
+	}
+
+	err := ct.send(ch.Settings, n)
+	if err != nil {
+		_ = database.InsertLog(database.LogLevelError, category, n.ServiceKey, ct.Label+" notification failed",
+			fmt.Sprintf("channel=%s, subject=%s, error=%v", ch.Name, n.Subject, err))
+		return err
+	}
+	_ = database.InsertLog(database.LogLevelInfo, category, n.ServiceKey, ct.Label+" notification sent",
+		fmt.Sprintf("channel=%s, subject=%s", ch.Name, n.Subject))
+	return nil
+}
+
+// StatusError is returned by HTTP-based channels when the endpoint rejects a notification
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // From a 429 response's Retry-After header or body, if any
+}
+
+func (e *StatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("unexpected status %d (retry after %s)", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// channelHTTPClient sends every HTTP channel's requests. The timeout keeps a stalled endpoint
+// from holding an outbox delivery past its lease, which would let it be claimed and sent twice.
+var channelHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// checkResponse turns a non-2xx HTTP response into a *StatusError
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err := &StatusError{StatusCode: resp.StatusCode}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err.RetryAfter = retryAfter(resp)
+	}
+	return err
+}
+
+// retryAfter reads the back-off requested by a 429 response: the Retry-After header
+// (seconds or HTTP date), else the JSON body used by Discord ("retry_after") and
+// Telegram ("parameters.retry_after"), both in seconds, or Matrix ("retry_after_ms").
+func retryAfter(resp *http.Response) time.Duration {
+	if h := strings.TrimSpace(resp.Header.Get("Retry-After")); h != "" {
+		if secs, err := strconv.ParseFloat(h, 64); err == nil {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(h); err == nil {
+			return time.Until(t)
+		}
+	}
+
+	var body struct {
+		RetryAfter   float64 `json:"retry_after"`
+		RetryAfterMS float64 `json:"retry_after_ms"`
+		Parameters   struct {
+			RetryAfter float64 `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body) == nil {
+		if body.RetryAfter > 0 {
+			return time.Duration(body.RetryAfter * float64(time.Second))
+		}
+		if body.RetryAfterMS > 0 {
+			return time.Duration(body.RetryAfterMS * float64(time.Millisecond))
+		}
+		return time.Duration(body.Parameters.RetryAfter * float64(time.Second))
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package alerts
+
+import (
+	"fmt"
+	"status/app/internal/database"
+)
+
+// CheckContentChange compares a freshly computed body hash with the last one recorded
+// for the service. The first hash is stored as a baseline; any later difference is
+// recorded in the content history and raised as a "changed" alert.
+func (m *Manager) CheckContentChange(serviceKey, serviceName, hash string) {
+	if hash == "" {
+		return
+	}
+
+	prev, err := database.GetLatestContentHash(serviceKey)
+	if err != nil || prev == hash {
+		return
+	}
+
+	if err := database.RecordContentHash(serviceKey, hash); err != nil {
+		_ = database.InsertLog(database.LogLevelError, database.LogCategoryCheck, serviceKey, "Failed to record content hash", err.Error())
+		return
+	}
+
+	if prev == "" {
+		_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryCheck, serviceKey, "Content baseline recorded", "hash="+shortHash(hash))
+		return
+	}
+
+	_ = database.InsertLog(database.LogLevelWarn, database.LogCategoryCheck, serviceKey, "Service content CHANGED",
+		fmt.Sprintf("previous=%s, current=%s", shortHash(prev), shortHash(hash)))
+
+	if m.config == nil || !m.config.Enabled {
+		return
+	}
+	svc, _ := database.GetServiceByKey(serviceKey)
+	if !m.eventEnabled(svc, EventChanged) {
+		return
+	}
+	data := m.templateData(svc, EventChanged, serviceKey, serviceName)
+	data.OldHash, data.NewHash = shortHash(prev), shortHash(hash)
+	m.dispatchAll(svc, EventChanged, data)
+}
+
+// shortHash abbreviates a hex digest for log lines and messages.
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
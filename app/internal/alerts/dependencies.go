@@ -0,0 +1,92 @@
+package alerts
+
+import (
+	"status/app/internal/database"
+	"status/app/internal/topology"
+	"sync"
+)
+
+// dependencyCache holds the depends_on graph and the services last recorded as down, so
+// dependency suppression does not reload every service on every check. It is rebuilt when
+// the services version changes; status updates keep the down set current in between.
+type dependencyCache struct {
+	mu      sync.Mutex
+	loaded  bool
+	version int64
+	graph   *topology.Graph
+	down    map[string]bool
+}
+
+// load rebuilds the cache if the services changed since it was built. Callers hold c.mu.
+func (c *dependencyCache) load() {
+	version := database.ServicesVersion()
+	if c.loaded && c.version == version {
+		return
+	}
+	services, _ := database.GetAllServices()
+	c.graph = topology.New(services)
+	c.down = make(map[string]bool)
+	rows, err := database.DB.Query(`SELECT service_key FROM service_status_history WHERE ok = 0`)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var key string
+			if rows.Scan(&key) == nil {
+				c.down[key] = true
+			}
+		}
+	}
+	c.loaded, c.version = true, version
+}
+
+// dependencyGraph returns the depends_on graph of all services
+func (m *Manager) dependencyGraph() *topology.Graph {
+	m.deps.mu.Lock()
+	defer m.deps.mu.Unlock()
+	m.deps.load()
+	return m.deps.graph
+}
+
+// setDown records a service's latest status in the cached down set
+func (m *Manager) setDown(serviceKey string, down bool) {
+	m.deps.mu.Lock()
+	defer m.deps.mu.Unlock()
+	if !m.deps.loaded {
+		return // The first load reads the status history, including this update
+	}
+	if down {
+		m.deps.down[serviceKey] = true
+	} else {
+		delete(m.deps.down, serviceKey)
+	}
+}
+
+// downServices returns the services last recorded as down
+func (m *Manager) downServices() map[string]bool {
+	down := make(map[string]bool)
+	if m.sim != nil {
+		for key, st := range m.sim.history {
+			if !st.ok {
+				down[key] = true
+			}
+		}
+		return down
+	}
+	m.deps.mu.Lock()
+	defer m.deps.mu.Unlock()
+	m.deps.load()
+	for key := range m.deps.down {
+		down[key] = true
+	}
+	return down
+}
+
+// upstreamRootCauses returns the down services, directly or transitively upstream of
+// serviceKey, that explain its outage
+func (m *Manager) upstreamRootCauses(serviceKey string) []string {
+	down := m.downServices()
+	if len(down) == 0 {
+		return nil
+	}
+	return m.dependencyGraph().RootCauses(serviceKey, func(k string) bool { return down[k] })
+}
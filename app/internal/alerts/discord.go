@@ -3,42 +3,48 @@ package alerts
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
-	"net/http"
-	"status/app/internal/database"
+	"status/app/internal/models"
 	"strings"
 	"time"
 )
 
-// SendDiscord sends a rich embed message via Discord webhook
+// SendDiscord sends a rich embed message via the legacy Discord webhook setting
 func (m *Manager) SendDiscord(subject, statusType, serviceName, message, statusPageURL string) {
-	colorMap := map[string]int{"down": 0xef4444, "degraded": 0xeab308, "up": 0x22c55e}
-	color := colorMap[statusType]
+	ch := models.NotificationChannel{Type: models.ChannelDiscord, Name: "Discord",
+		Settings: map[string]string{"webhook_url": m.config.DiscordWebhookURL}}
+	_ = m.Deliver(ch, Notification{Subject: subject, StatusType: statusType, ServiceName: serviceName, Message: message, StatusPageURL: statusPageURL})
+}
+
+// sendDiscord posts a rich embed to a Discord webhook
+func sendDiscord(settings map[string]string, n Notification) error {
+	fields := []map[string]interface{}{
+		{"name": "Service", "value": n.ServiceName, "inline": true},
+		{"name": "Status", "value": statusLabel(n.StatusType), "inline": true},
+		{"name": "Time", "value": time.Now().Format(time.RFC1123), "inline": false},
+	}
+	if n.AckURL != "" {
+		fields = append(fields, map[string]interface{}{"name": "Acknowledge", "value": "[Acknowledge this outage](" + n.AckURL + ")", "inline": false})
+	}
 
 	payload := map[string]interface{}{
 		"username":   "Servicarr",
 		"avatar_url": "https://raw.githubusercontent.com/JeKaQM/Servicarr_/main/web/static/images/icon.png",
 		"embeds": []map[string]interface{}{
 			{
-				"title":       subject,
-				"description": strings.ReplaceAll(strings.ReplaceAll(message, "<strong>", "**"), "</strong>", "**"),
-				"color":       color,
-				"fields": []map[string]interface{}{
-					{"name": "Service", "value": serviceName, "inline": true},
-					{"name": "Status", "value": strings.ToUpper(statusType), "inline": true},
-					{"name": "Time", "value": time.Now().Format(time.RFC1123), "inline": false},
-				},
-				"footer": map[string]string{"text": "Servicarr Status Monitor"},
+				"title":       n.Subject,
+				"description": strings.ReplaceAll(strings.ReplaceAll(n.Message, "<strong>", "**"), "</strong>", "**"),
+				"color":       statusColors[n.StatusType],
+				"fields":      fields,
+				"footer":      map[string]string{"text": "Servicarr Status Monitor"},
 			},
 		},
 	}
 
 	body, _ := json.Marshal(payload)
-	resp, err := http.Post(m.config.DiscordWebhookURL, "application/json", bytes.NewReader(body))
+	resp, err := channelHTTPClient.Post(settings["webhook_url"], "application/json", bytes.NewReader(body))
 	if err != nil {
-		_ = database.InsertLog(database.LogLevelError, "notification", serviceName, "Discord notification failed", err.Error())
-		return
+		return err
 	}
 	defer resp.Body.Close()
-	_ = database.InsertLog(database.LogLevelInfo, "notification", serviceName, "Discord notification sent", fmt.Sprintf("status=%d", resp.StatusCode))
+	return checkResponse(resp)
 }
@@ -1,17 +1,28 @@
 package alerts
 
 import (
+	"bytes"
+	"crypto/rand"
 	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"html"
 	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/smtp"
+	"net/textproto"
+	"slices"
 	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// SendEmail sends an email alert
+// SendEmail sends an email alert using the legacy SMTP settings
 func (m *Manager) SendEmail(subject, body string) error {
 	if m.config == nil || !m.config.Enabled {
 		return nil
@@ -21,104 +32,261 @@ func (m *Manager) SendEmail(subject, body string) error {
 		return errors.New("SMTP configuration incomplete")
 	}
 
-	from := m.config.FromEmail
-	if from == "" {
-		from = m.config.SMTPUser
+	var err error
+	for _, ch := range database.LegacyChannels(m.config) {
+		if ch.Type == models.ChannelEmail {
+			err = sendEmail(ch.Settings, emailMessage{Subject: subject, HTML: body, Text: subject})
+		}
+	}
+
+	// Log email send attempt
+	if err != nil {
+		_ = database.InsertLog(database.LogLevelError, database.LogCategoryEmail, "", "Failed to send email", fmt.Sprintf("to=%s, subject=%s, error=%v", m.config.AlertEmail, subject, err))
+	} else {
+		_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, "", "Email sent successfully", fmt.Sprintf("to=%s, subject=%s", m.config.AlertEmail, subject))
+	}
+
+	return err
+}
+
+// smtpDialTimeout bounds connecting to the SMTP server, and smtpSessionTimeout the conversation
+// that follows (EHLO, STARTTLS, AUTH and DATA). Together they stay well within the outbox
+// delivery lease, so a stalled server cannot get an email claimed and sent twice.
+const smtpDialTimeout = 15 * time.Second
+
+var smtpSessionTimeout = 60 * time.Second
+
+// SMTP connection security, the "smtp_security" setting of email channels
+const (
+	SMTPSecurityAuto     = ""         // Implicit TLS on port 465, otherwise STARTTLS when the server offers it
+	SMTPSecurityStartTLS = "starttls" // Plain connection upgraded with STARTTLS, which the server must offer
+	SMTPSecurityTLS      = "tls"      // Implicit TLS (SMTPS)
+	SMTPSecurityNone     = "none"     // No encryption, for relays on a trusted network
+)
+
+// SMTPSecurityModes lists the accepted smtp_security values
+var SMTPSecurityModes = []string{SMTPSecurityAuto, SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone}
+
+// emailMessage is an email sent through an email channel
+type emailMessage struct {
+	Subject   string
+	HTML      string
+	Text      string // Plain-text alternative to the HTML body
+	MessageID string // Generated when empty
+	InReplyTo string // Message-ID of the email this one follows up, for threading
+}
+
+// sendEmailNotification emails a notification as HTML with a plain-text alternative. The
+// first down email of an outage is remembered per recipient list; repeats, escalations and
+// the recovery reply to it so mail clients thread them together.
+func sendEmailNotification(settings map[string]string, n Notification) error {
+	msg := emailMessage{
+		Subject:   n.Subject,
+		HTML:      createHTMLEmail(n.Subject, n.StatusType, n.ServiceName, n.ServiceKey, n.Message, n.StatusPageURL, n.AckURL),
+		Text:      plainEmail(n),
+		MessageID: emailMessageID(settings, n.DeliveryID),
+	}
+	if n.ServiceKey == "" {
+		return sendEmail(settings, msg)
 	}
 
-	// Create MIME message with HTML
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = m.config.AlertEmail
-	headers["Subject"] = mime.QEncoding.Encode("utf-8", subject)
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = "text/html; charset=UTF-8"
+	scope := "email:" + strings.Join(splitAddresses(settings["to"]), ",")
+	root, _ := database.GetNotificationThread(scope, n.ServiceKey)
+	startsOutage := n.Event == EventDown || (n.StatusType == EventDown && root == "")
+	if root != "" && !startsOutage && (n.StatusType == EventDown || n.StatusType == EventUp) {
+		msg.InReplyTo = root
+	}
 
-	var msg string
-	for k, v := range headers {
-		msg += fmt.Sprintf("%s: %s\r\n", k, v)
+	if err := sendEmail(settings, msg); err != nil {
+		return err
 	}
-	msg += "\r\n" + body
+	switch {
+	case startsOutage:
+		_ = database.SaveNotificationThread(scope, n.ServiceKey, msg.MessageID)
+	case n.StatusType == EventUp && root != "":
+		_ = database.DeleteNotificationThread(scope, n.ServiceKey)
+	}
+	return nil
+}
 
-	host := m.config.SMTPHost
-	port := m.config.SMTPPort
-	addr := fmt.Sprintf("%s:%d", host, port)
+// plainEmail is the plain-text body of a notification email
+func plainEmail(n Notification) string {
+	text := n.Subject + "\n\n" + plainMessage(n.Message)
+	if n.StatusPageURL != "" {
+		text += "\n\nStatus: " + n.StatusPageURL
+	}
+	if n.AckURL != "" {
+		text += "\nAcknowledge: " + n.AckURL
+	}
+	return text + "\n\n" + time.Now().Format("Monday, January 2, 2006 at 3:04 PM MST") + "\n"
+}
 
-	c, err := dialSMTP(addr, host, port, m.config.SMTPSkipVerify)
-	if err == nil {
-		auth := smtp.PlainAuth("", m.config.SMTPUser, m.config.SMTPPassword, host)
-		if ok, _ := c.Extension("AUTH"); ok && m.config.SMTPUser != "" {
-			if authErr := c.Auth(auth); authErr != nil {
-				_ = c.Close()
-				err = authErr
-			}
-		}
+// emailMessageID derives the Message-ID from the outbox delivery so retries of a delivery
+// share it; direct sends get a random one. The domain is taken from the sender address.
+func emailMessageID(settings map[string]string, deliveryID int64) string {
+	domain := "servicarr.local"
+	if _, d, ok := strings.Cut(emailSender(settings), "@"); ok && d != "" {
+		domain = strings.Trim(d, "<> ")
+	}
+	if deliveryID > 0 {
+		return fmt.Sprintf("<servicarr-%d@%s>", deliveryID, domain)
 	}
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<servicarr-%s@%s>", hex.EncodeToString(b), domain)
+}
 
-	if err == nil {
-		if mailErr := c.Mail(from); mailErr != nil {
-			_ = c.Close()
-			err = mailErr
-		}
+// emailSender is the channel's From address, falling back to the SMTP user
+func emailSender(settings map[string]string) string {
+	if from := strings.TrimSpace(settings["from_email"]); from != "" {
+		return from
 	}
+	return settings["smtp_user"]
+}
 
-	if err == nil {
-		if rcptErr := c.Rcpt(m.config.AlertEmail); rcptErr != nil {
-			_ = c.Close()
-			err = rcptErr
+// composeEmail renders a multipart/alternative message with its headers in a fixed order.
+// Bcc recipients are left out of the headers.
+func composeEmail(from string, to, cc []string, msg emailMessage, date time.Time) []byte {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		pw, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		qp := quotedprintable.NewWriter(pw)
+		_, _ = qp.Write([]byte(part.content))
+		_ = qp.Close()
+	}
+	_ = mw.Close()
+
+	var b bytes.Buffer
+	header := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", name, value)
 		}
 	}
+	header("Date", date.Format(time.RFC1123Z))
+	header("From", from)
+	header("To", strings.Join(to, ", "))
+	header("Cc", strings.Join(cc, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Message-ID", msg.MessageID)
+	header("In-Reply-To", msg.InReplyTo)
+	header("References", msg.InReplyTo)
+	header("MIME-Version", "1.0")
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()))
+	b.WriteString("\r\n")
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
 
-	if err == nil {
-		w, dataErr := c.Data()
-		if dataErr != nil {
-			_ = c.Close()
-			err = dataErr
-		} else {
-			_, writeErr := w.Write([]byte(msg))
-			closeErr := w.Close()
-			if writeErr != nil {
-				err = writeErr
-			} else if closeErr != nil {
-				err = closeErr
-			}
-			_ = c.Quit()
+// sendEmail delivers an email using email channel settings. The "to", "cc" and "bcc"
+// settings may each hold several comma-separated addresses.
+func sendEmail(settings map[string]string, msg emailMessage) error {
+	host := settings["smtp_host"]
+	to, cc, bcc := splitAddresses(settings["to"]), splitAddresses(settings["cc"]), splitAddresses(settings["bcc"])
+	if host == "" || len(to) == 0 {
+		return errors.New("SMTP configuration incomplete")
+	}
+	security := settings["smtp_security"]
+	if !slices.Contains(SMTPSecurityModes, security) {
+		return fmt.Errorf("unknown SMTP security %q", security)
+	}
+
+	port, _ := strconv.Atoi(settings["smtp_port"])
+	if port == 0 {
+		port = 587
+		if security == SMTPSecurityTLS {
+			port = 465
 		}
 	}
+	user := settings["smtp_user"]
+	skipVerify, _ := strconv.ParseBool(settings["smtp_skip_verify"])
+	from := emailSender(settings)
+	if msg.MessageID == "" {
+		msg.MessageID = emailMessageID(settings, 0)
+	}
+	data := composeEmail(from, to, cc, msg, time.Now())
 
-	// Log email send attempt
+	c, err := dialSMTP(fmt.Sprintf("%s:%d", host, port), host, port, security, skipVerify)
 	if err != nil {
-		_ = database.InsertLog(database.LogLevelError, database.LogCategoryEmail, "", "Failed to send email", fmt.Sprintf("to=%s, subject=%s, error=%v", m.config.AlertEmail, subject, err))
-	} else {
-		_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, "", "Email sent successfully", fmt.Sprintf("to=%s, subject=%s", m.config.AlertEmail, subject))
+		return err
 	}
+	defer c.Close()
 
-	return err
+	if ok, _ := c.Extension("AUTH"); ok && user != "" {
+		if err := c.Auth(smtp.PlainAuth("", user, settings["smtp_password"], host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range slices.Concat(to, cc, bcc) {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// splitAddresses splits a comma-separated address list, dropping blanks
+func splitAddresses(list string) []string {
+	var out []string
+	for _, addr := range strings.Split(list, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
 }
 
 // CreateHTMLEmail generates a styled HTML email
 func CreateHTMLEmail(subject, statusType, serviceName, serviceKey, message, statusPageURL string) string {
-	// Status colors and text
-	statusColors := map[string]string{
-		"down":     "#ef4444",
-		"degraded": "#eab308",
-		"up":       "#22c55e",
-	}
-	statusTexts := map[string]string{
-		"down":     "SERVICE DOWN",
-		"degraded": "SERVICE DEGRADED",
-		"up":       "SERVICE UP",
-	}
+	return createHTMLEmail(subject, statusType, serviceName, serviceKey, message, statusPageURL, "")
+}
+
+// statusHeadlines are the banner texts alerts are headed with in rich (HTML) channels
+var statusHeadlines = map[string]string{
+	"down":     "SERVICE DOWN",
+	"degraded": "SERVICE DEGRADED",
+	"up":       "SERVICE UP",
+	"changed":  "CONTENT CHANGED",
+}
 
-	color := statusColors[statusType]
-	statusText := statusTexts[statusType]
+// createHTMLEmail is CreateHTMLEmail with an optional acknowledgement button linking to ackURL
+func createHTMLEmail(subject, statusType, serviceName, serviceKey, message, statusPageURL, ackURL string) string {
+	color := fmt.Sprintf("#%06x", statusColors[statusType])
+	statusText := statusHeadlines[statusType]
 
 	// Default URL if not set
 	if statusPageURL == "" {
 		statusPageURL = "#"
 	}
 
-	html := fmt.Sprintf(`<!DOCTYPE html>
+	ackButton := ""
+	if ackURL != "" {
+		ackButton = fmt.Sprintf(`
+                <a href="%s" style="display:inline-block; margin-left:8px; background-color:transparent; color:#e5e7eb; text-decoration:none; padding:11px 21px; border:1px solid #374151; border-radius:10px; font-weight:700; font-size:13px; letter-spacing:0.3px;">
+                  Acknowledge
+                </a>`, html.EscapeString(ackURL))
+	}
+
+	page := fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head>
   <meta charset="UTF-8">
@@ -173,7 +341,7 @@ func CreateHTMLEmail(subject, statusType, serviceName, serviceKey, message, stat
               <div style="text-align:center;">
                 <a href="%s" style="display:inline-block; background-color:#22c55e; color:#0c121c; text-decoration:none; padding:12px 22px; border-radius:10px; font-weight:700; font-size:13px; letter-spacing:0.3px;">
                   View Status Dashboard
-                </a>
+                </a>%s
               </div>
             </td>
           </tr>
@@ -188,44 +356,58 @@ func CreateHTMLEmail(subject, statusType, serviceName, serviceKey, message, stat
     </tr>
   </table>
 </body>
-</html>`, subject, message, statusText, subject, message, serviceName, color, statusText, time.Now().Format("Monday, January 2, 2006 at 3:04 PM MST"), statusPageURL)
+</html>`, subject, message, statusText, subject, message, serviceName, color, statusText, time.Now().Format("Monday, January 2, 2006 at 3:04 PM MST"), statusPageURL, ackButton)
 
-	return html
+	return page
 }
 
-func dialSMTP(addr, host string, port int, skipVerify bool) (*smtp.Client, error) {
+// dialSMTP connects to the SMTP server with the channel's connection security
+func dialSMTP(addr, host string, port int, security string, skipVerify bool) (*smtp.Client, error) {
 	tlsConfig := &tls.Config{
 		ServerName:         host,
 		InsecureSkipVerify: skipVerify,
 	}
 
 	// Implicit TLS for SMTPS (commonly port 465)
-	if port == 465 {
-		conn, err := tls.Dial("tcp", addr, tlsConfig)
+	if security == SMTPSecurityTLS || (security == SMTPSecurityAuto && port == 465) {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: smtpDialTimeout}, "tcp", addr, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		_ = conn.SetDeadline(time.Now().Add(smtpSessionTimeout))
+		c, err := smtp.NewClient(conn, host)
 		if err != nil {
+			_ = conn.Close()
 			return nil, err
 		}
-		return smtp.NewClient(conn, host)
+		return c, nil
 	}
 
-	// Plain TCP + STARTTLS if supported
-	conn, err := net.Dial("tcp", addr)
+	conn, err := net.DialTimeout("tcp", addr, smtpDialTimeout)
 	if err != nil {
 		return nil, err
 	}
-
+	_ = conn.SetDeadline(time.Now().Add(smtpSessionTimeout))
 	c, err := smtp.NewClient(conn, host)
 	if err != nil {
 		_ = conn.Close()
 		return nil, err
 	}
+	if security == SMTPSecurityNone {
+		return c, nil
+	}
 
-	if ok, _ := c.Extension("STARTTLS"); ok {
+	// STARTTLS when offered; required when chosen explicitly
+	ok, _ := c.Extension("STARTTLS")
+	if !ok && security == SMTPSecurityStartTLS {
+		_ = c.Close()
+		return nil, errors.New("SMTP server does not support STARTTLS")
+	}
+	if ok {
 		if err := c.StartTLS(tlsConfig); err != nil {
 			_ = c.Close()
 			return nil, err
 		}
 	}
-
 	return c, nil
 }
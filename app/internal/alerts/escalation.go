@@ -0,0 +1,189 @@
+package alerts
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyTimeLayout matches SQLite's datetime('now'), which service_status_history uses
+const historyTimeLayout = "2006-01-02 15:04:05"
+
+// outageState is the ongoing-outage bookkeeping kept in service_status_history
+type outageState struct {
+	DownSince    time.Time
+	LastNotified time.Time
+	Escalated    bool
+}
+
+// ValidateEscalation checks the repeat/escalation policy and normalises its channel list
+func ValidateEscalation(config *models.AlertConfig) error {
+	if config.RepeatIntervalMinutes < 0 {
+		return fmt.Errorf("repeat interval cannot be negative")
+	}
+	if config.EscalateAfterMinutes < 0 {
+		return fmt.Errorf("escalation delay cannot be negative")
+	}
+
+	ids := splitList(config.EscalationChannels)
+	for _, idStr := range ids {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid escalation channel ID %q", idStr)
+		}
+		if ch, err := database.GetNotificationChannel(id); err != nil || ch == nil {
+			return fmt.Errorf("notification channel %d does not exist", id)
+		}
+	}
+	config.EscalationChannels = strings.Join(ids, ",")
+	return nil
+}
+
+// escalationChannels returns the enabled channels configured to receive escalations
+func (m *Manager) escalationChannels() []models.NotificationChannel {
+	ids := splitList(m.config.EscalationChannels)
+	if len(ids) == 0 {
+		return nil
+	}
+	var out []models.NotificationChannel
+	for _, ch := range m.activeChannels() {
+		if ch.ID != 0 && slices.Contains(ids, strconv.Itoa(ch.ID)) {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// mergeChannels appends the channels in extra that are not already in base
+func mergeChannels(base, extra []models.NotificationChannel) []models.NotificationChannel {
+	out := append([]models.NotificationChannel(nil), base...)
+	for _, ch := range extra {
+		if !slices.ContainsFunc(out, func(c models.NotificationChannel) bool { return c.ID == ch.ID }) {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// loadOutageState reads a service's outage timestamps; ok is false when it is not down
+func (m *Manager) loadOutageState(serviceKey string) (outageState, bool) {
+	if m.sim != nil {
+		st := m.sim.history[serviceKey]
+		if st == nil || st.ok || st.downSince.IsZero() {
+			return outageState{}, false
+		}
+		return outageState{DownSince: st.downSince, LastNotified: st.lastNotified, Escalated: !st.escalatedAt.IsZero()}, true
+	}
+	var downSince, lastNotified, escalatedAt sql.NullString
+	err := database.DB.QueryRow(`SELECT down_since, last_notified_at, escalated_at
+		FROM service_status_history WHERE service_key = ? AND ok = 0`, serviceKey).
+		Scan(&downSince, &lastNotified, &escalatedAt)
+	if err != nil || !downSince.Valid {
+		return outageState{}, false
+	}
+
+	st := outageState{Escalated: escalatedAt.Valid}
+	st.DownSince, err = time.ParseInLocation(historyTimeLayout, downSince.String, time.UTC)
+	if err != nil {
+		return outageState{}, false
+	}
+	if lastNotified.Valid {
+		st.LastNotified, _ = time.ParseInLocation(historyTimeLayout, lastNotified.String, time.UTC)
+	}
+	return st, true
+}
+
+// markOutageNotified records that an alert for the current outage was just sent
+func (m *Manager) markOutageNotified(serviceKey string, escalated bool) {
+	if m.sim != nil {
+		if st := m.sim.history[serviceKey]; st != nil {
+			st.lastNotified = m.now()
+			if escalated {
+				st.escalatedAt = st.lastNotified
+			}
+		}
+		return
+	}
+	if escalated {
+		_, _ = database.DB.Exec(`UPDATE service_status_history SET last_notified_at = datetime('now'), escalated_at = datetime('now')
+			WHERE service_key = ?`, serviceKey)
+		return
+	}
+	_, _ = database.DB.Exec(`UPDATE service_status_history SET last_notified_at = datetime('now') WHERE service_key = ?`, serviceKey)
+}
+
+// checkOngoingOutage applies the repeat and escalation policy to a service that is still down.
+// Once escalated, repeats also go to the escalation channels; acknowledging the incident stops both.
+// Channels in quiet hours are skipped, and an outage whose channels are all quiet is not marked
+// as notified, so the repeat clock only moves when someone was actually told.
+func (m *Manager) checkOngoingOutage(svc *models.ServiceConfig, serviceKey, serviceName string, now time.Time) {
+	if m.config.RepeatIntervalMinutes <= 0 && m.config.EscalateAfterMinutes <= 0 {
+		return
+	}
+	if !m.eventEnabled(svc, EventDown) || incidentAcknowledged(serviceKey) {
+		return
+	}
+	st, down := m.loadOutageState(serviceKey)
+	if !down {
+		return
+	}
+	downFor := now.Sub(st.DownSince)
+	critical := svc != nil && svc.Critical
+
+	escalation := m.escalationChannels()
+	if audible := m.audibleChannels(escalation, TemplateEscalation, critical, now); !st.Escalated &&
+		m.config.EscalateAfterMinutes > 0 && len(audible) > 0 &&
+		downFor >= time.Duration(m.config.EscalateAfterMinutes)*time.Minute {
+		m.logAlert(database.LogLevelError, serviceKey, "Outage unacknowledged - escalating alert",
+			fmt.Sprintf("%s, down for %s", serviceName, formatDowntime(downFor)))
+		data := m.templateData(svc, TemplateEscalation, serviceKey, serviceName)
+		data.DownFor = formatDowntime(downFor)
+		m.dispatchTo(mergeChannels(m.audibleChannels(m.channelsFor(svc), TemplateEscalation, critical, now), audible),
+			TemplateEscalation, data)
+		m.markOutageNotified(serviceKey, true)
+		return
+	}
+
+	if m.config.RepeatIntervalMinutes <= 0 {
+		return
+	}
+	last := st.LastNotified
+	if last.IsZero() {
+		last = st.DownSince
+	}
+	if now.Sub(last) < time.Duration(m.config.RepeatIntervalMinutes)*time.Minute {
+		return
+	}
+
+	recipients := m.channelsFor(svc)
+	if st.Escalated {
+		recipients = mergeChannels(recipients, escalation)
+	}
+	recipients = m.audibleChannels(recipients, TemplateRepeat, critical, now)
+	if len(recipients) == 0 {
+		return
+	}
+	m.logAlert(database.LogLevelError, serviceKey, "Service still DOWN - repeating alert",
+		fmt.Sprintf("%s, down for %s", serviceName, formatDowntime(downFor)))
+	data := m.templateData(svc, TemplateRepeat, serviceKey, serviceName)
+	data.DownFor = formatDowntime(downFor)
+	m.dispatchTo(recipients, TemplateRepeat, data)
+	m.markOutageNotified(serviceKey, false)
+}
+
+// formatDowntime renders an outage duration as e.g. "45m" or "2h 5m"
+func formatDowntime(d time.Duration) string {
+	mins := int(d.Round(time.Minute) / time.Minute)
+	if mins < 60 {
+		return fmt.Sprintf("%dm", mins)
+	}
+	if mins%60 == 0 {
+		return fmt.Sprintf("%dh", mins/60)
+	}
+	return fmt.Sprintf("%dh %dm", mins/60, mins%60)
+}
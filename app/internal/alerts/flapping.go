@@ -0,0 +1,116 @@
+package alerts
+
+import (
+	"database/sql"
+	"fmt"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/stats"
+	"time"
+)
+
+// How flapping detection affects a status check
+const (
+	flapNone     = iota // Not flapping: alert as usual
+	flapSuppress        // Flapping: the flapping alert has been sent, skip individual transitions
+	flapSettled         // Just stopped flapping: report the status the service settled on
+)
+
+// ValidateFlapping checks the flapping thresholds. Window and stable period are required
+// once a threshold is set.
+func ValidateFlapping(config *models.AlertConfig) error {
+	if config.FlapThreshold < 0 || config.FlapWindowMinutes < 0 || config.FlapStableMinutes < 0 {
+		return fmt.Errorf("flapping settings cannot be negative")
+	}
+	if config.FlapThreshold == 0 {
+		return nil
+	}
+	if config.FlapWindowMinutes == 0 || config.FlapStableMinutes == 0 {
+		return fmt.Errorf("flapping detection needs a window and a stable period")
+	}
+	return nil
+}
+
+// applyFlapPolicy hands the configured flapping thresholds to the uptime calculators
+func applyFlapPolicy(config *models.AlertConfig) {
+	if config == nil {
+		stats.SetFlapPolicy(stats.FlapPolicy{})
+		return
+	}
+	stats.SetFlapPolicy(stats.FlapPolicy{
+		Threshold: config.FlapThreshold,
+		Window:    time.Duration(config.FlapWindowMinutes) * time.Minute,
+		Stable:    time.Duration(config.FlapStableMinutes) * time.Minute,
+	})
+}
+
+// flappingMarked reports whether a flapping alert has been sent for the service's current episode
+func (m *Manager) flappingMarked(serviceKey string) bool {
+	if m.sim != nil {
+		st := m.sim.history[serviceKey]
+		return st != nil && !st.flappingSince.IsZero()
+	}
+	var since sql.NullString
+	err := database.DB.QueryRow(`SELECT flapping_since FROM service_status_history WHERE service_key = ?`, serviceKey).Scan(&since)
+	return err == nil && since.Valid
+}
+
+// markFlapping records the start or end of a flapping episode
+func (m *Manager) markFlapping(serviceKey string, flapping bool) {
+	if m.sim != nil {
+		if st := m.sim.history[serviceKey]; st != nil {
+			st.flappingSince = time.Time{}
+			if flapping {
+				st.flappingSince = m.now()
+			}
+		}
+		return
+	}
+	if flapping {
+		_, _ = database.DB.Exec(`UPDATE service_status_history SET flapping_since = datetime('now') WHERE service_key = ?`, serviceKey)
+		return
+	}
+	_, _ = database.DB.Exec(`UPDATE service_status_history SET flapping_since = NULL WHERE service_key = ?`, serviceKey)
+}
+
+// checkFlapping sends a single alert when a service starts flapping and suppresses its
+// transitions until the uptime calculator reports it stable again. changed is whether this
+// check's status differs from the previous one.
+func (m *Manager) checkFlapping(svc *models.ServiceConfig, serviceKey, serviceName string, changed bool) int {
+	policy := stats.GetFlapPolicy()
+	marked := m.flappingMarked(serviceKey)
+	var flapping bool
+	var transitions int
+	if m.sim != nil {
+		transitions, flapping = m.sim.flapState(serviceKey, policy)
+	} else {
+		calc := stats.GetCalculator(serviceKey)
+		flapping = calc.Flapping()
+		transitions = calc.TransitionsSince(m.now().Add(-policy.Window))
+	}
+
+	switch {
+	case flapping && !marked:
+		m.markFlapping(serviceKey, true)
+		m.logAlert(database.LogLevelWarn, serviceKey, "Service FLAPPING - sending alert",
+			fmt.Sprintf("%s, %d state changes in %s", serviceName, transitions, formatDowntime(policy.Window)))
+		if m.eventEnabled(svc, EventFlapping) {
+			data := m.templateData(svc, EventFlapping, serviceKey, serviceName)
+			data.Transitions = transitions
+			data.FlapWindow = formatDowntime(policy.Window)
+			m.dispatchAll(svc, EventFlapping, data)
+		}
+		return flapSuppress
+	case flapping:
+		if changed {
+			m.logAlert(database.LogLevelInfo, serviceKey,
+				"Alert suppressed — service flapping", serviceName)
+		}
+		return flapSuppress
+	case marked:
+		m.markFlapping(serviceKey, false)
+		m.logAlert(database.LogLevelInfo, serviceKey, "Service stopped flapping", serviceName)
+		return flapSettled
+	}
+	return flapNone
+}
@@ -0,0 +1,49 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// gotifyPriorities maps statuses to Gotify priorities (0-10; clients alert loudly from 8)
+var gotifyPriorities = map[string]int{"down": 8, "degraded": 5, "up": 2, "changed": 4}
+
+// sendGotify posts a markdown message to a Gotify server using an application token
+func sendGotify(settings map[string]string, n Notification) error {
+	message := plainMessage(n.Message)
+	if n.AckURL != "" {
+		message += "\n\n[Acknowledge](" + n.AckURL + ")"
+	}
+
+	extras := map[string]interface{}{
+		"client::display": map[string]string{"contentType": "text/markdown"},
+	}
+	if click := clickURL(settings, n); click != "" {
+		extras["client::notification"] = map[string]interface{}{"click": map[string]string{"url": click}}
+	}
+
+	payload := map[string]interface{}{
+		"title":    n.Subject,
+		"message":  message,
+		"priority": gotifyPriorities[n.StatusType],
+		"extras":   extras,
+	}
+
+	body, _ := json.Marshal(payload)
+	url := strings.TrimRight(strings.TrimSpace(settings["server_url"]), "/") + "/message"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", settings["token"])
+
+	resp, err := channelHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
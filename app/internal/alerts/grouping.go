@@ -0,0 +1,221 @@
+package alerts
+
+import (
+	"fmt"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxGroupWindowSeconds caps how long a down alert can be held back for a digest
+const maxGroupWindowSeconds = 3600
+
+// groupedAlert is a down or recovery alert held back for a digest
+type groupedAlert struct {
+	svc  *models.ServiceConfig
+	data TemplateData
+
+	// suppressedBy lists the down upstream services that suppressed this alert when it was
+	// checked. It is held only to be listed as an affected service next to its root cause.
+	suppressedBy []string
+}
+
+// ValidateGrouping checks the outage grouping window
+func ValidateGrouping(config *models.AlertConfig) error {
+	if config.GroupWindowSeconds < 0 {
+		return fmt.Errorf("grouping window cannot be negative")
+	}
+	if config.GroupWindowSeconds > maxGroupWindowSeconds {
+		return fmt.Errorf("grouping window cannot exceed %d seconds", maxGroupWindowSeconds)
+	}
+	return nil
+}
+
+// digestEvent returns the template event a group of down or recovery alerts is sent as
+func digestEvent(event string) string {
+	if event == EventUp {
+		return TemplateDigestUp
+	}
+	return TemplateDigestDown
+}
+
+// notify sends a down or recovery alert. With a grouping window configured, the alert is
+// held and sent together with the other transitions of the same kind within the window.
+func (m *Manager) notify(svc *models.ServiceConfig, event string, data TemplateData) {
+	if m.config == nil || m.config.GroupWindowSeconds <= 0 {
+		m.dispatchAll(svc, event, data)
+		return
+	}
+	m.hold(event, groupedAlert{svc: svc, data: data})
+}
+
+// holdSuppressed adds a down or recovery transition suppressed by a down upstream dependency
+// to the pending group, so a digest lists it as affected whatever order the services were
+// checked in. Without a grouping window nothing is held.
+func (m *Manager) holdSuppressed(svc *models.ServiceConfig, event string, data TemplateData, roots []string) {
+	if m.config == nil || m.config.GroupWindowSeconds <= 0 {
+		return
+	}
+	m.hold(event, groupedAlert{svc: svc, data: data, suppressedBy: roots})
+}
+
+// hold adds an alert to the pending group for its event, starting the window on the first one
+func (m *Manager) hold(event string, item groupedAlert) {
+	m.groupMu.Lock()
+	if m.grouped == nil {
+		m.grouped = make(map[string][]groupedAlert)
+	}
+	first := len(m.grouped[event]) == 0
+	m.grouped[event] = append(m.grouped[event], item)
+	m.groupMu.Unlock()
+
+	window := time.Duration(m.config.GroupWindowSeconds) * time.Second
+	if first && m.sim != nil {
+		m.sim.groupDue[event] = m.now().Add(window)
+	} else if first {
+		time.AfterFunc(window, func() { m.flushGroup(event) })
+	}
+}
+
+// flushGroup sends the alerts held for an event. Services paused by a maintenance window are
+// dropped, and services that depend on another grouped service are never alerted on their own:
+// they are listed in the digests that carry their root cause. Each channel gets the single
+// alert when only one of the remaining services is routed to it, and a digest otherwise.
+func (m *Manager) flushGroup(event string) {
+	m.groupMu.Lock()
+	items := m.grouped[event]
+	delete(m.grouped, event)
+	m.groupMu.Unlock()
+
+	items = slices.DeleteFunc(items, func(item groupedAlert) bool {
+		w, _ := database.GetActiveMaintenance(item.data.ServiceKey)
+		if w != nil {
+			m.logAlert(database.LogLevelInfo, item.data.ServiceKey, "Alert suppressed — service paused",
+				fmt.Sprintf("event=%s, service=%s, until=%s, reason=%s", event, item.data.ServiceName, w.EndTime, w.Title))
+		}
+		return w != nil
+	})
+	upstream := m.groupedUpstream(items)
+	// A suppressed transition whose root cause went down before this window has nothing to join
+	items = slices.DeleteFunc(items, func(item groupedAlert) bool {
+		return len(item.suppressedBy) > 0 && len(upstream[item.data.ServiceKey]) == 0
+	})
+
+	if len(items) == 0 {
+		return
+	}
+	if len(items) == 1 {
+		m.dispatchAll(items[0].svc, event, items[0].data)
+		return
+	}
+
+	type recipient struct {
+		ch    models.NotificationChannel
+		items []groupedAlert
+	}
+	var order []string
+	recipients := make(map[string]*recipient)
+	for _, item := range items {
+		for _, ch := range m.channelsFor(item.svc) {
+			key := strconv.Itoa(ch.ID)
+			if ch.ID == 0 {
+				key = ch.Type + ":" + ch.Name
+			}
+			if recipients[key] == nil {
+				recipients[key] = &recipient{ch: ch}
+				order = append(order, key)
+			}
+			recipients[key].items = append(recipients[key].items, item)
+		}
+	}
+
+	digest := m.digestData(event, items)
+	m.logAlert(database.LogLevelInfo, digest.ServiceKey,
+		fmt.Sprintf("Grouped %d %s alerts into a digest", len(items), strings.ToUpper(event)), digest.Services)
+	for _, key := range order {
+		r := recipients[key]
+		// Dependents only appear next to a root cause routed to the same channel
+		isRoot := func(item groupedAlert) bool { return len(upstream[item.data.ServiceKey]) == 0 }
+		routed := slices.DeleteFunc(slices.Clone(r.items), func(item groupedAlert) bool {
+			return !isRoot(item) && !slices.ContainsFunc(r.items, func(other groupedAlert) bool {
+				return isRoot(other) && slices.Contains(upstream[item.data.ServiceKey], other.data.ServiceKey)
+			})
+		})
+		switch len(routed) {
+		case 0:
+			for _, item := range r.items {
+				m.logAlert(database.LogLevelInfo, item.data.ServiceKey, "Alert suppressed — upstream dependency down",
+					fmt.Sprintf("channel=%s, root_cause=%s", r.ch.Name, strings.Join(upstream[item.data.ServiceKey], ",")))
+			}
+		case 1:
+			m.dispatchTo([]models.NotificationChannel{r.ch}, event, routed[0].data)
+		default:
+			m.dispatchTo([]models.NotificationChannel{r.ch}, digestEvent(event), m.digestData(event, routed))
+		}
+	}
+}
+
+// groupedUpstream maps each grouped service to the other grouped services it depends on,
+// directly or transitively
+func (m *Manager) groupedUpstream(items []groupedAlert) map[string][]string {
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.data.ServiceKey)
+	}
+	graph := m.dependencyGraph()
+	upstream := make(map[string][]string, len(items))
+	for _, item := range items {
+		for _, dep := range graph.Upstream(item.data.ServiceKey) {
+			if slices.Contains(keys, dep) {
+				upstream[item.data.ServiceKey] = append(upstream[item.data.ServiceKey], dep)
+			}
+		}
+	}
+	return upstream
+}
+
+// digestData builds the template data for a digest. Grouped services that others in the
+// group depend on, directly or transitively, without depending on one themselves, are
+// listed first as the root cause.
+func (m *Manager) digestData(event string, items []groupedAlert) TemplateData {
+	upstream := m.groupedUpstream(items)
+	dependsOn := func(item groupedAlert) []string { return upstream[item.data.ServiceKey] }
+
+	var roots, rest []groupedAlert
+	for _, item := range items {
+		explains := slices.ContainsFunc(items, func(other groupedAlert) bool {
+			return slices.Contains(dependsOn(other), item.data.ServiceKey)
+		})
+		if explains && len(dependsOn(item)) == 0 {
+			roots = append(roots, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+
+	names := make([]string, 0, len(items))
+	rootNames := make([]string, 0, len(roots))
+	for _, item := range roots {
+		names = append(names, item.data.ServiceName)
+		rootNames = append(rootNames, item.data.ServiceName)
+	}
+	for _, item := range rest {
+		names = append(names, item.data.ServiceName)
+	}
+
+	data := m.templateData(nil, digestEvent(event), "", fmt.Sprintf("%d services", len(items)))
+	data.DayURL = ""
+	data.Count = len(items)
+	data.Services = strings.Join(names, ", ")
+	data.RootCause = strings.Join(rootNames, ", ")
+	data.Critical = slices.ContainsFunc(items, func(item groupedAlert) bool { return item.data.Critical })
+	if len(roots) == 1 {
+		data.ServiceKey = roots[0].data.ServiceKey
+		data.ServiceURL = roots[0].data.ServiceURL
+		data.DayURL = roots[0].data.DayURL
+	}
+	return data
+}
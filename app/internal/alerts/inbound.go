@@ -0,0 +1,422 @@
+package alerts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strings"
+)
+
+// Inbound webhook sources
+const (
+	InboundAlertmanager = "alertmanager"
+	InboundGrafana      = "grafana"
+	InboundGeneric      = "generic"
+)
+
+// InboundSources lists the accepted inbound webhook sources
+var InboundSources = []string{InboundAlertmanager, InboundGrafana, InboundGeneric}
+
+// Inbound alert severities and statuses
+const (
+	InboundCritical = "critical" // Takes the service down
+	InboundWarning  = "warning"  // Degrades the service
+	InboundFiring   = "firing"
+	InboundResolved = "resolved"
+)
+
+// defaultInboundLabels are the labels checked for a service when none are configured
+const defaultInboundLabels = "service,job"
+
+// minInboundTokenLength keeps inbound webhook tokens from being guessable
+const minInboundTokenLength = 16
+
+// ReceivedAlert is one alert of an inbound webhook payload, before it is mapped to a service
+type ReceivedAlert struct {
+	Fingerprint string
+	Name        string
+	Firing      bool
+	Severity    string // InboundCritical or InboundWarning
+	Summary     string
+	Service     string // Service named explicitly by the payload, if any
+	Labels      map[string]string
+}
+
+// InboundResult summarises an inbound webhook: how many alerts were applied, the services
+// whose state changed and the alerts that matched no service
+type InboundResult struct {
+	Applied   int      `json:"applied"`
+	Services  []string `json:"services"`
+	Unmatched []string `json:"unmatched"`
+}
+
+// ValidateInbound checks the inbound webhook settings and normalises the label list
+func ValidateInbound(config *models.AlertConfig) error {
+	config.InboundToken = strings.TrimSpace(config.InboundToken)
+	if config.InboundToken != "" && len(config.InboundToken) < minInboundTokenLength {
+		return fmt.Errorf("inbound webhook token must be at least %d characters", minInboundTokenLength)
+	}
+	config.InboundLabels = strings.Join(splitList(config.InboundLabels), ",")
+	return nil
+}
+
+// amPayload is the webhook body of Prometheus Alertmanager, which Grafana's unified alerting
+// also sends. The rule fields are legacy Grafana alerting, which reports one rule per call.
+type amPayload struct {
+	Status string `json:"status"`
+	Alerts []struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		Fingerprint string            `json:"fingerprint"`
+	} `json:"alerts"`
+
+	RuleID   int64             `json:"ruleId"`
+	RuleName string            `json:"ruleName"`
+	State    string            `json:"state"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Tags     map[string]string `json:"tags"`
+}
+
+// genericAlert is the body of the generic inbound webhook
+type genericAlert struct {
+	ID       string            `json:"id"`
+	Service  string            `json:"service"`
+	Name     string            `json:"name"`
+	Status   string            `json:"status"` // firing (default) or resolved
+	Severity string            `json:"severity"`
+	Message  string            `json:"message"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// ParseInbound decodes an inbound webhook body from one of the InboundSources
+func ParseInbound(source string, body []byte) ([]ReceivedAlert, error) {
+	switch source {
+	case InboundAlertmanager, InboundGrafana:
+		var p amPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", source, err)
+		}
+		if source == InboundGrafana && len(p.Alerts) == 0 {
+			return parseLegacyGrafana(p), nil
+		}
+		var out []ReceivedAlert
+		for _, a := range p.Alerts {
+			status := a.Status
+			if status == "" {
+				status = p.Status
+			}
+			fingerprint := a.Fingerprint
+			if fingerprint == "" {
+				fingerprint = labelFingerprint(a.Labels)
+			}
+			out = append(out, ReceivedAlert{
+				Fingerprint: fingerprint,
+				Name:        a.Labels["alertname"],
+				Firing:      status == InboundFiring,
+				Severity:    inboundSeverity(a.Labels["severity"]),
+				Summary:     firstNonEmpty(a.Annotations["summary"], a.Annotations["description"], a.Annotations["message"]),
+				Labels:      a.Labels,
+			})
+		}
+		return out, nil
+
+	case InboundGeneric:
+		var list []genericAlert
+		if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+			if err := json.Unmarshal(body, &list); err != nil {
+				return nil, fmt.Errorf("invalid generic payload: %w", err)
+			}
+		} else {
+			var a genericAlert
+			if err := json.Unmarshal(body, &a); err != nil {
+				return nil, fmt.Errorf("invalid generic payload: %w", err)
+			}
+			list = append(list, a)
+		}
+		var out []ReceivedAlert
+		for _, a := range list {
+			status := strings.ToLower(strings.TrimSpace(a.Status))
+			if status != "" && status != InboundFiring && status != InboundResolved {
+				return nil, fmt.Errorf("unknown status %q (expected firing or resolved)", a.Status)
+			}
+			name := firstNonEmpty(a.Name, "webhook")
+			fingerprint := a.ID
+			if fingerprint == "" {
+				fingerprint = a.Service + "/" + name
+			}
+			out = append(out, ReceivedAlert{
+				Fingerprint: fingerprint,
+				Name:        name,
+				Firing:      status != InboundResolved,
+				Severity:    inboundSeverity(a.Severity),
+				Summary:     a.Message,
+				Service:     a.Service,
+				Labels:      a.Labels,
+			})
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unknown inbound source %q", source)
+}
+
+// parseLegacyGrafana reads a legacy Grafana alert: "alerting" fires it and "ok" resolves it.
+// Other states (pending, no_data, paused) do not change anything.
+func parseLegacyGrafana(p amPayload) []ReceivedAlert {
+	if p.State != "alerting" && p.State != "ok" {
+		return nil
+	}
+	name := firstNonEmpty(p.RuleName, p.Title)
+	fingerprint := fmt.Sprintf("rule-%d", p.RuleID)
+	if p.RuleID == 0 {
+		fingerprint = "rule-" + name
+	}
+	return []ReceivedAlert{{
+		Fingerprint: fingerprint,
+		Name:        name,
+		Firing:      p.State == "alerting",
+		Severity:    inboundSeverity(p.Tags["severity"]),
+		Summary:     p.Message,
+		Labels:      p.Tags,
+	}}
+}
+
+// inboundSeverity maps a severity label to critical or warning; anything not clearly a
+// warning is treated as critical
+func inboundSeverity(s string) string {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "warning", "warn", "minor", "low", "info":
+		return InboundWarning
+	}
+	return InboundCritical
+}
+
+// labelFingerprint identifies an alert that carries no fingerprint by its sorted labels
+func labelFingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, labels[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// inboundLabels returns the alert labels checked for a service, in order
+func (m *Manager) inboundLabels() []string {
+	if m.config != nil && m.config.InboundLabels != "" {
+		return splitList(m.config.InboundLabels)
+	}
+	return splitList(defaultInboundLabels)
+}
+
+// inboundService maps an alert to a service key: the service the payload names, else the
+// first of the labels whose value is a service key or name. It returns "" when none matches.
+func inboundService(a ReceivedAlert, labels []string, services []models.ServiceConfig) string {
+	candidates := []string{a.Service}
+	if a.Service == "" {
+		for _, l := range labels {
+			candidates = append(candidates, a.Labels[l])
+		}
+	}
+	for _, c := range candidates {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		for _, s := range services {
+			if s.Key == c {
+				return s.Key
+			}
+		}
+		for _, s := range services {
+			if strings.EqualFold(s.Key, c) || strings.EqualFold(s.Name, c) {
+				return s.Key
+			}
+		}
+	}
+	return ""
+}
+
+// ReceiveInbound records the alerts of an inbound webhook and applies them to the services
+// they map to. service, when set, maps every alert to that service. checkOK reports whether
+// a service's own checks currently pass, so resolving its last alert hands it back to them.
+func (m *Manager) ReceiveInbound(source, service string, received []ReceivedAlert, checkOK func(serviceKey string) bool) (InboundResult, error) {
+	res := InboundResult{Services: []string{}, Unmatched: []string{}}
+	services, err := database.GetAllServices()
+	if err != nil {
+		return res, err
+	}
+
+	labels := m.inboundLabels()
+	for _, a := range received {
+		if service != "" {
+			a.Service = service
+		}
+		key := inboundService(a, labels, services)
+		if key == "" {
+			res.Unmatched = append(res.Unmatched, firstNonEmpty(a.Name, a.Fingerprint))
+			continue
+		}
+		changed, err := m.recordInbound(source, key, a)
+		if err != nil {
+			return res, err
+		}
+		res.Applied++
+		for _, k := range changed {
+			if !slices.Contains(res.Services, k) {
+				res.Services = append(res.Services, k)
+			}
+		}
+	}
+
+	if len(res.Unmatched) > 0 {
+		_ = database.InsertLog(database.LogLevelWarn, database.LogCategoryEmail, source, "Inbound alerts matched no service",
+			strings.Join(res.Unmatched, ", "))
+	}
+	for _, key := range res.Services {
+		m.applyInbound(key, checkOK(key))
+	}
+	return res, nil
+}
+
+// recordInbound saves an alert's new status, posting or removing its banner, and returns
+// the services whose state it may have changed. A resolved alert that was not firing is ignored.
+func (m *Manager) recordInbound(source, serviceKey string, a ReceivedAlert) ([]string, error) {
+	prev, err := database.GetInboundAlert(source, a.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	wasFiring := prev != nil && prev.Status == InboundFiring
+	if !a.Firing && !wasFiring {
+		return nil, nil
+	}
+
+	rec := models.InboundAlert{
+		Source:      source,
+		Fingerprint: a.Fingerprint,
+		ServiceKey:  serviceKey,
+		Name:        a.Name,
+		Severity:    a.Severity,
+		Status:      InboundResolved,
+		Summary:     a.Summary,
+	}
+	if a.Firing {
+		rec.Status = InboundFiring
+	}
+	if wasFiring {
+		rec.StatusAlertID = prev.StatusAlertID
+	}
+
+	switch {
+	case a.Firing && rec.StatusAlertID == "" && m.config != nil && m.config.InboundBanners:
+		level := "error"
+		if a.Severity == InboundWarning {
+			level = "warning"
+		}
+		if id, err := database.CreateStatusBanner(serviceKey, describeInbound(rec), level); err == nil {
+			rec.StatusAlertID = id
+		}
+	case !a.Firing && rec.StatusAlertID != "":
+		_ = database.DeleteStatusBanner(rec.StatusAlertID)
+		rec.StatusAlertID = ""
+	}
+	if err := database.SaveInboundAlert(&rec); err != nil {
+		return nil, err
+	}
+
+	level, message := database.LogLevelWarn, "Inbound alert FIRING"
+	if !a.Firing {
+		level, message = database.LogLevelInfo, "Inbound alert RESOLVED"
+	}
+	_ = database.InsertLog(level, database.LogCategoryEmail, serviceKey, message,
+		fmt.Sprintf("source=%s, severity=%s, %s", source, rec.Severity, describeInbound(rec)))
+
+	changed := []string{serviceKey}
+	if wasFiring && prev.ServiceKey != serviceKey {
+		changed = append(changed, prev.ServiceKey)
+	}
+	return changed, nil
+}
+
+// describeInbound renders an inbound alert as text, e.g. "Alertmanager: HighLatency — p99 above 2s"
+func describeInbound(a models.InboundAlert) string {
+	s := inboundSourceName(a.Source) + ": " + firstNonEmpty(a.Name, a.Fingerprint)
+	if a.Summary != "" {
+		s += " — " + a.Summary
+	}
+	return s
+}
+
+func inboundSourceName(source string) string {
+	switch source {
+	case InboundAlertmanager:
+		return "Alertmanager"
+	case InboundGrafana:
+		return "Grafana"
+	}
+	return "Webhook"
+}
+
+// InboundOverride applies a service's firing inbound alerts to its check result: a critical
+// alert takes it down and a warning degrades it. reason describes the firing alerts and is
+// empty when there are none.
+func InboundOverride(serviceKey string, ok, degraded bool) (bool, bool, string) {
+	firing, err := database.GetFiringInboundAlerts(serviceKey)
+	if err != nil || len(firing) == 0 {
+		return ok, degraded, ""
+	}
+	reasons := make([]string, 0, len(firing))
+	for _, a := range firing {
+		if a.Severity == InboundWarning {
+			degraded = true
+		} else {
+			ok = false
+		}
+		reasons = append(reasons, describeInbound(a))
+	}
+	return ok, ok && degraded, strings.Join(reasons, "; ")
+}
+
+// applyInbound re-evaluates a service after its inbound alerts changed, as if it had just
+// been checked. Incidents are recorded even while notifications are off.
+func (m *Manager) applyInbound(serviceKey string, checkOK bool) {
+	svc, _ := database.GetServiceByKey(serviceKey)
+	if svc == nil {
+		return
+	}
+	if disabled, _ := database.GetServiceDisabledState(serviceKey); disabled {
+		return
+	}
+	name := svc.Name
+	if name == "" {
+		name = serviceKey
+	}
+
+	ok, degraded, reason := InboundOverride(serviceKey, checkOK, false)
+	var details CheckDetails
+	if !ok {
+		details.Error = reason
+	} else if degraded {
+		details.Warnings = []string{reason}
+	}
+	m.CheckAndSendAlertsWithDetails(serviceKey, name, ok, degraded, details)
+}
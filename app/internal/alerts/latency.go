@@ -0,0 +1,106 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/stats"
+	"time"
+)
+
+// baselineTTL is how long a service's latency baseline is reused before it is recomputed
+const baselineTTL = 10 * time.Minute
+
+// latencyState tracks a service's latency against its baseline between checks
+type latencyState struct {
+	baseline   stats.Baseline
+	hasBase    bool
+	computedAt time.Time
+	since      time.Time // First check of the current anomaly; zero while latency is normal
+	sumMS      int       // Total latency of the anomalous checks so far
+	checks     int
+	alerted    bool
+}
+
+// ValidateLatencyAnomaly checks the latency anomaly settings. A sustain period is required
+// once a sigma is set, so a single slow check never alerts.
+func ValidateLatencyAnomaly(config *models.AlertConfig) error {
+	if config.LatencySigma < 0 || config.LatencySustainMinutes < 0 {
+		return fmt.Errorf("latency anomaly settings cannot be negative")
+	}
+	if config.LatencySigma > 0 && config.LatencySustainMinutes == 0 {
+		return fmt.Errorf("latency anomaly detection needs a sustain period")
+	}
+	return nil
+}
+
+// CheckLatencyAnomaly compares a check's latency with the service's baseline and raises a
+// "latency" alert once it has stayed above the configured sigma for the sustain period.
+// One alert is sent per anomaly; it ends with the first check back within range.
+func (m *Manager) CheckLatencyAnomaly(serviceKey, serviceName string, ok bool, latencyMS int) {
+	m.checkLatencyAnomaly(serviceKey, serviceName, ok, latencyMS, time.Now().UTC())
+}
+
+func (m *Manager) checkLatencyAnomaly(serviceKey, serviceName string, ok bool, latencyMS int, now time.Time) {
+	if m.config == nil || !m.config.Enabled || m.config.LatencySigma <= 0 {
+		return
+	}
+
+	m.latencyMu.Lock()
+	if m.latency == nil {
+		m.latency = make(map[string]*latencyState)
+	}
+	st := m.latency[serviceKey]
+	if st == nil {
+		st = &latencyState{}
+		m.latency[serviceKey] = st
+	}
+	if now.Sub(st.computedAt) >= baselineTTL {
+		st.baseline, st.hasBase = stats.LatencyBaseline(serviceKey, now)
+		st.computedAt = now
+	}
+
+	if !ok || !st.hasBase || st.baseline.Deviation(float64(latencyMS)) < m.config.LatencySigma {
+		ended := st.alerted && ok
+		st.since, st.sumMS, st.checks, st.alerted = time.Time{}, 0, 0, false
+		m.latencyMu.Unlock()
+		if ended {
+			_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, serviceKey, "Latency back to normal",
+				fmt.Sprintf("%s, %dms", serviceName, latencyMS))
+		}
+		return
+	}
+
+	if st.since.IsZero() {
+		st.since = now
+	}
+	st.sumMS += latencyMS
+	st.checks++
+	due := !st.alerted && now.Sub(st.since) >= time.Duration(m.config.LatencySustainMinutes)*time.Minute
+	if due {
+		st.alerted = true
+	}
+	baseline, since, avg := st.baseline, st.since, st.sumMS/st.checks
+	m.latencyMu.Unlock()
+
+	if !due {
+		return
+	}
+
+	sigma := baseline.Deviation(float64(avg))
+	anomalyFor := formatDowntime(now.Sub(since))
+	_ = database.InsertLog(database.LogLevelWarn, database.LogCategoryEmail, serviceKey, "Latency ANOMALY - sending alert",
+		fmt.Sprintf("%s, %dms vs baseline %.0fms (%.1fσ) for %s", serviceName, avg, baseline.Median, sigma, anomalyFor))
+
+	svc, _ := database.GetServiceByKey(serviceKey)
+	if !m.eventEnabled(svc, EventLatency) {
+		return
+	}
+	data := m.templateData(svc, EventLatency, serviceKey, serviceName)
+	data.LatencyMS = avg
+	data.BaselineMS = int(math.Round(baseline.Median))
+	data.Sigma = math.Round(sigma*10) / 10
+	data.AnomalyFor = anomalyFor
+	m.dispatchAll(svc, EventLatency, data)
+}
@@ -0,0 +1,141 @@
+package alerts
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"status/app/internal/database"
+	"strings"
+)
+
+// sendMatrix posts an HTML message to a Matrix room through the client-server API.
+// The first down alert of an outage is remembered per room; repeats and escalations are
+// threaded onto it, and the recovery is threaded onto it or edits it ("recovery" setting:
+// thread, edit or none).
+func sendMatrix(settings map[string]string, n Notification) error {
+	scope := "matrix:" + settings["room_id"]
+	root, _ := database.GetNotificationThread(scope, n.ServiceKey)
+	startsOutage := n.Event == EventDown || (n.StatusType == EventDown && root == "")
+
+	content := matrixContent(n)
+	if root != "" && !startsOutage {
+		switch {
+		case n.StatusType == EventDown:
+			content["m.relates_to"] = matrixThread(root)
+		case n.StatusType == EventUp && settings["recovery"] == "edit":
+			content = matrixEdit(root, content)
+		case n.StatusType == EventUp && settings["recovery"] != "none":
+			content["m.relates_to"] = matrixThread(root)
+		}
+	}
+
+	eventID, err := matrixSend(settings, matrixTxnID(n), content)
+	if err != nil {
+		return err
+	}
+	switch {
+	case startsOutage && eventID != "":
+		_ = database.SaveNotificationThread(scope, n.ServiceKey, eventID)
+	case n.StatusType == EventUp && root != "":
+		_ = database.DeleteNotificationThread(scope, n.ServiceKey)
+	}
+	return nil
+}
+
+// matrixContent builds an m.room.message with a plain-text body and an HTML formatted body
+// headed like the alert emails
+func matrixContent(n Notification) map[string]interface{} {
+	color := fmt.Sprintf("#%06x", statusColors[n.StatusType])
+	var formatted strings.Builder
+	fmt.Fprintf(&formatted, `<p><strong><font color="%s" data-mx-color="%s">● %s</font></strong></p>`, color, color, statusHeadlines[n.StatusType])
+	fmt.Fprintf(&formatted, "<h4>%s</h4><p>%s</p>", html.EscapeString(n.Subject), n.Message)
+
+	body := n.Subject + "\n\n" + plainMessage(n.Message)
+	var links []string
+	if n.StatusPageURL != "" {
+		links = append(links, fmt.Sprintf(`<a href="%s">View Status Dashboard</a>`, html.EscapeString(n.StatusPageURL)))
+		body += "\n\nStatus: " + n.StatusPageURL
+	}
+	if n.AckURL != "" {
+		links = append(links, fmt.Sprintf(`<a href="%s">Acknowledge</a>`, html.EscapeString(n.AckURL)))
+		body += "\nAcknowledge: " + n.AckURL
+	}
+	if len(links) > 0 {
+		formatted.WriteString("<p>" + strings.Join(links, " · ") + "</p>")
+	}
+
+	return map[string]interface{}{
+		"msgtype":        "m.text",
+		"body":           body,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted.String(),
+	}
+}
+
+// matrixThread relates a message to an outage's first message as a thread reply; the
+// in-reply-to fallback shows it as a reply in clients without thread support
+func matrixThread(root string) map[string]interface{} {
+	return map[string]interface{}{
+		"rel_type":        "m.thread",
+		"event_id":        root,
+		"is_falling_back": true,
+		"m.in_reply_to":   map[string]string{"event_id": root},
+	}
+}
+
+// matrixEdit turns content into an edit replacing the message root
+func matrixEdit(root string, content map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"msgtype":        "m.text",
+		"body":           "* " + content["body"].(string),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": "* " + content["formatted_body"].(string),
+		"m.new_content":  content,
+		"m.relates_to":   map[string]string{"rel_type": "m.replace", "event_id": root},
+	}
+}
+
+// matrixTxnID derives the transaction ID from the outbox delivery so a retried delivery is
+// deduplicated by the homeserver; direct sends get a random one
+func matrixTxnID(n Notification) string {
+	if n.DeliveryID > 0 {
+		return fmt.Sprintf("servicarr-%d", n.DeliveryID)
+	}
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "servicarr-" + hex.EncodeToString(b)
+}
+
+// matrixSend PUTs a room message and returns its event ID
+func matrixSend(settings map[string]string, txnID string, content map[string]interface{}) (string, error) {
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(strings.TrimSpace(settings["homeserver"]), "/"), url.PathEscape(settings["room_id"]), url.PathEscape(txnID))
+
+	body, _ := json.Marshal(content)
+	req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+settings["access_token"])
+
+	resp, err := channelHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+
+	var out struct {
+		EventID string `json:"event_id"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return out.EventID, nil
+}
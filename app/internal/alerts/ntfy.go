@@ -0,0 +1,56 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ntfyPriorities maps statuses to ntfy priorities (1 min … 5 urgent)
+var ntfyPriorities = map[string]int{"down": 5, "degraded": 4, "up": 3, "changed": 3}
+
+// ntfyTags maps statuses to ntfy tags; tags matching an emoji short code are shown as that emoji
+var ntfyTags = map[string]string{"down": "rotating_light", "degraded": "warning", "up": "white_check_mark", "changed": "memo"}
+
+// sendNtfy publishes a notification to an ntfy topic, on ntfy.sh unless server_url is set
+func sendNtfy(settings map[string]string, n Notification) error {
+	server := strings.TrimRight(strings.TrimSpace(settings["server_url"]), "/")
+	if server == "" {
+		server = "https://ntfy.sh"
+	}
+
+	payload := map[string]interface{}{
+		"topic":    settings["topic"],
+		"title":    n.Subject,
+		"message":  plainMessage(n.Message),
+		"markdown": true,
+		"priority": ntfyPriorities[n.StatusType],
+		"tags":     []string{ntfyTags[n.StatusType], n.ServiceKey},
+	}
+	if click := clickURL(settings, n); click != "" {
+		payload["click"] = click
+	}
+	if n.AckURL != "" {
+		payload["actions"] = []map[string]interface{}{
+			{"action": "view", "label": "Acknowledge", "url": n.AckURL, "clear": true},
+		}
+	}
+
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest("POST", server, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := settings["token"]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := channelHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
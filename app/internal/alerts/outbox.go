@@ -0,0 +1,224 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"time"
+)
+
+const (
+	deliveryLease       = 2 * time.Minute // Time a claimed delivery is hidden from other senders
+	maxDeliveryAttempts = 8
+	retryBaseDelay      = 30 * time.Second
+	retryMaxDelay       = time.Hour
+	deliveryRetention   = 30 * 24 * time.Hour
+)
+
+// enqueue stores a notification for a channel in the outbox and attempts it straight away.
+// Channels that are not stored in the database (legacy config) are sent directly.
+// During a simulation the notification is recorded, and only queued when the run is live,
+// marked as simulated in its subject.
+func (m *Manager) enqueue(ch models.NotificationChannel, n Notification) {
+	if m.sim != nil {
+		m.recordSimulated(ch, n, time.Time{})
+		if !m.sim.live {
+			return
+		}
+		n.Subject = simulatedSubjectPrefix + n.Subject
+	}
+	if ch.ID == 0 {
+		go func() { _ = m.Deliver(ch, n) }()
+		return
+	}
+
+	id, err := database.EnqueueNotification(newDelivery(ch, n))
+	if err != nil {
+		log.Printf("Warning: failed to queue notification for channel %q: %v", ch.Name, err)
+		go func() { _ = m.Deliver(ch, n) }()
+		return
+	}
+	go m.attemptDelivery(id)
+}
+
+// deferDelivery stores a notification in the outbox that the delivery worker sends once due,
+// such as an alert held until quiet hours end.
+func (m *Manager) deferDelivery(ch models.NotificationChannel, n Notification, due time.Time) {
+	// Only the latest deferred alert of each kind for a service is sent when the window ends
+	if ch.ID != 0 && n.ServiceKey != "" {
+		if replaced := m.cancelDeferred(ch.ID, n.ServiceKey, n.Event); replaced > 0 {
+			m.logAlert(database.LogLevelInfo, n.ServiceKey, "Deferred alert replaced — quiet hours",
+				fmt.Sprintf("channel=%s, event=%s, replaced=%d", ch.Name, n.Event, replaced))
+		}
+	}
+	if m.sim != nil {
+		m.recordSimulated(ch, n, due)
+		if !m.sim.live {
+			return
+		}
+		n.Subject = simulatedSubjectPrefix + n.Subject
+	}
+	if ch.ID == 0 {
+		time.AfterFunc(time.Until(due), func() { _ = m.Deliver(ch, n) })
+		return
+	}
+	if _, err := database.ScheduleNotification(newDelivery(ch, n), due); err != nil {
+		log.Printf("Warning: failed to schedule notification for channel %q: %v", ch.Name, err)
+		m.enqueue(ch, n)
+	}
+}
+
+// cancelDeferred drops a service's deferred deliveries for the given events that have not
+// been sent yet; channelID 0 matches every channel. A simulation drops them from its result
+// and leaves the outbox alone.
+func (m *Manager) cancelDeferred(channelID int, serviceKey string, events ...string) int64 {
+	if m.sim != nil {
+		return m.sim.dropDeferred(channelID, serviceKey, events...)
+	}
+	n, err := database.CancelDeferredNotifications(channelID, serviceKey, events...)
+	if err != nil {
+		log.Printf("Warning: failed to cancel deferred notifications for %s: %v", serviceKey, err)
+	}
+	return n
+}
+
+// newDelivery is the outbox record for a notification to a channel
+func newDelivery(ch models.NotificationChannel, n Notification) *models.NotificationDelivery {
+	payload, _ := json.Marshal(n)
+	return &models.NotificationDelivery{
+		ChannelID:   ch.ID,
+		ChannelType: ch.Type,
+		ChannelName: ch.Name,
+		ServiceKey:  n.ServiceKey,
+		Subject:     n.Subject,
+		Payload:     string(payload),
+	}
+}
+
+// attemptDelivery sends one queued delivery if it is due, then marks it delivered,
+// schedules a retry with exponential backoff, or dead-letters it.
+func (m *Manager) attemptDelivery(id int64) {
+	claimed, err := database.ClaimNotification(id, deliveryLease)
+	if err != nil || !claimed {
+		return
+	}
+	d, err := database.GetNotificationDelivery(id)
+	if err != nil || d == nil {
+		return
+	}
+
+	var n Notification
+	if err := json.Unmarshal([]byte(d.Payload), &n); err != nil {
+		m.deadLetter(d, "invalid payload: "+err.Error())
+		return
+	}
+	ch, err := database.GetNotificationChannel(d.ChannelID)
+	if err != nil {
+		_ = database.RescheduleNotification(id, err.Error(), time.Now().Add(retryDelay(d.Attempts, err)))
+		return
+	}
+	if ch == nil {
+		m.deadLetter(d, "channel no longer exists")
+		return
+	}
+	if !ch.Enabled {
+		m.deadLetter(d, "channel is disabled")
+		return
+	}
+
+	n.DeliveryID = d.ID
+	err = m.Deliver(*ch, n)
+	switch {
+	case err == nil:
+		_ = database.MarkNotificationDelivered(id)
+	case !retryable(err) || d.Attempts >= maxDeliveryAttempts:
+		m.deadLetter(d, err.Error())
+	default:
+		_ = database.RescheduleNotification(id, err.Error(), time.Now().Add(retryDelay(d.Attempts, err)))
+	}
+}
+
+func (m *Manager) deadLetter(d *models.NotificationDelivery, reason string) {
+	_ = database.MarkNotificationDead(d.ID, reason)
+	_ = database.InsertLog(database.LogLevelError, "notification", d.ServiceKey, "Notification dead-lettered",
+		fmt.Sprintf("channel=%s, subject=%s, attempts=%d, error=%s", d.ChannelName, d.Subject, d.Attempts, reason))
+}
+
+// retryable reports whether a failed send is worth retrying. Client errors other than
+// timeouts and rate limits mean the request itself is wrong and will not succeed later.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) && se.StatusCode >= 400 && se.StatusCode < 500 {
+		return se.StatusCode == http.StatusRequestTimeout || se.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// retryDelay is the wait before the next attempt: the server's Retry-After if given,
+// otherwise exponential backoff from retryBaseDelay capped at retryMaxDelay.
+func retryDelay(attempts int, err error) time.Duration {
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > 0 {
+		return se.RetryAfter
+	}
+	delay := retryBaseDelay
+	for i := 1; i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// ProcessOutbox attempts every pending delivery that is due
+func (m *Manager) ProcessOutbox() {
+	ids, err := database.GetDueNotificationIDs(50)
+	if err != nil {
+		log.Printf("Warning: failed to read notification outbox: %v", err)
+		return
+	}
+	for _, id := range ids {
+		m.attemptDelivery(id)
+	}
+}
+
+// Resend queues a copy of an earlier delivery and attempts it immediately
+func (m *Manager) Resend(id int64) (int64, error) {
+	d, err := database.GetNotificationDelivery(id)
+	if err != nil {
+		return 0, err
+	}
+	if d == nil {
+		return 0, errors.New("delivery not found")
+	}
+	newID, err := database.EnqueueNotification(d)
+	if err != nil {
+		return 0, err
+	}
+	go m.attemptDelivery(newID)
+	return newID, nil
+}
+
+// StartDeliveryWorker retries due notifications every few seconds, picking up anything
+// left pending by a previous run, and prunes old delivery records hourly.
+func (m *Manager) StartDeliveryWorker() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		var lastPrune time.Time
+		for range ticker.C {
+			m.ProcessOutbox()
+			if time.Since(lastPrune) >= time.Hour {
+				if n, err := database.PruneNotificationDeliveries(deliveryRetention); err == nil && n > 0 {
+					log.Printf("Pruned %d old notification deliveries", n)
+				}
+				lastPrune = time.Now()
+			}
+		}
+	}()
+}
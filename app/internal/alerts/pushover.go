@@ -0,0 +1,53 @@
+package alerts
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pushoverAPIBase is the Pushover API endpoint (overridden in tests)
+var pushoverAPIBase = "https://api.pushover.net"
+
+// pushoverPriorities maps statuses to Pushover priorities (-1 quiet, 0 normal, 1 high)
+var pushoverPriorities = map[string]int{"down": 1, "degraded": 0, "up": -1, "changed": -1}
+
+// sendPushover sends a message through the Pushover API to a user or group key
+func sendPushover(settings map[string]string, n Notification) error {
+	message := strings.ReplaceAll(strings.ReplaceAll(n.Message, "<strong>", "<b>"), "</strong>", "</b>")
+	if n.AckURL != "" {
+		message += fmt.Sprintf("\n\n<a href=\"%s\">Acknowledge</a>", html.EscapeString(n.AckURL))
+	}
+
+	form := url.Values{
+		"token":     {settings["token"]},
+		"user":      {settings["user_key"]},
+		"title":     {n.Subject},
+		"message":   {message},
+		"html":      {"1"},
+		"priority":  {strconv.Itoa(pushoverPriorities[n.StatusType])},
+		"timestamp": {strconv.FormatInt(time.Now().Unix(), 10)},
+	}
+	if click := clickURL(settings, n); click != "" {
+		form.Set("url", click)
+		form.Set("url_title", "View Status Dashboard")
+		if click == n.DayURL {
+			form.Set("url_title", "View Today's Checks")
+		}
+	}
+	for _, key := range []string{"device", "sound"} {
+		if v := strings.TrimSpace(settings[key]); v != "" {
+			form.Set(key, v)
+		}
+	}
+
+	resp, err := channelHTTPClient.PostForm(pushoverAPIBase+"/1/messages.json", form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
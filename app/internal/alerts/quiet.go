@@ -0,0 +1,167 @@
+package alerts
+
+import (
+	"fmt"
+	"slices"
+	"status/app/internal/models"
+	"strings"
+	"time"
+)
+
+// What a quiet-hours schedule does with an alert raised inside its window
+const (
+	QuietSuppress = "suppress" // Drop the alert
+	QuietDelay    = "delay"    // Hold the alert until the window ends
+	QuietDeliver  = "deliver"  // Send the alert anyway
+)
+
+// QuietActions lists the supported quiet-hours actions
+var QuietActions = []string{QuietSuppress, QuietDelay, QuietDeliver}
+
+// weekdays are the day names used in quiet-hours schedules, indexed by time.Weekday
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ValidateQuietHours checks an enabled schedule and normalises its days and actions.
+// Disabled schedules are stored as given so they can be switched back on later.
+func ValidateQuietHours(q *models.QuietHours) error {
+	if !q.Enabled {
+		return nil
+	}
+	q.Timezone = strings.TrimSpace(q.Timezone)
+	if q.Timezone != "" {
+		if _, err := time.LoadLocation(q.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", q.Timezone)
+		}
+	}
+
+	var days []string
+	for _, name := range splitList(q.Days) {
+		day := name
+		if len(day) > 3 {
+			day = day[:3]
+		}
+		if !slices.Contains(weekdays, day) {
+			return fmt.Errorf("unknown weekday %q", name)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+	q.Days = strings.Join(days, ",")
+
+	q.Start, q.End = strings.TrimSpace(q.Start), strings.TrimSpace(q.End)
+	if _, err := parseClock(q.Start); err != nil {
+		return fmt.Errorf("invalid start time %q (use HH:MM)", q.Start)
+	}
+	if _, err := parseClock(q.End); err != nil {
+		return fmt.Errorf("invalid end time %q (use HH:MM)", q.End)
+	}
+
+	actions := make(map[string]string, len(q.Actions))
+	for event, action := range q.Actions {
+		event = strings.ToLower(strings.TrimSpace(event))
+		action = strings.ToLower(strings.TrimSpace(action))
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("unknown event %q (supported: %s)", event, strings.Join(Events, ", "))
+		}
+		if !slices.Contains(QuietActions, action) {
+			return fmt.Errorf("unknown quiet hours action %q (supported: %s)", action, strings.Join(QuietActions, ", "))
+		}
+		actions[event] = action
+	}
+	q.Actions = actions
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietUntil returns when the quiet window containing t ends, or the zero time when t is
+// outside the schedule. A window belongs to the weekday it starts on, so an overnight window
+// configured for Friday still covers early Saturday. Equal start and end times mean all day.
+func quietUntil(q models.QuietHours, t time.Time) time.Time {
+	if !q.Enabled {
+		return time.Time{}
+	}
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return time.Time{}
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return time.Time{}
+	}
+	loc := time.Local
+	if q.Timezone != "" {
+		if l, err := time.LoadLocation(q.Timezone); err == nil {
+			loc = l
+		}
+	}
+	days := splitList(q.Days)
+
+	t = t.In(loc)
+	for back := 0; back <= 1; back++ {
+		day := t.AddDate(0, 0, -back)
+		if len(days) > 0 && !slices.Contains(days, weekdays[day.Weekday()]) {
+			continue
+		}
+		from := time.Date(day.Year(), day.Month(), day.Day(), start/60, start%60, 0, 0, loc)
+		to := time.Date(day.Year(), day.Month(), day.Day(), end/60, end%60, 0, 0, loc)
+		if !to.After(from) {
+			to = to.AddDate(0, 0, 1)
+		}
+		if !t.Before(from) && t.Before(to) {
+			return to
+		}
+	}
+	return time.Time{}
+}
+
+// quietAction returns what a schedule does with an event during its window. Follow-up and
+// digest events share the setting of the status they report; unset events are delayed.
+func quietAction(q models.QuietHours, event string) string {
+	if action := q.Actions[event]; action != "" {
+		return action
+	}
+	if action := q.Actions[statusForEvent(event)]; action != "" {
+		return action
+	}
+	return QuietDelay
+}
+
+// quietHoursFor decides how quiet hours affect an alert on a channel at now: the channel's
+// own schedule if enabled, otherwise the global one. until is when a delayed alert is due.
+func (m *Manager) quietHoursFor(ch models.NotificationChannel, event string, now time.Time) (action string, until time.Time) {
+	q := ch.QuietHours
+	if !q.Enabled && m.config != nil {
+		q = m.config.QuietHours
+	}
+	until = quietUntil(q, now)
+	if until.IsZero() {
+		return QuietDeliver, until
+	}
+	return quietAction(q, event), until
+}
+
+// audibleChannels returns the channels outside quiet hours for an event at now; critical
+// alerts bypass quiet hours. Repeats and escalations are skipped on quiet channels rather
+// than delayed, since a backlog of them at the end of the window describes a timeline
+// nobody saw.
+func (m *Manager) audibleChannels(channels []models.NotificationChannel, event string, critical bool, now time.Time) []models.NotificationChannel {
+	if critical {
+		return channels
+	}
+	var out []models.NotificationChannel
+	for _, ch := range channels {
+		if action, _ := m.quietHoursFor(ch, event, now); action == QuietDeliver {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
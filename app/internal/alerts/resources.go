@@ -0,0 +1,260 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/resources"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	resourceLogService        = "resources" // Service name resource alerts are logged under
+	defaultHysteresisFraction = 0.05        // Clearing margin of a rule saved without one, as a fraction of its threshold
+)
+
+// ResourceOperators lists the comparisons numeric resource rules support
+var ResourceOperators = []string{">", ">=", "<", "<="}
+
+// resourceRuleState tracks a rule between polls
+type resourceRuleState struct {
+	pendingSince time.Time // When the condition started holding; zero while it does not
+	firing       bool      // The alert has been sent and has not cleared yet
+}
+
+// resourcePoller holds the Glances client and the rule states of the resource alert poller
+type resourcePoller struct {
+	mu     sync.Mutex
+	client *resources.Client
+	url    string
+	states map[int]*resourceRuleState
+}
+
+// ValidateResourceRule normalises a resource alert rule and checks its metric, operator,
+// container and durations
+func ValidateResourceRule(r *models.ResourceAlertRule) error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Metric = strings.ToLower(strings.TrimSpace(r.Metric))
+	r.Operator = strings.TrimSpace(r.Operator)
+	r.Container = strings.TrimSpace(r.Container)
+	if r.ForMinutes < 0 || r.Hysteresis < 0 {
+		return fmt.Errorf("for_minutes and hysteresis cannot be negative")
+	}
+
+	if r.Metric == resources.MetricContainer {
+		if r.Container == "" {
+			return fmt.Errorf("container rules need a container name")
+		}
+		r.Operator, r.Threshold, r.Hysteresis = "", 0, 0
+	} else {
+		if !slices.Contains(resources.Metrics, r.Metric) {
+			return fmt.Errorf("unknown metric %q (supported: %s, %s)", r.Metric, strings.Join(resources.Metrics, ", "), resources.MetricContainer)
+		}
+		if r.Operator == "" {
+			r.Operator = ">"
+		}
+		if !slices.Contains(ResourceOperators, r.Operator) {
+			return fmt.Errorf("unknown operator %q (supported: %s)", r.Operator, strings.Join(ResourceOperators, " "))
+		}
+		r.Hysteresis = resourceMargin(*r)
+		r.Container = ""
+	}
+	if r.Name == "" {
+		r.Name = describeResourceRule(*r)
+	}
+	return nil
+}
+
+// describeResourceRule renders a rule as text, e.g. "fs_used_percent > 90 for 10m"
+func describeResourceRule(r models.ResourceAlertRule) string {
+	s := fmt.Sprintf("%s %s %s", r.Metric, r.Operator, formatResourceValue(r.Threshold))
+	if r.Metric == resources.MetricContainer {
+		s = fmt.Sprintf("container %s not running", r.Container)
+	}
+	if r.ForMinutes > 0 {
+		s += fmt.Sprintf(" for %dm", r.ForMinutes)
+	}
+	return s
+}
+
+// formatResourceValue formats a metric value without trailing zeros
+func formatResourceValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// StartResourceAlerts polls the configured Glances instance every interval and evaluates
+// the enabled resource alert rules
+func (m *Manager) StartResourceAlerts(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.pollResources()
+		}
+	}()
+}
+
+// pollResources fetches a snapshot and evaluates the rules against it. Nothing is fetched
+// while alerts are off, there are no enabled rules or Glances is not configured.
+func (m *Manager) pollResources() {
+	if m.config == nil || !m.config.Enabled {
+		return
+	}
+	rules, err := database.GetResourceAlertRules()
+	if err != nil || !slices.ContainsFunc(rules, func(r models.ResourceAlertRule) bool { return r.Enabled }) {
+		return
+	}
+	cfg, err := database.LoadResourcesUIConfig()
+	if err != nil || cfg == nil || cfg.GlancesURL == "" {
+		return
+	}
+
+	url := resources.APIURL(cfg.GlancesURL)
+	m.resourceAlerts.mu.Lock()
+	if m.resourceAlerts.client == nil || m.resourceAlerts.url != url {
+		m.resourceAlerts.client, m.resourceAlerts.url = resources.NewClient(url), url
+	}
+	client := m.resourceAlerts.client
+	m.resourceAlerts.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	snap, err := client.FetchSnapshot(ctx)
+	if err != nil {
+		return
+	}
+	m.evaluateResourceRules(rules, snap, time.Now().UTC())
+}
+
+// evaluateResourceRules applies each enabled rule to a snapshot. A rule fires once its
+// condition has held for ForMinutes and clears only when the value is back past the
+// threshold by the hysteresis margin, so a value hovering at the threshold alerts once.
+func (m *Manager) evaluateResourceRules(rules []models.ResourceAlertRule, snap resources.Snapshot, now time.Time) {
+	m.resourceAlerts.mu.Lock()
+	if m.resourceAlerts.states == nil {
+		m.resourceAlerts.states = make(map[int]*resourceRuleState)
+	}
+	type transition struct {
+		rule   models.ResourceAlertRule
+		firing bool
+		value  string
+	}
+	var transitions []transition
+	active := make(map[int]bool)
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		active[rule.ID] = true
+		breached, cleared, value, ok := checkResourceRule(rule, snap)
+		if !ok {
+			continue // Metric not reported: keep the state until it is
+		}
+		st := m.resourceAlerts.states[rule.ID]
+		if st == nil {
+			st = &resourceRuleState{}
+			m.resourceAlerts.states[rule.ID] = st
+		}
+
+		switch {
+		case st.firing && cleared:
+			st.firing, st.pendingSince = false, time.Time{}
+			transitions = append(transitions, transition{rule, false, value})
+		case st.firing:
+		case breached:
+			if st.pendingSince.IsZero() {
+				st.pendingSince = now
+			}
+			if now.Sub(st.pendingSince) >= time.Duration(rule.ForMinutes)*time.Minute {
+				st.firing = true
+				transitions = append(transitions, transition{rule, true, value})
+			}
+		default:
+			st.pendingSince = time.Time{}
+		}
+	}
+	for id := range m.resourceAlerts.states {
+		if !active[id] {
+			delete(m.resourceAlerts.states, id)
+		}
+	}
+	m.resourceAlerts.mu.Unlock()
+
+	for _, t := range transitions {
+		m.sendResourceAlert(t.rule, t.firing, t.value)
+	}
+}
+
+// resourceMargin is how far back past its threshold a rule's value must go to clear. Rules
+// without a margin use a fraction of the threshold, so a value hovering at it does not flap.
+func resourceMargin(rule models.ResourceAlertRule) float64 {
+	if rule.Hysteresis > 0 {
+		return rule.Hysteresis
+	}
+	return math.Round(math.Abs(rule.Threshold)*defaultHysteresisFraction*10) / 10
+}
+
+// checkResourceRule evaluates a rule: breached is whether its condition holds, cleared
+// whether a firing alert may clear. ok is false when the snapshot lacks the metric.
+func checkResourceRule(rule models.ResourceAlertRule, snap resources.Snapshot) (breached, cleared bool, value string, ok bool) {
+	if rule.Metric == resources.MetricContainer {
+		status := snap.ContainerStatus(rule.Container)
+		if status == "" {
+			status = "missing"
+		}
+		return status != "running", status == "running", status, true
+	}
+
+	v, ok := snap.MetricValue(rule.Metric)
+	if !ok {
+		return false, false, "", false
+	}
+	margin := resourceMargin(rule)
+	switch rule.Operator {
+	case ">":
+		breached, cleared = v > rule.Threshold, v <= rule.Threshold-margin
+	case ">=":
+		breached, cleared = v >= rule.Threshold, v < rule.Threshold-margin
+	case "<":
+		breached, cleared = v < rule.Threshold, v >= rule.Threshold+margin
+	case "<=":
+		breached, cleared = v <= rule.Threshold, v > rule.Threshold+margin
+	}
+	return breached, cleared, formatResourceValue(math.Round(v*10) / 10), true
+}
+
+// sendResourceAlert logs a rule firing or clearing and sends it to every active channel
+func (m *Manager) sendResourceAlert(rule models.ResourceAlertRule, firing bool, value string) {
+	event, level, message := TemplateResource, database.LogLevelWarn, "Resource alert FIRING - sending alert"
+	if !firing {
+		event, level, message = TemplateResourceResolved, database.LogLevelInfo, "Resource alert RESOLVED - sending alert"
+	}
+	_ = database.InsertLog(level, database.LogCategoryEmail, resourceLogService, message,
+		fmt.Sprintf("%s (%s), value %s", rule.Name, describeResourceRule(rule), value))
+
+	data := m.templateData(nil, event, "", rule.Name)
+	data.DayURL = ""
+	data.Metric = rule.Metric
+	data.Value = value
+	data.Threshold = formatResourceValue(rule.Threshold)
+	data.Condition = describeResourceRule(rule)
+	if rule.Metric == resources.MetricContainer {
+		data.Metric = "container " + rule.Container
+		data.Threshold = ""
+	}
+	m.dispatchAll(nil, event, data)
+}
+
+// ResetResourceRule forgets a rule's state after it is edited or deleted, so the new
+// condition starts from scratch
+func (m *Manager) ResetResourceRule(id int) {
+	m.resourceAlerts.mu.Lock()
+	defer m.resourceAlerts.mu.Unlock()
+	delete(m.resourceAlerts.states, id)
+}
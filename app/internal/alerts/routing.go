@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"fmt"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Alert events a service can subscribe to
+const (
+	EventDown     = "down"
+	EventDegraded = "degraded"
+	EventUp       = "up"
+	EventChanged  = "changed"
+	EventFlapping = "flapping"
+	EventLatency  = "latency"
+)
+
+// Events lists the alert events in display order
+var Events = []string{EventDown, EventDegraded, EventUp, EventChanged, EventFlapping, EventLatency}
+
+// ValidateRouting normalises a service's notify_channels and notify_events lists and
+// checks that every event is known and every channel exists.
+func ValidateRouting(sc *models.ServiceConfig) error {
+	events := splitList(sc.NotifyEvents)
+	for _, e := range events {
+		if !slices.Contains(Events, e) {
+			return fmt.Errorf("unknown notify event %q (supported: %s)", e, strings.Join(Events, ", "))
+		}
+	}
+	sc.NotifyEvents = strings.Join(events, ",")
+
+	ids := splitList(sc.NotifyChannels)
+	for _, idStr := range ids {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid notify channel ID %q", idStr)
+		}
+		if ch, err := database.GetNotificationChannel(id); err != nil || ch == nil {
+			return fmt.Errorf("notification channel %d does not exist", id)
+		}
+	}
+	sc.NotifyChannels = strings.Join(ids, ",")
+	return nil
+}
+
+// eventEnabled reports whether an event should alert for a service: its own event list
+// when set, otherwise the global alert_on_* settings. Content changes are opted into per
+// service, so they are part of the global default.
+func (m *Manager) eventEnabled(svc *models.ServiceConfig, event string) bool {
+	if svc != nil {
+		if events := splitList(svc.NotifyEvents); len(events) > 0 {
+			return slices.Contains(events, event)
+		}
+	}
+	switch event {
+	case EventDown, EventFlapping:
+		return m.config.AlertOnDown
+	case EventDegraded, EventLatency:
+		return m.config.AlertOnDegraded
+	case EventUp:
+		return m.config.AlertOnUp
+	case EventChanged:
+		return true
+	}
+	return false
+}
+
+// channelsFor returns the enabled channels a service's alerts are routed to. Services
+// without an explicit channel list use every enabled channel.
+func (m *Manager) channelsFor(svc *models.ServiceConfig) []models.NotificationChannel {
+	active := m.activeChannels()
+	if svc == nil {
+		return active
+	}
+	ids := splitList(svc.NotifyChannels)
+	if len(ids) == 0 {
+		return active
+	}
+
+	routed := make([]models.NotificationChannel, 0, len(ids))
+	for _, ch := range active {
+		if slices.Contains(ids, strconv.Itoa(ch.ID)) {
+			routed = append(routed, ch)
+		}
+	}
+	return routed
+}
+
+// splitList splits a comma-separated list, trimming entries and dropping blanks and duplicates
+func splitList(list string) []string {
+	var out []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" && !slices.Contains(out, item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
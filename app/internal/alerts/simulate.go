@@ -0,0 +1,317 @@
+package alerts
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/stats"
+	"time"
+)
+
+// Simulated service states
+const (
+	SimUp       = "up"
+	SimDegraded = "degraded"
+	SimDown     = "down"
+)
+
+// Errors from Simulate for a request that cannot be simulated, as opposed to a database failure
+var (
+	ErrAlertsDisabled  = errors.New("alerts are disabled")
+	ErrServiceNotFound = errors.New("service not found")
+)
+
+const (
+	simulatedSubjectPrefix = "[Simulation] " // Marks the notifications a live simulation sends
+	maxSimulationSteps     = 50
+	maxSimulationSpan      = 7 * 24 * time.Hour // Longest simulated timeline, long enough for repeats and escalation
+)
+
+// SimulationStep is one state transition in an alert simulation
+type SimulationStep struct {
+	State        string `json:"state"`                   // up, degraded or down
+	Service      string `json:"service,omitempty"`       // Defaults to the simulated service
+	AfterSeconds int    `json:"after_seconds,omitempty"` // Simulated time since the previous step
+	Error        string `json:"error,omitempty"`         // Check error shown in the alert
+}
+
+// SimulatedNotification is a notification the alert pipeline queued during a simulation
+type SimulatedNotification struct {
+	Step          int    `json:"step"`
+	ChannelID     int    `json:"channel_id"`
+	ChannelName   string `json:"channel_name"`
+	ChannelType   string `json:"channel_type"`
+	Event         string `json:"event"`
+	ServiceKey    string `json:"service_key"`
+	Subject       string `json:"subject"`
+	Message       string `json:"message"`
+	DeferredUntil string `json:"deferred_until,omitempty"` // Held by quiet hours until then
+}
+
+// SimulationLog is an alert log entry written during a simulation, explaining what was
+// sent, suppressed or deferred
+type SimulationLog struct {
+	Step    int    `json:"step"`
+	Level   string `json:"level"`
+	Service string `json:"service"`
+	Message string `json:"message"`
+	Details string `json:"details"`
+}
+
+// SimulationResult is what an alert simulation would have sent
+type SimulationResult struct {
+	Live          bool                    `json:"live"`
+	Notifications []SimulatedNotification `json:"notifications"`
+	Log           []SimulationLog         `json:"log"`
+}
+
+// simStatus is a service's service_status_history row during a simulation
+type simStatus struct {
+	ok, degraded  bool
+	downSince     time.Time
+	lastNotified  time.Time
+	escalatedAt   time.Time
+	flappingSince time.Time
+	transitions   []time.Time // Simulated status changes, for flapping detection
+}
+
+// simulation is the state of a manager running an alert simulation. Status history is
+// kept in memory, seeded from the real table, and notifications are recorded instead of
+// queued unless the simulation is live.
+type simulation struct {
+	live     bool
+	now      time.Time
+	step     int
+	history  map[string]*simStatus
+	groupDue map[string]time.Time // When each held digest would be flushed, by event
+	result   *SimulationResult
+}
+
+// ValidateSimulation checks the steps of a simulation and the simulated time they span
+func ValidateSimulation(steps []SimulationStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	if len(steps) > maxSimulationSteps {
+		return fmt.Errorf("a simulation cannot have more than %d steps", maxSimulationSteps)
+	}
+	var span time.Duration
+	for i, step := range steps {
+		switch step.State {
+		case SimUp, SimDegraded, SimDown:
+		default:
+			return fmt.Errorf("step %d: state must be up, degraded or down", i+1)
+		}
+		if step.AfterSeconds < 0 {
+			return fmt.Errorf("step %d: after_seconds cannot be negative", i+1)
+		}
+		span += time.Duration(step.AfterSeconds) * time.Second
+	}
+	if span > maxSimulationSpan {
+		return fmt.Errorf("a simulation cannot span more than %s", formatDowntime(maxSimulationSpan))
+	}
+	return nil
+}
+
+// Simulate runs a sequence of state transitions through the full alert pipeline: dependency
+// suppression, flapping, routing, quiet hours, templates, grouping, repeats and escalation.
+// Status history and incidents are never touched. In a dry run nothing is sent; a live run
+// queues the notifications for delivery as well.
+func (m *Manager) Simulate(serviceKey string, steps []SimulationStep, live bool) (*SimulationResult, error) {
+	if m.config == nil || !m.config.Enabled {
+		return nil, ErrAlertsDisabled
+	}
+	if err := ValidateSimulation(steps); err != nil {
+		return nil, err
+	}
+	services := make(map[string]*models.ServiceConfig)
+	for _, step := range steps {
+		key := step.Service
+		if key == "" {
+			key = serviceKey
+		}
+		if services[key] != nil {
+			continue
+		}
+		svc, err := database.GetServiceByKey(key)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrServiceNotFound, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		services[key] = svc
+	}
+
+	history, err := loadSimHistory()
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	sim := &Manager{
+		config:         m.config,
+		statusPageURL:  m.statusPageURL,
+		channels:       m.channels,
+		channelsLoaded: m.channelsLoaded,
+		signer:         m.signer,
+	}
+	m.mu.RUnlock()
+	sim.sim = &simulation{
+		live:     live,
+		now:      time.Now().UTC(),
+		history:  history,
+		groupDue: make(map[string]time.Time),
+		result:   &SimulationResult{Live: live, Notifications: []SimulatedNotification{}, Log: []SimulationLog{}},
+	}
+
+	for i, step := range steps {
+		sim.sim.now = sim.sim.now.Add(time.Duration(step.AfterSeconds) * time.Second)
+		sim.sim.step = i + 1
+		sim.flushDueGroups(false)
+
+		key := step.Service
+		if key == "" {
+			key = serviceKey
+		}
+		svc := services[key]
+		sim.sim.recordTransition(key, step.State)
+		sim.CheckAndSendAlertsWithDetails(key, svc.Name, step.State != SimDown, step.State == SimDegraded,
+			CheckDetails{Error: step.Error})
+	}
+	sim.flushDueGroups(true)
+	return sim.sim.result, nil
+}
+
+// flushDueGroups sends the digests whose grouping window has passed on the simulated clock,
+// or all held digests once the simulation is over
+func (m *Manager) flushDueGroups(all bool) {
+	for _, event := range []string{EventDown, EventUp} {
+		due, held := m.sim.groupDue[event]
+		if held && (all || !m.sim.now.Before(due)) {
+			delete(m.sim.groupDue, event)
+			m.flushGroup(event)
+		}
+	}
+}
+
+// loadSimHistory copies service_status_history into memory for a simulation. Flapping
+// episodes are left out: the simulation detects flapping from its own transitions only.
+func loadSimHistory() (map[string]*simStatus, error) {
+	rows, err := database.DB.Query(`SELECT service_key, ok, degraded, down_since, last_notified_at, escalated_at
+		FROM service_status_history`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	parse := func(s sql.NullString) time.Time {
+		if !s.Valid {
+			return time.Time{}
+		}
+		t, _ := time.ParseInLocation(historyTimeLayout, s.String, time.UTC)
+		return t
+	}
+	history := make(map[string]*simStatus)
+	for rows.Next() {
+		var key string
+		var ok, degraded int
+		var downSince, lastNotified, escalatedAt sql.NullString
+		if err := rows.Scan(&key, &ok, &degraded, &downSince, &lastNotified, &escalatedAt); err != nil {
+			return nil, err
+		}
+		history[key] = &simStatus{
+			ok:           ok == 1,
+			degraded:     degraded == 1,
+			downSince:    parse(downSince),
+			lastNotified: parse(lastNotified),
+			escalatedAt:  parse(escalatedAt),
+		}
+	}
+	return history, rows.Err()
+}
+
+// recordTransition notes a status change on the simulated clock before the step is checked,
+// as the monitor adds a heartbeat to the service's uptime calculator before alerting
+func (s *simulation) recordTransition(serviceKey, state string) {
+	st := s.history[serviceKey]
+	if st == nil {
+		return
+	}
+	if st.ok != (state != SimDown) || st.degraded != (state == SimDegraded) {
+		st.transitions = append(st.transitions, s.now)
+	}
+}
+
+// flapState evaluates the flapping policy against the simulated transitions, as the uptime
+// calculator does for real heartbeats: the status changes within the window, and whether the
+// service is flapping. An episode already marked lasts until the status has been stable long enough.
+func (s *simulation) flapState(serviceKey string, p stats.FlapPolicy) (transitions int, flapping bool) {
+	st := s.history[serviceKey]
+	if st == nil || p.Threshold <= 0 {
+		return 0, false
+	}
+	since := s.now.Add(-p.Window)
+	for _, t := range st.transitions {
+		if !t.Before(since) {
+			transitions++
+		}
+	}
+	if !st.flappingSince.IsZero() && len(st.transitions) > 0 {
+		return transitions, s.now.Sub(st.transitions[len(st.transitions)-1]) < p.Stable
+	}
+	return transitions, transitions > p.Threshold
+}
+
+// now is the current time, or the simulated clock during a simulation
+func (m *Manager) now() time.Time {
+	if m.sim != nil {
+		return m.sim.now
+	}
+	return time.Now().UTC()
+}
+
+// logAlert writes an alert log entry, or records it in the result during a simulation
+func (m *Manager) logAlert(level, serviceKey, message, details string) {
+	if m.sim != nil {
+		m.sim.result.Log = append(m.sim.result.Log, SimulationLog{
+			Step: m.sim.step, Level: level, Service: serviceKey, Message: message, Details: details,
+		})
+		return
+	}
+	_ = database.InsertLog(level, database.LogCategoryEmail, serviceKey, message, details)
+}
+
+// recordSimulated adds a notification to the simulation result; due is set when quiet
+// hours hold it back
+func (m *Manager) recordSimulated(ch models.NotificationChannel, n Notification, due time.Time) {
+	sn := SimulatedNotification{
+		Step:        m.sim.step,
+		ChannelID:   ch.ID,
+		ChannelName: ch.Name,
+		ChannelType: ch.Type,
+		Event:       n.Event,
+		ServiceKey:  n.ServiceKey,
+		Subject:     n.Subject,
+		Message:     n.Message,
+	}
+	if !due.IsZero() {
+		sn.DeferredUntil = due.UTC().Format(time.RFC3339)
+	}
+	m.sim.result.Notifications = append(m.sim.result.Notifications, sn)
+}
+
+// dropDeferred removes notifications held back by quiet hours that are not due yet on the
+// simulated clock, as cancelling their deferred deliveries would; channelID 0 matches every
+// channel
+func (s *simulation) dropDeferred(channelID int, serviceKey string, events ...string) int64 {
+	before := len(s.result.Notifications)
+	s.result.Notifications = slices.DeleteFunc(s.result.Notifications, func(n SimulatedNotification) bool {
+		due, err := time.Parse(time.RFC3339, n.DeferredUntil)
+		return err == nil && due.After(s.now) && n.ServiceKey == serviceKey &&
+			(channelID == 0 || n.ChannelID == channelID) && slices.Contains(events, n.Event)
+	})
+	return int64(before - len(s.result.Notifications))
+}
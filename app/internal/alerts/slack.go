@@ -0,0 +1,75 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// slackEscaper escapes the characters Slack's mrkdwn reserves for links and mentions
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackText converts a notification's HTML fragment to Slack mrkdwn
+func slackText(message string) string {
+	parts := strings.Split(message, "<strong>")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(slackEscaper.Replace(html.UnescapeString(part)), "&lt;/strong&gt;", "*")
+	}
+	return strings.Join(parts, "*")
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with an ellipsis
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// sendSlack posts a Block Kit message to a Slack incoming webhook, in an attachment so it
+// gets the status colour bar
+func sendSlack(settings map[string]string, n Notification) error {
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": truncateRunes(n.Subject, 150), "emoji": true}},
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": slackText(n.Message)}},
+		{"type": "section", "fields": []map[string]string{
+			{"type": "mrkdwn", "text": "*Service*\n" + slackEscaper.Replace(n.ServiceName)},
+			{"type": "mrkdwn", "text": "*Status*\n" + statusLabel(n.StatusType)},
+		}},
+		{"type": "context", "elements": []map[string]string{
+			{"type": "mrkdwn", "text": "🕒 " + time.Now().Format(time.RFC1123)},
+		}},
+	}
+
+	var buttons []map[string]interface{}
+	if n.StatusPageURL != "" {
+		buttons = append(buttons, map[string]interface{}{"type": "button", "url": n.StatusPageURL,
+			"text": map[string]string{"type": "plain_text", "text": "View Status Dashboard"}})
+	}
+	if n.AckURL != "" {
+		buttons = append(buttons, map[string]interface{}{"type": "button", "url": n.AckURL, "style": "danger",
+			"text": map[string]string{"type": "plain_text", "text": "Acknowledge"}})
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, map[string]interface{}{"type": "actions", "elements": buttons})
+	}
+
+	payload := map[string]interface{}{
+		"text": n.Subject, // Notification fallback
+		"attachments": []map[string]interface{}{
+			{"color": fmt.Sprintf("#%06x", statusColors[n.StatusType]), "blocks": blocks},
+		},
+	}
+
+	body, _ := json.Marshal(payload)
+	resp, err := channelHTTPClient.Post(settings["webhook_url"], "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
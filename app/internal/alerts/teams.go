@@ -0,0 +1,64 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// teamsStyles maps statuses to Adaptive Card container styles, which stand in for the
+// chat colours (cards cannot use arbitrary colours)
+var teamsStyles = map[string]string{"down": "attention", "degraded": "warning", "up": "good", "changed": "accent"}
+
+// sendTeams posts an Adaptive Card to a Microsoft Teams workflow webhook
+// ("Post to a channel when a webhook request is received")
+func sendTeams(settings map[string]string, n Notification) error {
+	var actions []map[string]string
+	if n.StatusPageURL != "" {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "View Status Dashboard", "url": n.StatusPageURL})
+	}
+	if n.AckURL != "" {
+		actions = append(actions, map[string]string{"type": "Action.OpenUrl", "title": "Acknowledge", "url": n.AckURL, "style": "destructive"})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"msteams": map[string]string{"width": "Full"},
+		"body": []map[string]interface{}{
+			{
+				"type":  "Container",
+				"style": teamsStyles[n.StatusType],
+				"bleed": true,
+				"items": []map[string]interface{}{
+					{"type": "TextBlock", "text": n.Subject, "weight": "Bolder", "size": "Medium", "wrap": true},
+				},
+			},
+			{"type": "TextBlock", "text": plainMessage(n.Message), "wrap": true},
+			{"type": "FactSet", "facts": []map[string]string{
+				{"title": "Service", "value": n.ServiceName},
+				{"title": "Status", "value": statusLabel(n.StatusType)},
+				{"title": "Time", "value": time.Now().Format(time.RFC1123)},
+			}},
+		},
+	}
+	if len(actions) > 0 {
+		card["actions"] = actions
+	}
+
+	payload := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+
+	body, _ := json.Marshal(payload)
+	resp, err := channelHTTPClient.Post(settings["webhook_url"], "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
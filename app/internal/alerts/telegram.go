@@ -4,30 +4,42 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"status/app/internal/database"
+	"html"
+	"status/app/internal/models"
 	"strings"
 	"time"
 )
 
-// SendTelegram sends a message via Telegram Bot API
+// telegramAPIBase is the Telegram Bot API endpoint (overridden in tests)
+var telegramAPIBase = "https://api.telegram.org"
+
+// SendTelegram sends a message via the legacy Telegram bot settings
 func (m *Manager) SendTelegram(subject, statusType, serviceName, message string) {
-	plainMsg := strings.ReplaceAll(strings.ReplaceAll(message, "<strong>", "<b>"), "</strong>", "</b>")
-	text := fmt.Sprintf("<b>%s</b>\n\n%s\n\n🕒 %s", subject, plainMsg, time.Now().Format(time.RFC1123))
+	ch := models.NotificationChannel{Type: models.ChannelTelegram, Name: "Telegram",
+		Settings: map[string]string{"bot_token": m.config.TelegramBotToken, "chat_id": m.config.TelegramChatID}}
+	_ = m.Deliver(ch, Notification{Subject: subject, StatusType: statusType, ServiceName: serviceName, Message: message})
+}
+
+// sendTelegram sends a message via the Telegram Bot API
+func sendTelegram(settings map[string]string, n Notification) error {
+	plainMsg := strings.ReplaceAll(strings.ReplaceAll(n.Message, "<strong>", "<b>"), "</strong>", "</b>")
+	text := fmt.Sprintf("<b>%s</b>\n\n%s\n\n🕒 %s", n.Subject, plainMsg, time.Now().Format(time.RFC1123))
+	if n.AckURL != "" {
+		text += fmt.Sprintf("\n\n<a href=\"%s\">✋ Acknowledge</a>", html.EscapeString(n.AckURL))
+	}
 
 	payload := map[string]interface{}{
-		"chat_id":    m.config.TelegramChatID,
+		"chat_id":    settings["chat_id"],
 		"text":       text,
 		"parse_mode": "HTML",
 	}
 
 	body, _ := json.Marshal(payload)
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", m.config.TelegramBotToken)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	url := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIBase, settings["bot_token"])
+	resp, err := channelHTTPClient.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
-		_ = database.InsertLog(database.LogLevelError, "notification", serviceName, "Telegram notification failed", err.Error())
-		return
+		return err
 	}
 	defer resp.Body.Close()
-	_ = database.InsertLog(database.LogLevelInfo, "notification", serviceName, "Telegram notification sent", fmt.Sprintf("status=%d", resp.StatusCode))
+	return checkResponse(resp)
 }
@@ -0,0 +1,423 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/stats"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	telegramPollTimeout = 30 * time.Second // How long a getUpdates long poll waits for messages
+	telegramRetryDelay  = 10 * time.Second // Wait after a failed poll
+	telegramSyncEvery   = 30 * time.Second // How often bots are started or stopped to match the channels
+	maxPauseDuration    = 7 * 24 * time.Hour
+)
+
+// telegramHelp lists the bot commands
+const telegramHelp = `<b>Commands</b>
+/status — summary of all services
+/service &lt;key&gt; — uptime and latency of a service
+/ack [key] — acknowledge the current outage; the key is needed when several are open
+/pause &lt;key&gt; &lt;duration&gt; — silence a service's alerts, e.g. /pause nas 2h
+/resume &lt;key&gt; — end a pause early`
+
+// telegramBots holds the long-poll loops of the bots answering commands, by bot token
+type telegramBots struct {
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+}
+
+// telegramUpdate is the part of a Bot API update the bot reads
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		From *struct {
+			Username  string `json:"username"`
+			FirstName string `json:"first_name"`
+		} `json:"from"`
+	} `json:"message"`
+}
+
+// StartTelegramBot answers bot commands in the chats of Telegram channels that have commands
+// enabled, long polling getUpdates once per bot token. Bots follow channel changes.
+func (m *Manager) StartTelegramBot() {
+	go func() {
+		ticker := time.NewTicker(telegramSyncEvery)
+		defer ticker.Stop()
+		for {
+			m.syncTelegramBots()
+			<-ticker.C
+		}
+	}()
+}
+
+// telegramCommandChats returns the chat IDs allowed to send commands, by bot token
+func (m *Manager) telegramCommandChats() map[string][]string {
+	chats := make(map[string][]string)
+	for _, ch := range m.activeChannels() {
+		if ch.Type != models.ChannelTelegram || ch.Settings["commands"] != "true" {
+			continue
+		}
+		token, chat := ch.Settings["bot_token"], strings.TrimSpace(ch.Settings["chat_id"])
+		if token != "" && chat != "" {
+			chats[token] = append(chats[token], chat)
+		}
+	}
+	return chats
+}
+
+// syncTelegramBots starts a poll loop for each bot token with command chats and stops the rest
+func (m *Manager) syncTelegramBots() {
+	wanted := m.telegramCommandChats()
+
+	m.telegram.mu.Lock()
+	defer m.telegram.mu.Unlock()
+	if m.telegram.running == nil {
+		m.telegram.running = make(map[string]context.CancelFunc)
+	}
+	for token, cancel := range m.telegram.running {
+		if _, ok := wanted[token]; !ok {
+			cancel()
+			delete(m.telegram.running, token)
+		}
+	}
+	for token := range wanted {
+		if _, ok := m.telegram.running[token]; !ok {
+			ctx, cancel := context.WithCancel(context.Background())
+			m.telegram.running[token] = cancel
+			go m.runTelegramBot(ctx, token)
+		}
+	}
+}
+
+// runTelegramBot long polls a bot's updates until ctx is cancelled. It starts from the first
+// unconfirmed update; batches are confirmed before their commands run, so a restart does not
+// run them again.
+func (m *Manager) runTelegramBot(ctx context.Context, token string) {
+	var offset int64
+	for ctx.Err() == nil {
+		next, err := m.pollTelegram(ctx, token, offset)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+		offset = next
+	}
+}
+
+// pollTelegram fetches one batch of updates and answers the commands in it. The batch is
+// confirmed with Telegram before any command runs; the returned offset is the one to poll next.
+func (m *Manager) pollTelegram(ctx context.Context, token string, offset int64) (int64, error) {
+	updates, err := getTelegramUpdates(ctx, token, offset, telegramPollTimeout)
+	if err != nil {
+		return offset, err
+	}
+	next := offset
+	for _, u := range updates {
+		next = max(next, u.UpdateID+1)
+	}
+	if next != offset {
+		// Polling from next confirms the batch; later updates come back on the next poll
+		if _, err := getTelegramUpdates(ctx, token, next, 0); err != nil {
+			return offset, err
+		}
+	}
+
+	allowed := m.telegramCommandChats()[token]
+	for _, u := range updates {
+		msg := u.Message
+		if msg == nil || !strings.HasPrefix(msg.Text, "/") {
+			continue
+		}
+		chat := strconv.FormatInt(msg.Chat.ID, 10)
+		if !slices.Contains(allowed, chat) {
+			_ = database.InsertLog(database.LogLevelWarn, "notification", "", "Telegram command from unknown chat ignored",
+				fmt.Sprintf("chat=%s", chat))
+			continue
+		}
+		by := "telegram"
+		if msg.From != nil {
+			by = "telegram:" + firstNonEmpty(prefixed("@", msg.From.Username), msg.From.FirstName, chat)
+		}
+		reply := m.telegramCommand(msg.Text, by)
+		if err := sendTelegramText(ctx, token, chat, reply); err != nil {
+			_ = database.InsertLog(database.LogLevelError, "notification", "", "Telegram command reply failed", err.Error())
+		}
+	}
+	return next, nil
+}
+
+// getTelegramUpdates calls getUpdates from offset, which confirms every earlier update, waiting
+// up to timeout for new ones
+func getTelegramUpdates(ctx context.Context, token string, offset int64, timeout time.Duration) ([]telegramUpdate, error) {
+	q := url.Values{}
+	q.Set("offset", strconv.FormatInt(offset, 10))
+	q.Set("timeout", strconv.Itoa(int(timeout/time.Second)))
+	q.Set("allowed_updates", `["message"]`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/bot%s/getUpdates?%s", telegramAPIBase, token, q.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: timeout + channelHTTPClient.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		OK     bool             `json:"ok"`
+		Result []telegramUpdate `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if !body.OK {
+		return nil, fmt.Errorf("telegram getUpdates failed")
+	}
+	return body.Result, nil
+}
+
+// prefixed returns s with a prefix, or "" when s is empty
+func prefixed(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
+
+// sendTelegramText posts an HTML reply to a chat
+func sendTelegramText(ctx context.Context, token, chatID, text string) error {
+	body, _ := json.Marshal(map[string]any{"chat_id": chatID, "text": text, "parse_mode": "HTML"})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIBase, token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := channelHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// telegramCommand runs a bot command and returns the HTML reply. by identifies the sender
+// in acknowledgements and pauses.
+func (m *Manager) telegramCommand(text, by string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return telegramHelp
+	}
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@") // "/status@MyBot" in group chats
+	args := fields[1:]
+
+	switch command {
+	case "/status":
+		return botStatus()
+	case "/service":
+		if len(args) != 1 {
+			return "Usage: /service &lt;key&gt;"
+		}
+		return botService(args[0])
+	case "/ack":
+		return m.botAck(args, by)
+	case "/pause":
+		if len(args) != 2 {
+			return "Usage: /pause &lt;key&gt; &lt;duration&gt;, e.g. /pause nas 2h"
+		}
+		return botPause(args[0], args[1], by)
+	case "/resume":
+		if len(args) != 1 {
+			return "Usage: /resume &lt;key&gt;"
+		}
+		return botResume(args[0], by)
+	}
+	return telegramHelp
+}
+
+// botServiceState describes a service's current state for bot replies
+func botServiceState(key string) string {
+	if disabled, _ := database.GetServiceDisabledState(key); disabled {
+		return "⏹ disabled"
+	}
+	hb, ok := stats.LastHeartbeat(key)
+	if !ok {
+		return "⚪ no data"
+	}
+	state := "✅ up"
+	if hb.Status == 0 {
+		state = "🔴 down"
+	}
+	if w, _ := database.GetActiveMaintenance(key); w != nil {
+		state += ", ⏸ paused until " + botTime(w.EndTime)
+	}
+	return state
+}
+
+// botTime renders a stored RFC 3339 timestamp in local time
+func botTime(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("Jan 2 15:04")
+}
+
+func botStatus() string {
+	services, err := database.GetAllServices()
+	if err != nil {
+		return "Failed to load services"
+	}
+	if len(services) == 0 {
+		return "No services configured"
+	}
+
+	var lines []string
+	up := 0
+	for _, svc := range services {
+		state := botServiceState(svc.Key)
+		if strings.HasPrefix(state, "✅") {
+			up++
+		}
+		lines = append(lines, fmt.Sprintf("%s — <b>%s</b> (<code>%s</code>)", state, html.EscapeString(svc.Name), html.EscapeString(svc.Key)))
+	}
+	return fmt.Sprintf("<b>%d/%d services up</b>\n\n%s", up, len(services), strings.Join(lines, "\n"))
+}
+
+func botService(key string) string {
+	svc, err := database.GetServiceByKey(key)
+	if err != nil || svc == nil {
+		return fmt.Sprintf("Unknown service <code>%s</code>", html.EscapeString(key))
+	}
+
+	st := stats.GetUptimeStats(key)
+	lines := []string{
+		fmt.Sprintf("<b>%s</b> — %s", html.EscapeString(svc.Name), botServiceState(key)),
+		fmt.Sprintf("Uptime: %.2f%% (24h), %.2f%% (7d), %.2f%% (30d)", st.Uptime24h, st.Uptime7d, st.Uptime30d),
+		fmt.Sprintf("Average latency (24h): %.0f ms", st.AvgLatency),
+	}
+	if hb, ok := stats.LastHeartbeat(key); ok {
+		last := "Last check: " + hb.Time.Local().Format("Jan 2 15:04:05")
+		if hb.Ping != nil {
+			last += fmt.Sprintf(", %d ms", *hb.Ping)
+		}
+		lines = append(lines, last)
+		if hb.Status == 0 && hb.Msg != "" {
+			lines = append(lines, "Error: "+html.EscapeString(hb.Msg))
+		}
+	}
+	if inc, _ := database.GetOpenIncident(key); inc != nil {
+		line := "Outage since " + botTime(inc.StartedAt)
+		if inc.AcknowledgedAt != "" {
+			line += ", acknowledged by " + html.EscapeString(inc.AcknowledgedBy)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// botAck acknowledges the given service's ongoing incident. Without a key it acknowledges
+// the only unacknowledged incident, and asks for a key when several are open.
+func (m *Manager) botAck(args []string, by string) string {
+	if len(args) > 1 {
+		return "Usage: /ack [key]"
+	}
+	incidents, err := database.GetOpenIncidents()
+	if err != nil {
+		return "Failed to load incidents"
+	}
+
+	var open []models.Incident
+	for _, inc := range incidents {
+		if inc.AcknowledgedAt == "" && (len(args) == 0 || inc.ServiceKey == args[0]) {
+			open = append(open, inc)
+		}
+	}
+	if len(open) == 0 {
+		return "No unacknowledged outage"
+	}
+	if len(open) > 1 {
+		keys := make([]string, len(open))
+		for i, inc := range open {
+			keys[i] = "<code>" + html.EscapeString(inc.ServiceKey) + "</code>"
+		}
+		return "Several outages are open; use /ack &lt;key&gt; with one of " + strings.Join(keys, ", ")
+	}
+
+	inc := open[0]
+	if _, err := m.Acknowledge(inc.ID, by); err != nil {
+		return "Failed to acknowledge the outage"
+	}
+	return "✋ Acknowledged: " + html.EscapeString(firstNonEmpty(inc.ServiceName, inc.ServiceKey))
+}
+
+// parseBotDuration parses a pause duration such as 30m, 2h or 1d
+func parseBotDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func botPause(key, duration, by string) string {
+	svc, err := database.GetServiceByKey(key)
+	if err != nil || svc == nil {
+		return fmt.Sprintf("Unknown service <code>%s</code>", html.EscapeString(key))
+	}
+	d, err := parseBotDuration(duration)
+	if err != nil || d <= 0 {
+		return "Invalid duration; use e.g. 30m, 2h or 1d"
+	}
+	if d > maxPauseDuration {
+		return "A pause cannot be longer than 7 days"
+	}
+
+	until := time.Now().Add(d)
+	if _, err := database.CreateMaintenanceWindow(key, "Paused from Telegram by "+by, until); err != nil {
+		return "Failed to pause the service"
+	}
+	_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, key, "Alerts paused",
+		fmt.Sprintf("until=%s, by=%s", until.UTC().Format(time.RFC3339), by))
+	return fmt.Sprintf("⏸ Alerts for <b>%s</b> paused until %s", html.EscapeString(svc.Name), until.Local().Format("Jan 2 15:04"))
+}
+
+func botResume(key, by string) string {
+	n, err := database.EndMaintenance(key)
+	if err != nil {
+		return "Failed to resume the service"
+	}
+	if n == 0 {
+		return fmt.Sprintf("<code>%s</code> is not paused", html.EscapeString(key))
+	}
+	_ = database.InsertLog(database.LogLevelInfo, database.LogCategoryEmail, key, "Alerts resumed", "by="+by)
+	return fmt.Sprintf("▶️ Alerts for <code>%s</code> resumed", html.EscapeString(key))
+}
@@ -0,0 +1,338 @@
+package alerts
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"slices"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template events beyond the alert events: follow-ups for an ongoing outage
+const (
+	TemplateRepeat     = "repeat"
+	TemplateEscalation = "escalation"
+	TemplateDigestDown = "digest_down"
+	TemplateDigestUp   = "digest_up"
+
+	// Resource alert rules firing and clearing
+	TemplateResource         = "resource"
+	TemplateResourceResolved = "resource_resolved"
+)
+
+// TemplateEvents lists the events that have a notification template, in display order
+var TemplateEvents = []string{EventDown, EventDegraded, EventUp, EventChanged, EventFlapping, EventLatency, TemplateRepeat, TemplateEscalation, TemplateDigestDown, TemplateDigestUp, TemplateResource, TemplateResourceResolved}
+
+// TemplateData is the data notification templates are rendered with
+type TemplateData struct {
+	Event         string // down, degraded, up, changed, flapping, latency, repeat, escalation, digest_down, digest_up, resource, resource_resolved
+	ServiceName   string
+	ServiceKey    string
+	ServiceURL    string
+	LatencyMS     int    // Latency of the check that raised the alert; 0 if unknown
+	Error         string // Check error, if any
+	Warnings      string // App health warnings, joined with "; "
+	DownFor       string // Outage duration so far, e.g. "2h 5m" (repeat and escalation)
+	StatusPageURL string
+	DayURL        string // Status page link opening today's detail view for the service
+	Dependencies  string // Keys of the services this one depends on, comma-separated
+	OldHash       string // Content hashes (changed)
+	NewHash       string
+	AckURL        string
+	Time          string
+	Count         int     // Number of services in a digest
+	Services      string  // Names of the services in a digest, root causes first, comma-separated
+	RootCause     string  // Names of the digest's services the others depend on, if any
+	Critical      bool    // The service (or one in a digest) is flagged critical and bypasses quiet hours
+	Transitions   int     // Status changes within the flapping window (flapping)
+	FlapWindow    string  // Flapping detection window, e.g. "10m"
+	BaselineMS    int     // Normal latency the anomaly is measured against (latency)
+	Sigma         float64 // Deviations above the baseline, one decimal (latency)
+	AnomalyFor    string  // How long latency has been anomalous, e.g. "10m" (latency)
+	Condition     string  // Resource rule as text, e.g. "fs_used_percent > 90 for 10m"
+	Metric        string  // Resource metric, or "container <name>" (resource rules)
+	Value         string  // Current value of the metric or container status
+	Threshold     string  // Rule threshold; empty for container rules
+}
+
+// TemplateVariables documents the fields of TemplateData for the template editor
+var TemplateVariables = []string{
+	".Event", ".ServiceName", ".ServiceKey", ".ServiceURL", ".LatencyMS", ".Error", ".Warnings",
+	".DownFor", ".StatusPageURL", ".DayURL", ".Dependencies", ".OldHash", ".NewHash", ".AckURL", ".Time",
+	".Count", ".Services", ".RootCause", ".Critical", ".Transitions", ".FlapWindow",
+	".BaselineMS", ".Sigma", ".AnomalyFor", ".Condition", ".Metric", ".Value", ".Threshold",
+}
+
+// MessageTemplate is a subject and message template pair
+type MessageTemplate struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// DefaultTemplates are the built-in templates used when no override is saved
+var DefaultTemplates = map[string]MessageTemplate{
+	EventDown: {
+		Subject: "🔴 Service Down: {{.ServiceName}}",
+		Body:    "The service <strong>{{.ServiceName}}</strong> is currently unreachable and not responding to health checks. Please investigate immediately.",
+	},
+	EventDegraded: {
+		Subject: "⚠️ Service Degraded: {{.ServiceName}}",
+		Body: "{{if .Warnings}}The service <strong>{{.ServiceName}}</strong> is responding but reports health warnings: {{html .Warnings}}" +
+			"{{else}}The service <strong>{{.ServiceName}}</strong> is responding but degraded{{if .LatencyMS}} (last check took {{.LatencyMS}}ms){{end}}. Performance may be impacted.{{end}}",
+	},
+	EventUp: {
+		Subject: "✅ Service Recovered: {{.ServiceName}}",
+		Body:    "Great news! The service <strong>{{.ServiceName}}</strong> has recovered and is now responding normally to health checks.",
+	},
+	EventChanged: {
+		Subject: "📝 Content Changed: {{.ServiceName}}",
+		Body:    "The response content of <strong>{{.ServiceName}}</strong> has changed unexpectedly (hash {{.OldHash}} → {{.NewHash}}). Verify the change was intended.",
+	},
+	EventFlapping: {
+		Subject: "🔁 Service Flapping: {{.ServiceName}}",
+		Body: "The service <strong>{{.ServiceName}}</strong> changed state {{.Transitions}} times in the last {{.FlapWindow}}. " +
+			"Individual down and recovery alerts are paused until it is stable again.",
+	},
+	EventLatency: {
+		Subject: "🐢 Latency Anomaly: {{.ServiceName}}",
+		Body: "The service <strong>{{.ServiceName}}</strong> has been responding in {{.LatencyMS}}ms for {{.AnomalyFor}}, " +
+			"against a normal {{.BaselineMS}}ms ({{.Sigma}}σ above its baseline).",
+	},
+	TemplateRepeat: {
+		Subject: "🔴 Still Down: {{.ServiceName}} ({{.DownFor}})",
+		Body:    "The service <strong>{{.ServiceName}}</strong> is still unreachable after {{.DownFor}}. Please investigate immediately.",
+	},
+	TemplateEscalation: {
+		Subject: "🚨 Escalation: {{.ServiceName}} down for {{.DownFor}}",
+		Body:    "The service <strong>{{.ServiceName}}</strong> has been down for {{.DownFor}} and the outage has not been acknowledged. Escalating to additional contacts.",
+	},
+	TemplateDigestDown: {
+		Subject: "🔴 {{.Count}} Services Down{{if .RootCause}}: {{.RootCause}}{{end}}",
+		Body: "{{.Count}} services stopped responding at the same time: {{.Services}}." +
+			"{{if .RootCause}} The likely root cause is <strong>{{.RootCause}}</strong>, which the others depend on.{{end}}",
+	},
+	TemplateDigestUp: {
+		Subject: "✅ {{.Count}} Services Recovered",
+		Body:    "{{.Count}} services have recovered and are responding normally again: {{.Services}}.",
+	},
+	TemplateResource: {
+		Subject: "🌡️ Resource Alert: {{.ServiceName}}",
+		Body:    "The resource rule <strong>{{.Condition}}</strong> is firing: {{.Metric}} is {{.Value}}{{if .Threshold}} (threshold {{.Threshold}}){{end}}.",
+	},
+	TemplateResourceResolved: {
+		Subject: "✅ Resource Alert Resolved: {{.ServiceName}}",
+		Body:    "The resource rule <strong>{{.Condition}}</strong> has cleared: {{.Metric}} is back at {{.Value}}.",
+	},
+}
+
+// statusForEvent maps a template event to the status type channels colour their message by
+func statusForEvent(event string) string {
+	switch event {
+	case TemplateRepeat, TemplateEscalation, TemplateDigestDown:
+		return EventDown
+	case TemplateDigestUp, TemplateResourceResolved:
+		return EventUp
+	case EventFlapping, EventLatency, TemplateResource:
+		return EventDegraded
+	}
+	return event
+}
+
+// ValidateTemplate checks that a template override targets a known event and channel type
+// and that both parts parse and render against sample data.
+func ValidateTemplate(t *models.NotificationTemplate) error {
+	t.Event = strings.ToLower(strings.TrimSpace(t.Event))
+	t.ChannelType = strings.ToLower(strings.TrimSpace(t.ChannelType))
+	if !slices.Contains(TemplateEvents, t.Event) {
+		return fmt.Errorf("unknown template event %q (supported: %s)", t.Event, strings.Join(TemplateEvents, ", "))
+	}
+	if _, ok := channelTypes[t.ChannelType]; t.ChannelType != "" && !ok {
+		return fmt.Errorf("unknown channel type %q", t.ChannelType)
+	}
+	if strings.TrimSpace(t.Subject) == "" && strings.TrimSpace(t.Body) == "" {
+		return fmt.Errorf("subject or body is required")
+	}
+	sample := SampleTemplateData(t.Event, "")
+	if _, err := renderTemplate(t.Subject, sample); err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	if _, err := renderTemplate(t.Body, sample); err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	return nil
+}
+
+// renderTemplate executes one template source against data
+func renderTemplate(src string, data TemplateData) (string, error) {
+	tmpl, err := template.New("notification").Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// templateFor returns the template for an event on a channel type: the channel type's
+// override, then the all-channels override, then the default. Empty override parts keep
+// the default.
+func templateFor(event, channelType string) MessageTemplate {
+	tmpl := DefaultTemplates[event]
+	for _, ct := range []string{channelType, ""} {
+		override, err := database.GetNotificationTemplate(event, ct)
+		if err != nil || override == nil {
+			continue
+		}
+		if strings.TrimSpace(override.Subject) != "" {
+			tmpl.Subject = override.Subject
+		}
+		if strings.TrimSpace(override.Body) != "" {
+			tmpl.Body = override.Body
+		}
+		break
+	}
+	return tmpl
+}
+
+// RenderTemplate renders a template pair, falling back to the event's default for any
+// part that fails so a broken override never suppresses an alert.
+func RenderTemplate(event string, tmpl MessageTemplate, data TemplateData) (subject, body string) {
+	def := DefaultTemplates[event]
+	subject, err := renderTemplate(tmpl.Subject, data)
+	if err != nil {
+		_ = database.InsertLog(database.LogLevelWarn, "notification", data.ServiceKey, "Notification template failed, using default",
+			fmt.Sprintf("event=%s, subject: %v", event, err))
+		subject, _ = renderTemplate(def.Subject, data)
+	}
+	body, err = renderTemplate(tmpl.Body, data)
+	if err != nil {
+		_ = database.InsertLog(database.LogLevelWarn, "notification", data.ServiceKey, "Notification template failed, using default",
+			fmt.Sprintf("event=%s, body: %v", event, err))
+		body, _ = renderTemplate(def.Body, data)
+	}
+	return subject, body
+}
+
+// templateData fills the service-level template fields for an alert
+func (m *Manager) templateData(svc *models.ServiceConfig, event, serviceKey, serviceName string) TemplateData {
+	data := TemplateData{
+		Event:         event,
+		ServiceName:   serviceName,
+		ServiceKey:    serviceKey,
+		StatusPageURL: m.ResolveStatusPageURL(""),
+		Time:          time.Now().Format(time.RFC1123),
+	}
+	data.DayURL = dayURL(data.StatusPageURL, serviceKey, time.Now())
+	if svc != nil {
+		data.ServiceURL = svc.URL
+		data.Dependencies = strings.Join(splitList(svc.DependsOn), ",")
+		data.Critical = svc.Critical
+	}
+	return data
+}
+
+// dayURL links to the dashboard's day-detail view of a service for the UTC day of t
+func dayURL(statusPageURL, serviceKey string, t time.Time) string {
+	if statusPageURL == "" || serviceKey == "" {
+		return ""
+	}
+	return strings.TrimRight(statusPageURL, "/") + "/?service=" + url.QueryEscape(serviceKey) + "&day=" + t.UTC().Format("2006-01-02")
+}
+
+// SampleTemplateData is example data used to validate and preview templates
+func SampleTemplateData(event, statusPageURL string) TemplateData {
+	if statusPageURL == "" {
+		statusPageURL = "https://status.example.com"
+	}
+	data := TemplateData{
+		Event:         event,
+		ServiceName:   "Plex",
+		ServiceKey:    "plex",
+		ServiceURL:    "http://plex.local:32400",
+		LatencyMS:     245,
+		StatusPageURL: statusPageURL,
+		DayURL:        dayURL(statusPageURL, "plex", time.Now()),
+		Dependencies:  "nas",
+		Time:          time.Now().Format(time.RFC1123),
+	}
+	switch event {
+	case EventDown, TemplateRepeat, TemplateEscalation:
+		data.Error = "dial tcp 192.168.1.10:32400: connect: connection refused"
+		data.LatencyMS = 0
+		data.DownFor = "45m"
+		data.AckURL = statusPageURL + "/?ack=sample"
+	case EventDegraded:
+		data.Warnings = "Indexer Prowlarr is unavailable"
+	case EventChanged:
+		data.OldHash = "3f2a9c1b7d4e"
+		data.NewHash = "8b1e0f6a2c93"
+	case EventFlapping:
+		data.Transitions = 6
+		data.FlapWindow = "10m"
+	case EventLatency:
+		data.LatencyMS = 152
+		data.BaselineMS = 15
+		data.Sigma = 27.4
+		data.AnomalyFor = "10m"
+	case TemplateResource, TemplateResourceResolved:
+		data.ServiceName = "Disk usage"
+		data.ServiceKey = ""
+		data.ServiceURL = ""
+		data.DayURL = ""
+		data.LatencyMS = 0
+		data.Dependencies = ""
+		data.Condition = "fs_used_percent > 90 for 10m"
+		data.Metric = "fs_used_percent"
+		data.Value = "93.4"
+		data.Threshold = "90"
+		if event == TemplateResourceResolved {
+			data.Value = "84.2"
+		}
+	case TemplateDigestDown, TemplateDigestUp:
+		data.ServiceName = "3 services"
+		data.ServiceKey = "nas"
+		data.ServiceURL = "http://nas.local"
+		data.DayURL = dayURL(statusPageURL, "nas", time.Now())
+		data.LatencyMS = 0
+		data.Dependencies = ""
+		data.Count = 3
+		data.Services = "NAS, Plex, Sonarr"
+		data.RootCause = "NAS"
+		if event == TemplateDigestUp {
+			data.RootCause = ""
+		}
+	}
+	return data
+}
+
+// PreviewTemplate renders a template override with sample data. Empty parts fall back to the
+// saved template for the event and channel type, so an empty request previews what is in use.
+func (m *Manager) PreviewTemplate(t *models.NotificationTemplate) (subject, body string, err error) {
+	t.Event = strings.ToLower(strings.TrimSpace(t.Event))
+	t.ChannelType = strings.ToLower(strings.TrimSpace(t.ChannelType))
+	if !slices.Contains(TemplateEvents, t.Event) {
+		return "", "", fmt.Errorf("unknown template event %q", t.Event)
+	}
+	current := templateFor(t.Event, t.ChannelType)
+	if strings.TrimSpace(t.Subject) == "" {
+		t.Subject = current.Subject
+	}
+	if strings.TrimSpace(t.Body) == "" {
+		t.Body = current.Body
+	}
+
+	data := SampleTemplateData(t.Event, m.ResolveStatusPageURL(""))
+	if subject, err = renderTemplate(t.Subject, data); err != nil {
+		return "", "", fmt.Errorf("subject: %w", err)
+	}
+	if body, err = renderTemplate(t.Body, data); err != nil {
+		return "", "", fmt.Errorf("body: %w", err)
+	}
+	return subject, body, nil
+}
@@ -6,49 +6,55 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"status/app/internal/database"
+	"status/app/internal/models"
 	"strings"
 	"time"
 )
 
-// SendWebhook sends a JSON payload to a generic webhook URL with optional HMAC signing
+// SendWebhook sends a JSON payload to the legacy generic webhook URL
 func (m *Manager) SendWebhook(subject, statusType, serviceName, serviceKey, message string) {
+	ch := models.NotificationChannel{Type: models.ChannelWebhook, Name: "Webhook",
+		Settings: map[string]string{"url": m.config.WebhookURL, "secret": m.config.WebhookSecret}}
+	_ = m.Deliver(ch, Notification{Subject: subject, StatusType: statusType, ServiceName: serviceName, ServiceKey: serviceKey, Message: message})
+}
+
+// sendWebhook posts a JSON payload to a generic webhook URL with optional HMAC signing
+func sendWebhook(settings map[string]string, n Notification) error {
 	payload := map[string]interface{}{
 		"event":        "status_change",
-		"service_key":  serviceKey,
-		"service_name": serviceName,
-		"status":       statusType,
-		"subject":      subject,
-		"message":      strings.ReplaceAll(strings.ReplaceAll(message, "<strong>", ""), "</strong>", ""),
+		"service_key":  n.ServiceKey,
+		"service_name": n.ServiceName,
+		"status":       n.StatusType,
+		"subject":      n.Subject,
+		"message":      strings.ReplaceAll(strings.ReplaceAll(n.Message, "<strong>", ""), "</strong>", ""),
 		"timestamp":    time.Now().UTC().Format(time.RFC3339),
 	}
+	if n.AckURL != "" {
+		payload["ack_url"] = n.AckURL
+	}
 
 	body, _ := json.Marshal(payload)
 
-	req, err := http.NewRequest("POST", m.config.WebhookURL, bytes.NewReader(body))
+	req, err := http.NewRequest("POST", settings["url"], bytes.NewReader(body))
 	if err != nil {
-		_ = database.InsertLog(database.LogLevelError, "notification", serviceName, "Webhook request failed", err.Error())
-		return
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Servicarr/1.0")
 
 	// HMAC-SHA256 signature
-	if m.config.WebhookSecret != "" {
-		mac := hmac.New(sha256.New, []byte(m.config.WebhookSecret))
+	if secret := settings["secret"]; secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
 		mac.Write(body)
 		sig := hex.EncodeToString(mac.Sum(nil))
 		req.Header.Set("X-Servicarr-Signature", "sha256="+sig)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := channelHTTPClient.Do(req)
 	if err != nil {
-		_ = database.InsertLog(database.LogLevelError, "notification", serviceName, "Webhook notification failed", err.Error())
-		return
+		return err
 	}
 	defer resp.Body.Close()
-	_ = database.InsertLog(database.LogLevelInfo, "notification", serviceName, "Webhook notification sent", fmt.Sprintf("url=%s, status=%d", m.config.WebhookURL, resp.StatusCode))
+	return checkResponse(resp)
 }
@@ -410,3 +410,52 @@ func TestMakeSessionCookie_SpecialCharsInUsername(t *testing.T) {
 		t.Errorf("expected user %q, got %q", `admin"test`, sess.U)
 	}
 }
+
+// --- Signed tokens ---
+
+func TestSignAndVerifyToken(t *testing.T) {
+	a := testAuth(t)
+	tok := a.SignToken("ack", "42", time.Hour)
+	got, err := a.VerifyToken("ack", tok)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "42" {
+		t.Errorf("subject = %q, want 42", got)
+	}
+}
+
+func TestVerifyToken_WrongPurpose(t *testing.T) {
+	a := testAuth(t)
+	tok := a.SignToken("ack", "42", time.Hour)
+	if _, err := a.VerifyToken("reset", tok); err == nil {
+		t.Error("token should not verify for a different purpose")
+	}
+}
+
+func TestVerifyToken_Expired(t *testing.T) {
+	a := testAuth(t)
+	tok := a.SignToken("ack", "42", -time.Minute)
+	if _, err := a.VerifyToken("ack", tok); err == nil {
+		t.Error("expired token should be rejected")
+	}
+}
+
+func TestVerifyToken_WrongSecret(t *testing.T) {
+	a := testAuth(t)
+	tok := a.SignToken("ack", "42", time.Hour)
+	a.Reload(a.User, a.Hash, []byte("another-secret"))
+	if _, err := a.VerifyToken("ack", tok); err == nil {
+		t.Error("token signed with another secret should be rejected")
+	}
+}
+
+func TestSignToken_NotASessionCookie(t *testing.T) {
+	a := testAuth(t)
+	tok := a.SignToken("ack", "42", time.Hour)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sess", Value: tok})
+	if _, err := a.ParseSession(req); err == nil {
+		t.Error("a signed token must not be accepted as a session")
+	}
+}
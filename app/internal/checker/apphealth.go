@@ -0,0 +1,143 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// appHealthPaths maps service types to the endpoint queried in app health mode.
+// *arr apps report health warnings; media servers report active sessions.
+var appHealthPaths = map[string]string{
+	"sonarr":   "/api/v3/health",
+	"radarr":   "/api/v3/health",
+	"lidarr":   "/api/v1/health",
+	"readarr":  "/api/v1/health",
+	"prowlarr": "/api/v1/health",
+	"plex":     "/status/sessions",
+	"jellyfin": "/Sessions?activeWithinSeconds=960",
+	"emby":     "/Sessions?activeWithinSeconds=960",
+}
+
+// apiPathMarkers are the path segments where a template's URL suffix begins;
+// everything before them (including any reverse-proxy prefix) is the app base URL.
+var apiPathMarkers = []string{"/api/", "/identity", "/system/", "/status/", "/sessions", "/web/"}
+
+// SupportsAppHealth reports whether a service type has an app health endpoint.
+func SupportsAppHealth(serviceType string) bool {
+	_, ok := appHealthPaths[strings.ToLower(serviceType)]
+	return ok
+}
+
+// appBaseURL strips the health-check suffix (and query) from a service URL.
+func appBaseURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	path := u.Path
+	lower := strings.ToLower(path)
+	for _, marker := range apiPathMarkers {
+		if i := strings.Index(lower, marker); i >= 0 {
+			path = path[:i]
+			lower = lower[:i]
+		}
+	}
+	u.Path = strings.TrimSuffix(path, "/")
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String(), nil
+}
+
+// arrHealthItem is one entry of the *arr /health response.
+type arrHealthItem struct {
+	Source  string `json:"source"`
+	Type    string `json:"type"` // ok, notice, warning, error
+	Message string `json:"message"`
+}
+
+// applyAppHealth queries the app health endpoint of a responding service and
+// attaches its warnings and metrics to the result.
+func applyAppHealth(res *Result, client *http.Client, rawURL string, opts CheckOptions) {
+	serviceType := strings.ToLower(opts.ServiceType)
+	path, ok := appHealthPaths[serviceType]
+	if !ok {
+		return
+	}
+	base, err := appBaseURL(rawURL)
+	if err != nil {
+		res.Warnings = append(res.Warnings, "app health unavailable: invalid URL")
+		return
+	}
+
+	req, err := http.NewRequest("GET", base+path, nil)
+	if err != nil {
+		res.Warnings = append(res.Warnings, "app health unavailable: invalid URL")
+		return
+	}
+	req.Header.Set("User-Agent", "Servicarr/1.0")
+	req.Header.Set("Accept", "application/json")
+	setAuthHeaders(req, opts.ServiceType, opts.APIToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Warnings = append(res.Warnings, "app health unavailable: "+SanitizeError(err.Error()))
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyRead))
+	if resp.StatusCode != http.StatusOK {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("app health unavailable: HTTP %d", resp.StatusCode))
+		return
+	}
+
+	switch serviceType {
+	case "plex":
+		var sessions struct {
+			MediaContainer struct {
+				Size int `json:"size"`
+			} `json:"MediaContainer"`
+		}
+		if err := json.Unmarshal(body, &sessions); err != nil {
+			res.Warnings = append(res.Warnings, "app health unavailable: unexpected response")
+			return
+		}
+		res.addMetric("streams", float64(sessions.MediaContainer.Size))
+	case "jellyfin", "emby":
+		var sessions []struct {
+			NowPlayingItem json.RawMessage `json:"NowPlayingItem"`
+		}
+		if err := json.Unmarshal(body, &sessions); err != nil {
+			res.Warnings = append(res.Warnings, "app health unavailable: unexpected response")
+			return
+		}
+		streams := 0
+		for _, s := range sessions {
+			if len(s.NowPlayingItem) > 0 && string(s.NowPlayingItem) != "null" {
+				streams++
+			}
+		}
+		res.addMetric("streams", float64(streams))
+	default:
+		var items []arrHealthItem
+		if err := json.Unmarshal(body, &items); err != nil {
+			res.Warnings = append(res.Warnings, "app health unavailable: unexpected response")
+			return
+		}
+		for _, it := range items {
+			if it.Type == "warning" || it.Type == "error" {
+				res.Warnings = append(res.Warnings, it.Message)
+			}
+		}
+	}
+}
+
+func (r *Result) addMetric(name string, value float64) {
+	if r.Metrics == nil {
+		r.Metrics = map[string]float64{}
+	}
+	r.Metrics[name] = value
+}
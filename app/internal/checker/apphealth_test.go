@@ -0,0 +1,155 @@
+package checker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSupportsAppHealth(t *testing.T) {
+	for _, st := range []string{"sonarr", "Radarr", "prowlarr", "plex", "jellyfin"} {
+		if !SupportsAppHealth(st) {
+			t.Errorf("%s should support app health", st)
+		}
+	}
+	if SupportsAppHealth("custom") {
+		t.Error("custom should not support app health")
+	}
+}
+
+func TestAppBaseURL(t *testing.T) {
+	cases := map[string]string{
+		"http://host:8989/api/v3/system/status":           "http://host:8989",
+		"https://example.com/sonarr/api/v3/system/status": "https://example.com/sonarr",
+		"http://plex:32400/identity?X-Plex-Token=abc":     "http://plex:32400",
+		"http://jf:8096/System/Ping":                      "http://jf:8096",
+		"http://host:7878":                                "http://host:7878",
+	}
+	for in, want := range cases {
+		got, err := appBaseURL(in)
+		if err != nil || got != want {
+			t.Errorf("appBaseURL(%q) = %q, want %q (err=%v)", in, got, want, err)
+		}
+	}
+}
+
+func TestRun_AppHealth_ArrWarnings(t *testing.T) {
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/system/status":
+			w.Write([]byte(`{"version":"4.0"}`))
+		case "/api/v3/health":
+			gotKey = r.Header.Get("X-Api-Key")
+			w.Write([]byte(`[
+				{"source":"IndexerStatusCheck","type":"warning","message":"Indexers unavailable due to failures"},
+				{"source":"UpdateCheck","type":"notice","message":"New update available"},
+				{"source":"DownloadClientCheck","type":"error","message":"No download client is available"}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL + "/api/v3/system/status", Timeout: 2 * time.Second,
+		ServiceType: "sonarr", APIToken: "secret", AppHealth: true})
+	if !res.OK {
+		t.Fatalf("expected OK, got %q", res.Err)
+	}
+	if gotKey != "secret" {
+		t.Errorf("health request should carry the API key, got %q", gotKey)
+	}
+	if len(res.Warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", res.Warnings)
+	}
+	if !IsDegraded(res, "") {
+		t.Error("warnings should mark the service degraded")
+	}
+}
+
+func TestRun_AppHealth_ArrHealthy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL + "/api/v1/system/status", Timeout: 2 * time.Second,
+		ServiceType: "prowlarr", AppHealth: true})
+	if !res.OK || len(res.Warnings) != 0 {
+		t.Errorf("expected healthy result, got %+v", res)
+	}
+}
+
+func TestRun_AppHealth_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/health" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL + "/api/v3/system/status", Timeout: 2 * time.Second,
+		ServiceType: "radarr", AppHealth: true})
+	if !res.OK || len(res.Warnings) != 1 {
+		t.Errorf("an unreachable health endpoint should degrade, not fail: %+v", res)
+	}
+}
+
+func TestRun_AppHealth_PlexStreams(t *testing.T) {
+	var gotToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status/sessions" {
+			gotToken = r.Header.Get("X-Plex-Token")
+			w.Write([]byte(`{"MediaContainer":{"size":2}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL + "/identity", Timeout: 2 * time.Second,
+		ServiceType: "plex", APIToken: "plextoken", AppHealth: true})
+	if gotToken != "plextoken" {
+		t.Errorf("expected plex token header, got %q", gotToken)
+	}
+	if res.Metrics["streams"] != 2 {
+		t.Errorf("expected 2 streams, got %v", res.Metrics)
+	}
+}
+
+func TestRun_AppHealth_JellyfinStreams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/Sessions" {
+			w.Write([]byte(`[{"Id":"a","NowPlayingItem":{"Name":"Film"}},{"Id":"b"},{"Id":"c","NowPlayingItem":null}]`))
+			return
+		}
+		w.Write([]byte(`"Jellyfin Server"`))
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL + "/System/Ping", Timeout: 2 * time.Second,
+		ServiceType: "jellyfin", AppHealth: true})
+	if res.Metrics["streams"] != 1 {
+		t.Errorf("expected 1 active stream, got %v", res.Metrics)
+	}
+}
+
+func TestRun_AppHealth_Disabled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/health" {
+			t.Error("health endpoint should not be queried when app health is off")
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	Run(CheckOptions{URL: srv.URL + "/api/v3/system/status", Timeout: 2 * time.Second, ServiceType: "sonarr"})
+}
@@ -2,10 +2,14 @@ package checker
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"regexp"
 	"status/app/internal/models"
@@ -95,9 +99,137 @@ type CheckOptions struct {
 	Timeout     time.Duration
 	ExpectedMin int
 	ExpectedMax int
-	CheckType   string // http, tcp, dns
+	CheckType   string // http, tcp, dns, minecraft, a2s
 	ServiceType string // plex, sonarr, etc. (used for token/header rules)
 	APIToken    string
+
+	AppHealth     bool                 // HTTP: also query the app's own health endpoint (*arr, Plex, Jellyfin, Emby)
+	EntityChecks  []models.EntityCheck // HTTP: Home Assistant entity rules
+	HashContent   bool                 // HTTP: hash the response body for change detection
+	TimeTransfer  bool                 // HTTP: read the response body to time the transfer phase
+	ContentIgnore []*regexp.Regexp     // HTTP: sections removed from the body before hashing
+}
+
+// Result holds the outcome of a single health check.
+type Result struct {
+	OK          bool
+	Code        int
+	MS          *int // Total latency, including the body transfer when the body is read
+	Err         string
+	Timings     *models.HTTPTimings   // HTTP checks only
+	ContentHash string                // Hex SHA-256 of the body when HashContent is set
+	Game        *models.GameInfo      // Game server checks only
+	Metrics     map[string]float64    // Extra numeric metrics reported by the check (e.g. players)
+	Warnings    []string              // App health warnings; a responding service with warnings is degraded
+	Entities    []models.EntityResult // Home Assistant entity rule results
+}
+
+// DegradedThresholdMS is the latency above which a responding service is reported as degraded.
+const DegradedThresholdMS = 200
+
+// DegradedPhases lists the accepted values for ServiceConfig.DegradedPhase.
+var DegradedPhases = []string{"", "total", "dns", "connect", "tls", "ttfb", "transfer"}
+
+// checkTransport disables keep-alives so every check performs its own DNS lookup,
+// connect and TLS handshake, keeping the per-phase timings meaningful.
+var checkTransport = func() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DisableKeepAlives = true
+	return t
+}()
+
+// maxBodyRead caps how much of a response body is read when timing the transfer phase
+// (and therefore how much is covered by content hashing). The body is only read when content
+// hashing or a transfer-phase degraded check needs it.
+const maxBodyRead = 1 << 20
+
+// OptionsForService builds check options from a stored service configuration.
+func OptionsForService(sc *models.ServiceConfig) CheckOptions {
+	timeout := time.Duration(sc.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	opts := CheckOptions{
+		URL:          sc.URL,
+		Timeout:      timeout,
+		ExpectedMin:  sc.ExpectedMin,
+		ExpectedMax:  sc.ExpectedMax,
+		CheckType:    sc.CheckType,
+		ServiceType:  sc.ServiceType,
+		APIToken:     sc.APIToken,
+		AppHealth:    sc.AppHealth,
+		EntityChecks: sc.EntityChecks,
+		HashContent:  sc.ContentCheck,
+		TimeTransfer: strings.EqualFold(strings.TrimSpace(sc.DegradedPhase), "transfer"),
+	}
+	if sc.ContentCheck {
+		patterns, err := ParseIgnorePatterns(sc.ContentIgnore)
+		if err != nil {
+			log.Printf("content ignore patterns for %s: %v", sc.Key, err)
+		}
+		opts.ContentIgnore = patterns
+	}
+	return opts
+}
+
+// ParseIgnorePatterns compiles newline-separated regular expressions.
+// Blank lines are skipped; the first invalid pattern is reported but the
+// valid ones are still returned.
+func ParseIgnorePatterns(raw string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	var firstErr error
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("invalid ignore pattern %q: %w", line, err)
+			}
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, firstErr
+}
+
+// HashContent returns the hex SHA-256 of body after removing every match of the ignore patterns.
+func HashContent(body []byte, ignore []*regexp.Regexp) string {
+	for _, re := range ignore {
+		body = re.ReplaceAll(body, nil)
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsDegraded reports whether a responding service should be flagged as degraded.
+// Any app health or entity warning degrades the service; otherwise phase selects the HTTP
+// timing compared against the threshold, and empty or "total" (or a check
+// without timings) uses the overall latency.
+func IsDegraded(res Result, phase string) bool {
+	if len(res.Warnings) > 0 {
+		return true
+	}
+	if res.MS == nil {
+		return false
+	}
+	if res.Timings != nil {
+		switch strings.ToLower(strings.TrimSpace(phase)) {
+		case "dns":
+			return res.Timings.DNSMs > DegradedThresholdMS
+		case "connect":
+			return res.Timings.ConnectMs > DegradedThresholdMS
+		case "tls":
+			return res.Timings.TLSMs > DegradedThresholdMS
+		case "ttfb":
+			return res.Timings.TTFBMs > DegradedThresholdMS
+		case "transfer":
+			return res.Timings.TransferMs > DegradedThresholdMS
+		}
+	}
+	return *res.MS > DegradedThresholdMS
 }
 
 // HTTPCheck performs a basic HTTP/TCP/DNS check (backward-compatible wrapper).
@@ -112,6 +244,13 @@ func HTTPCheck(url string, timeout time.Duration, minOK, maxOK int) (ok bool, co
 
 // Check performs a health check on a service with support for http/tcp/dns and API tokens.
 func Check(opts CheckOptions) (ok bool, code int, ms *int, errStr string) {
+	res := Run(opts)
+	return res.OK, res.Code, res.MS, res.Err
+}
+
+// Run performs a health check and returns the full result, including
+// per-phase timings for HTTP checks.
+func Run(opts CheckOptions) Result {
 	checkType := strings.ToLower(strings.TrimSpace(opts.CheckType))
 	url := strings.TrimSpace(opts.URL)
 
@@ -131,6 +270,10 @@ func Check(opts CheckOptions) (ok bool, code int, ms *int, errStr string) {
 			checkType = "tcp"
 		} else if strings.HasPrefix(url, "dns://") {
 			checkType = "dns"
+		} else if strings.HasPrefix(url, "minecraft://") {
+			checkType = "minecraft"
+		} else if strings.HasPrefix(url, "a2s://") {
+			checkType = "a2s"
 		} else {
 			checkType = "http"
 		}
@@ -139,20 +282,18 @@ func Check(opts CheckOptions) (ok bool, code int, ms *int, errStr string) {
 	switch checkType {
 	case "always_up", "demo":
 		d := 0
-		ms = &d
-		return true, http.StatusOK, ms, ""
+		return Result{OK: true, Code: http.StatusOK, MS: &d}
 	case "tcp":
 		addr := strings.TrimPrefix(url, "tcp://")
 		t0 := time.Now()
 		conn, err := net.DialTimeout("tcp", addr, opts.Timeout)
 		d := int(time.Since(t0).Milliseconds())
-		ms = &d
 		if err != nil {
 			log.Printf("tcp check error addr=%s err=%v", addr, err)
-			return false, 0, nil, err.Error()
+			return Result{Err: err.Error()}
 		}
 		_ = conn.Close()
-		return true, 0, ms, ""
+		return Result{OK: true, MS: &d}
 	case "dns":
 		hostname := strings.TrimPrefix(url, "dns://")
 		t0 := time.Now()
@@ -160,80 +301,116 @@ func Check(opts CheckOptions) (ok bool, code int, ms *int, errStr string) {
 		defer cancel()
 		addrs, err := net.DefaultResolver.LookupHost(ctx, hostname)
 		d := int(time.Since(t0).Milliseconds())
-		ms = &d
 		if err != nil {
 			log.Printf("dns check error hostname=%s err=%v", hostname, err)
-			return false, 0, ms, err.Error()
+			return Result{MS: &d, Err: err.Error()}
 		}
 		if len(addrs) == 0 {
 			log.Printf("dns check error hostname=%s no addresses returned", hostname)
-			return false, 0, ms, "no addresses returned"
+			return Result{MS: &d, Err: "no addresses returned"}
 		}
 		log.Printf("dns check success hostname=%s resolved to %v", hostname, addrs)
-		return true, 0, ms, ""
+		return Result{OK: true, MS: &d}
+	case "minecraft":
+		return runMinecraft(url, opts.Timeout)
+	case "a2s":
+		return runA2S(url, opts.Timeout)
 	default:
-		// HTTP/HTTPS — SSRF: block cloud metadata endpoints
-		if err := ValidateURLTarget(url); err != nil {
-			log.Printf("SSRF blocked: %v", err)
-			return false, 0, nil, err.Error()
-		}
-		client := &http.Client{Timeout: opts.Timeout}
-		t0 := time.Now()
+		return runHTTP(url, opts)
+	}
+}
 
-		testURL := url
-		if opts.APIToken != "" && strings.ToLower(opts.ServiceType) == "plex" {
-			if strings.Contains(testURL, "?") {
-				testURL += "&X-Plex-Token=" + opts.APIToken
-			} else {
-				testURL += "?X-Plex-Token=" + opts.APIToken
-			}
-		}
+// runHTTP performs an HTTP/HTTPS check, tracing each connection phase.
+func runHTTP(url string, opts CheckOptions) Result {
+	// SSRF: block cloud metadata endpoints
+	if err := ValidateURLTarget(url); err != nil {
+		log.Printf("SSRF blocked: %v", err)
+		return Result{Err: err.Error()}
+	}
+	client := &http.Client{Timeout: opts.Timeout, Transport: checkTransport}
 
-		req, err := http.NewRequest("GET", testURL, nil)
-		if err != nil {
-			return false, 0, nil, "invalid URL"
-		}
-		req.Header.Set("User-Agent", "Servicarr/1.0")
-		req.Header.Set("Accept", "application/json")
-
-		if token := strings.TrimSpace(opts.APIToken); token != "" {
-			switch strings.ToLower(opts.ServiceType) {
-			case "plex":
-				req.Header.Set("X-Plex-Token", token)
-			case "sonarr", "radarr", "lidarr", "readarr", "prowlarr", "bazarr":
-				req.Header.Set("X-Api-Key", token)
-			case "overseerr", "jellyseerr":
-				req.Header.Set("X-Api-Key", token)
-			case "tautulli":
-				if strings.Contains(req.URL.String(), "?") {
-					req.URL.RawQuery += "&apikey=" + token
-				} else {
-					req.URL.RawQuery = "apikey=" + token
-				}
-			case "jellyfin", "emby":
-				req.Header.Set("X-Emby-Token", token)
-			case "homeassistant":
-				if strings.HasPrefix(strings.ToLower(token), "bearer ") {
-					req.Header.Set("Authorization", token)
-				} else {
-					req.Header.Set("Authorization", "Bearer "+token)
-				}
-			default:
-				req.Header.Set("X-Api-Key", token)
-				req.Header.Set("Authorization", "Bearer "+token)
-			}
+	testURL := url
+	if opts.APIToken != "" && strings.ToLower(opts.ServiceType) == "plex" {
+		if strings.Contains(testURL, "?") {
+			testURL += "&X-Plex-Token=" + opts.APIToken
+		} else {
+			testURL += "?X-Plex-Token=" + opts.APIToken
 		}
+	}
 
-		resp, err := client.Do(req)
-		d := int(time.Since(t0).Milliseconds())
-		ms = &d
-		if err != nil {
-			log.Printf("http check error url=%s err=%v", url, err)
-			return false, 0, nil, err.Error()
+	req, err := http.NewRequest("GET", testURL, nil)
+	if err != nil {
+		return Result{Err: "invalid URL"}
+	}
+	req.Header.Set("User-Agent", "Servicarr/1.0")
+	req.Header.Set("Accept", "application/json")
+	setAuthHeaders(req, opts.ServiceType, opts.APIToken)
+
+	var trace phaseTrace
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	t0 := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Printf("http check error url=%s err=%v", url, err)
+		return Result{Err: err.Error()}
+	}
+	defer resp.Body.Close()
+	// The total covers the transfer whenever it is timed, so the phases add up to it
+	var body []byte
+	var end time.Time
+	if opts.HashContent || opts.TimeTransfer {
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxBodyRead))
+		end = time.Now()
+	}
+	d := int(time.Since(t0).Milliseconds())
+	timings := trace.timings(end)
+
+	ok := resp.StatusCode >= opts.ExpectedMin && resp.StatusCode <= opts.ExpectedMax
+	res := Result{OK: ok, Code: resp.StatusCode, MS: &d, Timings: timings}
+	// Only hash successful responses so an error page isn't reported as a content change
+	if opts.HashContent && ok {
+		res.ContentHash = HashContent(body, opts.ContentIgnore)
+	}
+	if opts.AppHealth && ok {
+		applyAppHealth(&res, client, url, opts)
+	}
+	if len(opts.EntityChecks) > 0 && ok {
+		applyEntityChecks(&res, client, url, opts)
+	}
+	return res
+}
+
+// setAuthHeaders attaches the API token using the convention of the given service type.
+func setAuthHeaders(req *http.Request, serviceType, apiToken string) {
+	token := strings.TrimSpace(apiToken)
+	if token == "" {
+		return
+	}
+	switch strings.ToLower(serviceType) {
+	case "plex":
+		req.Header.Set("X-Plex-Token", token)
+	case "sonarr", "radarr", "lidarr", "readarr", "prowlarr", "bazarr":
+		req.Header.Set("X-Api-Key", token)
+	case "overseerr", "jellyseerr":
+		req.Header.Set("X-Api-Key", token)
+	case "tautulli":
+		if strings.Contains(req.URL.String(), "?") {
+			req.URL.RawQuery += "&apikey=" + token
+		} else {
+			req.URL.RawQuery = "apikey=" + token
+		}
+	case "jellyfin", "emby":
+		req.Header.Set("X-Emby-Token", token)
+	case "homeassistant":
+		if strings.HasPrefix(strings.ToLower(token), "bearer ") {
+			req.Header.Set("Authorization", token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
 		}
-		defer resp.Body.Close()
-		ok = resp.StatusCode >= opts.ExpectedMin && resp.StatusCode <= opts.ExpectedMax
-		return ok, resp.StatusCode, ms, ""
+	default:
+		req.Header.Set("X-Api-Key", token)
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 }
 
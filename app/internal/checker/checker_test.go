@@ -1,6 +1,7 @@
 package checker
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -250,6 +251,115 @@ func TestCheck_InferTCPFromURL(t *testing.T) {
 	_ = errStr
 }
 
+// --- Run / HTTP phase timings ---
+
+func TestRun_HTTP_Timings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL, Timeout: 2 * time.Second})
+	if !res.OK {
+		t.Fatalf("expected ok, got code=%d err=%q", res.Code, res.Err)
+	}
+	if res.Timings == nil {
+		t.Fatal("expected timings for HTTP check")
+	}
+	if res.Timings.TTFBMs < 25 {
+		t.Errorf("ttfb_ms = %d, want >= 25 (server sleeps 30ms)", res.Timings.TTFBMs)
+	}
+	if res.Timings.TLSMs != 0 {
+		t.Errorf("tls_ms = %d, want 0 for plain HTTP", res.Timings.TLSMs)
+	}
+}
+
+func TestRun_HTTP_TransferTimedOnlyWhenNeeded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(40 * time.Millisecond)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL, Timeout: 2 * time.Second, TimeTransfer: true})
+	if res.Timings == nil || res.Timings.TransferMs < 35 {
+		t.Fatalf("transfer should be timed, got %+v", res.Timings)
+	}
+	tm := res.Timings
+	if sum := tm.DNSMs + tm.ConnectMs + tm.TLSMs + tm.TTFBMs + tm.TransferMs; *res.MS < sum {
+		t.Errorf("total %dms should include every phase (%dms)", *res.MS, sum)
+	}
+
+	res = Run(CheckOptions{URL: srv.URL, Timeout: 2 * time.Second})
+	if res.Timings.TransferMs != 0 || *res.MS >= 35 {
+		t.Errorf("the body should not be read without content or transfer checks, got %dms, %+v", *res.MS, res.Timings)
+	}
+}
+
+func TestOptionsForService_TimeTransfer(t *testing.T) {
+	if !OptionsForService(&models.ServiceConfig{URL: "http://x", DegradedPhase: "transfer"}).TimeTransfer {
+		t.Error("a transfer degraded phase should time the transfer")
+	}
+	if OptionsForService(&models.ServiceConfig{URL: "http://x", DegradedPhase: "ttfb"}).TimeTransfer {
+		t.Error("other phases should not read the body")
+	}
+}
+
+func TestPhaseTrace_TLS(t *testing.T) {
+	var trace phaseTrace
+	ct := trace.clientTrace()
+	ct.TLSHandshakeStart()
+	time.Sleep(5 * time.Millisecond)
+	ct.TLSHandshakeDone(tls.ConnectionState{}, nil)
+	if got := trace.timings(time.Now()).TLSMs; got < 4 {
+		t.Errorf("tls_ms = %d, want >= 4", got)
+	}
+}
+
+func TestRun_TCP_NoTimings(t *testing.T) {
+	res := Run(CheckOptions{URL: "tcp://127.0.0.1:1", Timeout: time.Second, CheckType: "tcp"})
+	if res.Timings != nil {
+		t.Error("TCP checks should not report HTTP timings")
+	}
+}
+
+func TestIsDegraded(t *testing.T) {
+	ms := 50
+	res := Result{OK: true, MS: &ms, Timings: &models.HTTPTimings{TTFBMs: 20, DNSMs: 250}}
+	if IsDegraded(res, "") {
+		t.Error("total 50ms should not be degraded")
+	}
+	if !IsDegraded(res, "dns") {
+		t.Error("dns 250ms should be degraded when targeting dns")
+	}
+	if IsDegraded(res, "ttfb") {
+		t.Error("ttfb 20ms should not be degraded")
+	}
+
+	slow := 300
+	noTimings := Result{OK: true, MS: &slow}
+	if !IsDegraded(noTimings, "ttfb") {
+		t.Error("without timings the phase should fall back to total latency")
+	}
+	if IsDegraded(Result{}, "") {
+		t.Error("nil latency should never be degraded")
+	}
+}
+
+func TestOptionsForService_DefaultTimeout(t *testing.T) {
+	opts := OptionsForService(&models.ServiceConfig{URL: "http://x", ServiceType: "sonarr", APIToken: "k"})
+	if opts.Timeout != 5*time.Second {
+		t.Errorf("timeout = %v, want 5s", opts.Timeout)
+	}
+	if opts.ServiceType != "sonarr" || opts.APIToken != "k" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
+
 // --- FindServiceByKey ---
 
 func TestFindServiceByKey_Found(t *testing.T) {
@@ -346,3 +456,67 @@ func containsStr(s, sub string) bool {
 	}
 	return false
 }
+
+// --- content hashing ---
+
+func TestParseIgnorePatterns(t *testing.T) {
+	patterns, err := ParseIgnorePatterns("\\d+\n\n  nonce=\\w+  \n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Errorf("expected 2 patterns, got %d", len(patterns))
+	}
+}
+
+func TestParseIgnorePatterns_Invalid(t *testing.T) {
+	patterns, err := ParseIgnorePatterns("ok\n(unclosed")
+	if err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if len(patterns) != 1 {
+		t.Errorf("valid patterns should still be returned, got %d", len(patterns))
+	}
+}
+
+func TestHashContent_IgnoresPatterns(t *testing.T) {
+	ignore, _ := ParseIgnorePatterns(`generated at \d+`)
+	a := HashContent([]byte("hello generated at 1700000000"), ignore)
+	b := HashContent([]byte("hello generated at 1700000060"), ignore)
+	if a != b {
+		t.Error("hashes should match once ignored text is stripped")
+	}
+	if a == HashContent([]byte("goodbye generated at 1700000000"), ignore) {
+		t.Error("different content should produce a different hash")
+	}
+}
+
+func TestRun_HTTP_ContentHash(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stable body"))
+	}))
+	defer srv.Close()
+
+	opts := CheckOptions{URL: srv.URL, Timeout: 2 * time.Second, ExpectedMin: 200, ExpectedMax: 399, CheckType: "http"}
+	if res := Run(opts); res.ContentHash != "" {
+		t.Error("content should not be hashed unless requested")
+	}
+
+	opts.HashContent = true
+	res := Run(opts)
+	if res.ContentHash != HashContent([]byte("stable body"), nil) {
+		t.Errorf("unexpected content hash %q", res.ContentHash)
+	}
+}
+
+func TestRun_HTTP_ContentHash_SkipsFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	res := Run(CheckOptions{URL: srv.URL, Timeout: 2 * time.Second, ExpectedMin: 200, ExpectedMax: 399, CheckType: "http", HashContent: true})
+	if res.ContentHash != "" {
+		t.Error("failed responses should not be hashed")
+	}
+}
@@ -0,0 +1,297 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"status/app/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	minecraftDefaultPort = "25565"
+	a2sDefaultPort       = "27015"
+
+	// maxMinecraftPacket bounds the status response we are willing to read.
+	maxMinecraftPacket = 1 << 18
+)
+
+// gameAddr strips the scheme from a game server URL and applies the default port.
+func gameAddr(rawURL, scheme, defaultPort string) string {
+	addr := strings.TrimPrefix(rawURL, scheme+"://")
+	addr = strings.TrimSuffix(addr, "/")
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), defaultPort)
+	}
+	return addr
+}
+
+// gameResult converts server info into a check result, exposing player counts as metrics.
+func gameResult(info *models.GameInfo, ms int) Result {
+	return Result{
+		OK:   true,
+		MS:   &ms,
+		Game: info,
+		Metrics: map[string]float64{
+			"players":     float64(info.Players),
+			"max_players": float64(info.MaxPlayers),
+		},
+	}
+}
+
+// --- Minecraft Server List Ping ---
+
+// runMinecraft queries a Minecraft (Java Edition 1.7+) server using the Server List Ping protocol.
+func runMinecraft(rawURL string, timeout time.Duration) Result {
+	addr := gameAddr(rawURL, "minecraft", minecraftDefaultPort)
+	t0 := time.Now()
+	info, err := queryMinecraft(addr, timeout)
+	d := int(time.Since(t0).Milliseconds())
+	if err != nil {
+		log.Printf("minecraft check error addr=%s err=%v", addr, err)
+		return Result{Err: err.Error()}
+	}
+	return gameResult(info, d)
+}
+
+func queryMinecraft(addr string, timeout time.Duration) (*models.GameInfo, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	var port uint16
+	if _, err := fmt.Sscanf(portStr, "%d", &port); err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	// Handshake (next state 1 = status) followed by an empty status request
+	var hs bytes.Buffer
+	writeVarInt(&hs, 0x00)
+	writeVarInt(&hs, -1) // protocol version; -1 asks the server for its own
+	writeVarInt(&hs, len(host))
+	hs.WriteString(host)
+	_ = binary.Write(&hs, binary.BigEndian, port)
+	writeVarInt(&hs, 1)
+
+	var out bytes.Buffer
+	writeVarInt(&out, hs.Len())
+	out.Write(hs.Bytes())
+	writeVarInt(&out, 1)
+	out.WriteByte(0x00)
+	if _, err := conn.Write(out.Bytes()); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 || length > maxMinecraftPacket {
+		return nil, fmt.Errorf("invalid status packet length %d", length)
+	}
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return nil, err
+	}
+
+	pr := bytes.NewReader(packet)
+	if id, err := readVarInt(pr); err != nil || id != 0x00 {
+		return nil, errors.New("unexpected status packet")
+	}
+	n, err := readVarInt(pr)
+	if err != nil || n < 0 || n > pr.Len() {
+		return nil, errors.New("malformed status payload")
+	}
+	payload := make([]byte, n)
+	_, _ = io.ReadFull(pr, payload)
+
+	var status struct {
+		Version struct {
+			Name string `json:"name"`
+		} `json:"version"`
+		Players struct {
+			Max    int `json:"max"`
+			Online int `json:"online"`
+		} `json:"players"`
+		Description json.RawMessage `json:"description"`
+	}
+	if err := json.Unmarshal(payload, &status); err != nil {
+		return nil, fmt.Errorf("invalid status JSON: %w", err)
+	}
+
+	return &models.GameInfo{
+		Name:       minecraftMOTD(status.Description),
+		Players:    status.Players.Online,
+		MaxPlayers: status.Players.Max,
+		Version:    status.Version.Name,
+	}, nil
+}
+
+// formattingCodes matches legacy § colour/format codes in a MOTD.
+var formattingCodes = regexp.MustCompile(`§.`)
+
+// minecraftMOTD flattens a description that is either a plain string or a chat component.
+func minecraftMOTD(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		var component struct {
+			Text  string `json:"text"`
+			Extra []struct {
+				Text string `json:"text"`
+			} `json:"extra"`
+		}
+		if json.Unmarshal(raw, &component) != nil {
+			return ""
+		}
+		text = component.Text
+		for _, e := range component.Extra {
+			text += e.Text
+		}
+	}
+	return strings.TrimSpace(formattingCodes.ReplaceAllString(text, ""))
+}
+
+func writeVarInt(buf *bytes.Buffer, v int) {
+	u := uint32(v)
+	for {
+		if u&^0x7F == 0 {
+			buf.WriteByte(byte(u))
+			return
+		}
+		buf.WriteByte(byte(u&0x7F | 0x80))
+		u >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int, error) {
+	var result uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int(int32(result)), nil
+		}
+	}
+	return 0, errors.New("varint too long")
+}
+
+// --- Valve A2S_INFO ---
+
+var a2sHeader = []byte{0xFF, 0xFF, 0xFF, 0xFF}
+
+// a2sInfoRequest is the A2S_INFO query; a challenge may be appended.
+var a2sInfoRequest = append(append([]byte{}, a2sHeader...), append([]byte{0x54}, "Source Engine Query\x00"...)...)
+
+// runA2S queries a Source-engine (or compatible, e.g. Valheim) server with A2S_INFO over UDP.
+func runA2S(rawURL string, timeout time.Duration) Result {
+	addr := gameAddr(rawURL, "a2s", a2sDefaultPort)
+	t0 := time.Now()
+	info, err := queryA2S(addr, timeout)
+	d := int(time.Since(t0).Milliseconds())
+	if err != nil {
+		log.Printf("a2s check error addr=%s err=%v", addr, err)
+		return Result{Err: err.Error()}
+	}
+	return gameResult(info, d)
+}
+
+func queryA2S(addr string, timeout time.Duration) (*models.GameInfo, error) {
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	req := a2sInfoRequest
+	buf := make([]byte, 1400)
+	// Servers may answer with a challenge that must be echoed back once
+	for attempt := 0; attempt < 2; attempt++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		resp := buf[:n]
+		if len(resp) < 5 || !bytes.Equal(resp[:4], a2sHeader) {
+			return nil, errors.New("unexpected A2S response")
+		}
+		switch resp[4] {
+		case 0x41: // S2C_CHALLENGE
+			if len(resp) < 9 {
+				return nil, errors.New("short A2S challenge")
+			}
+			req = append(append([]byte{}, a2sInfoRequest...), resp[5:9]...)
+		case 0x49: // S2A_INFO
+			return parseA2SInfo(resp[5:])
+		default:
+			return nil, fmt.Errorf("unexpected A2S response type 0x%02x", resp[4])
+		}
+	}
+	return nil, errors.New("A2S challenge not accepted")
+}
+
+// parseA2SInfo decodes the body of an S2A_INFO response (after the 0x49 header byte).
+func parseA2SInfo(b []byte) (*models.GameInfo, error) {
+	r := bytes.NewReader(b)
+	if _, err := r.ReadByte(); err != nil { // protocol
+		return nil, errors.New("short A2S_INFO response")
+	}
+	name, err1 := readCString(r)
+	mapName, err2 := readCString(r)
+	_, err3 := readCString(r) // folder
+	_, err4 := readCString(r) // game
+	if err := errors.Join(err1, err2, err3, err4); err != nil {
+		return nil, errors.New("malformed A2S_INFO response")
+	}
+
+	// id (short), players, max players, bots, server type, environment, visibility, VAC
+	var fixed [9]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, errors.New("short A2S_INFO response")
+	}
+	version, _ := readCString(r)
+
+	return &models.GameInfo{
+		Name:       name,
+		Players:    int(fixed[2]),
+		MaxPlayers: int(fixed[3]),
+		Version:    version,
+		Map:        mapName,
+	}, nil
+}
+
+func readCString(r *bytes.Reader) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == 0 {
+			return sb.String(), nil
+		}
+		sb.WriteByte(c)
+	}
+}
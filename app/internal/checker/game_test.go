@@ -0,0 +1,173 @@
+package checker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// --- Minecraft Server List Ping ---
+
+// fakeMinecraftServer answers a single status request with the given JSON.
+func fakeMinecraftServer(t *testing.T, status string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		// Handshake then status request
+		for i := 0; i < 2; i++ {
+			n, err := readVarInt(r)
+			if err != nil {
+				return
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+				return
+			}
+		}
+		var payload bytes.Buffer
+		writeVarInt(&payload, 0x00)
+		writeVarInt(&payload, len(status))
+		payload.WriteString(status)
+		var out bytes.Buffer
+		writeVarInt(&out, payload.Len())
+		out.Write(payload.Bytes())
+		conn.Write(out.Bytes())
+	}()
+	return ln.Addr().String()
+}
+
+func TestVarIntRoundTrip(t *testing.T) {
+	for _, v := range []int{0, 1, 127, 128, 25565, 2097151, -1} {
+		var buf bytes.Buffer
+		writeVarInt(&buf, v)
+		got, err := readVarInt(&buf)
+		if err != nil || got != v {
+			t.Errorf("varint %d round-tripped to %d (err=%v)", v, got, err)
+		}
+	}
+}
+
+func TestRun_Minecraft(t *testing.T) {
+	addr := fakeMinecraftServer(t, `{"version":{"name":"1.20.4","protocol":765},"players":{"max":20,"online":3},"description":{"text":"§aHello ","extra":[{"text":"World"}]}}`)
+
+	res := Run(CheckOptions{URL: "minecraft://" + addr, Timeout: 2 * time.Second})
+	if !res.OK {
+		t.Fatalf("expected OK, got err %q", res.Err)
+	}
+	if res.Game == nil || res.Game.Players != 3 || res.Game.MaxPlayers != 20 || res.Game.Version != "1.20.4" {
+		t.Errorf("unexpected game info %+v", res.Game)
+	}
+	if res.Game.Name != "Hello World" {
+		t.Errorf("motd = %q", res.Game.Name)
+	}
+	if res.Metrics["players"] != 3 || res.Metrics["max_players"] != 20 {
+		t.Errorf("unexpected metrics %v", res.Metrics)
+	}
+}
+
+func TestRun_Minecraft_InvalidJSON(t *testing.T) {
+	addr := fakeMinecraftServer(t, `not json`)
+	res := Run(CheckOptions{URL: addr, CheckType: "minecraft", Timeout: 2 * time.Second})
+	if res.OK || res.Err == "" {
+		t.Error("expected failure for invalid status JSON")
+	}
+}
+
+func TestMinecraftMOTD_PlainString(t *testing.T) {
+	if got := minecraftMOTD([]byte(`"§6A Server"`)); got != "A Server" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestGameAddr_DefaultPort(t *testing.T) {
+	if got := gameAddr("minecraft://mc.example.com", "minecraft", minecraftDefaultPort); got != "mc.example.com:25565" {
+		t.Errorf("got %q", got)
+	}
+	if got := gameAddr("a2s://10.0.0.5:2457", "a2s", a2sDefaultPort); got != "10.0.0.5:2457" {
+		t.Errorf("got %q", got)
+	}
+}
+
+// --- Valve A2S_INFO ---
+
+func a2sInfoResponse() []byte {
+	var b bytes.Buffer
+	b.Write(a2sHeader)
+	b.WriteByte(0x49)
+	b.WriteByte(17) // protocol
+	b.WriteString("My Valheim\x00")
+	b.WriteString("dedicated\x00")
+	b.WriteString("valheim\x00")
+	b.WriteString("Valheim\x00")
+	binary.Write(&b, binary.LittleEndian, uint16(0))
+	b.Write([]byte{4, 10, 0, 'd', 'l', 1, 0}) // players, max, bots, type, env, visibility, vac
+	b.WriteString("0.217.46\x00")
+	return b.Bytes()
+}
+
+// fakeA2SServer answers A2S_INFO, first demanding a challenge when challenge is set.
+func fakeA2SServer(t *testing.T, challenge bool) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 1400)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:n]
+			if challenge && len(req) == len(a2sInfoRequest) {
+				pc.WriteTo(append(append([]byte{}, a2sHeader...), 0x41, 1, 2, 3, 4), addr)
+				continue
+			}
+			pc.WriteTo(a2sInfoResponse(), addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestRun_A2S(t *testing.T) {
+	addr := fakeA2SServer(t, false)
+	res := Run(CheckOptions{URL: "a2s://" + addr, Timeout: 2 * time.Second})
+	if !res.OK {
+		t.Fatalf("expected OK, got err %q", res.Err)
+	}
+	g := res.Game
+	if g == nil || g.Name != "My Valheim" || g.Map != "dedicated" || g.Players != 4 || g.MaxPlayers != 10 || g.Version != "0.217.46" {
+		t.Errorf("unexpected game info %+v", g)
+	}
+}
+
+func TestRun_A2S_Challenge(t *testing.T) {
+	addr := fakeA2SServer(t, true)
+	res := Run(CheckOptions{URL: addr, CheckType: "a2s", Timeout: 2 * time.Second})
+	if !res.OK || res.Metrics["players"] != 4 {
+		t.Errorf("expected challenge to be answered, got %+v", res)
+	}
+}
+
+func TestParseA2SInfo_Truncated(t *testing.T) {
+	if _, err := parseA2SInfo([]byte{17, 'x'}); err == nil {
+		t.Error("expected error for truncated response")
+	}
+}
@@ -0,0 +1,170 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+)
+
+// EntityOperators lists the comparison operators accepted in EntityCheck rules.
+var EntityOperators = []string{"==", "!=", ">", ">=", "<", "<="}
+
+// ValidateEntityCheck reports a descriptive error for a malformed entity rule.
+func ValidateEntityCheck(ec models.EntityCheck) error {
+	if strings.TrimSpace(ec.EntityID) == "" || !strings.Contains(ec.EntityID, ".") {
+		return fmt.Errorf("invalid entity_id %q", ec.EntityID)
+	}
+	switch ec.Operator {
+	case "==", "!=":
+	case ">", ">=", "<", "<=":
+		if _, err := strconv.ParseFloat(strings.TrimSpace(ec.Value), 64); err != nil {
+			return fmt.Errorf("%s: threshold %q is not a number", ec.EntityID, ec.Value)
+		}
+	default:
+		return fmt.Errorf("%s: invalid operator %q", ec.EntityID, ec.Operator)
+	}
+	switch ec.Severity {
+	case "", "down", "degraded":
+	default:
+		return fmt.Errorf("%s: invalid severity %q", ec.EntityID, ec.Severity)
+	}
+	return nil
+}
+
+// haState is the subset of a Home Assistant /api/states/{entity_id} response we use.
+type haState struct {
+	State      string         `json:"state"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// applyEntityChecks fetches each configured entity and evaluates its rule. A failed
+// rule with severity "down" fails the check; "degraded" rules add a warning.
+func applyEntityChecks(res *Result, client *http.Client, rawURL string, opts CheckOptions) {
+	base, err := appBaseURL(rawURL)
+	if err != nil {
+		res.OK = false
+		res.Err = "invalid URL"
+		return
+	}
+
+	var downMsgs []string
+	for _, ec := range opts.EntityChecks {
+		er := evaluateEntity(client, base, opts, ec)
+		res.Entities = append(res.Entities, er)
+		if er.OK {
+			continue
+		}
+		if er.Severity == "degraded" {
+			res.Warnings = append(res.Warnings, er.Message)
+		} else {
+			downMsgs = append(downMsgs, er.Message)
+		}
+	}
+	if len(downMsgs) > 0 {
+		res.OK = false
+		res.Err = strings.Join(downMsgs, "; ")
+	}
+}
+
+func evaluateEntity(client *http.Client, base string, opts CheckOptions, ec models.EntityCheck) models.EntityResult {
+	er := models.EntityResult{EntityID: ec.EntityID}
+	fail := func(msg string) models.EntityResult {
+		er.Severity = ec.Severity
+		if er.Severity == "" {
+			er.Severity = "down"
+		}
+		er.Message = ec.EntityID + ": " + msg
+		return er
+	}
+
+	req, err := http.NewRequest("GET", base+"/api/states/"+url.PathEscape(ec.EntityID), nil)
+	if err != nil {
+		return fail("invalid entity")
+	}
+	req.Header.Set("User-Agent", "Servicarr/1.0")
+	req.Header.Set("Accept", "application/json")
+	setAuthHeaders(req, opts.ServiceType, opts.APIToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail("unavailable: " + SanitizeError(err.Error()))
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyRead))
+	if resp.StatusCode == http.StatusNotFound {
+		return fail("entity not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fail(fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+
+	var st haState
+	if err := json.Unmarshal(body, &st); err != nil {
+		return fail("unexpected response")
+	}
+
+	actual := st.State
+	label := "state"
+	if ec.Attribute != "" {
+		label = ec.Attribute
+		v, ok := st.Attributes[ec.Attribute]
+		if !ok {
+			return fail("attribute " + ec.Attribute + " not found")
+		}
+		actual = attributeString(v)
+	}
+	er.Value = actual
+
+	if !compareEntityValue(actual, ec.Operator, ec.Value) {
+		return fail(fmt.Sprintf("%s %q does not satisfy %s %s", label, actual, ec.Operator, ec.Value))
+	}
+	er.OK = true
+	return er
+}
+
+// attributeString renders an attribute value for comparison.
+func attributeString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+}
+
+// compareEntityValue applies op to an actual value. == and != compare text
+// case-insensitively; ordering operators require both sides to be numeric.
+func compareEntityValue(actual, op, expected string) bool {
+	switch op {
+	case "==":
+		return strings.EqualFold(strings.TrimSpace(actual), strings.TrimSpace(expected))
+	case "!=":
+		return !strings.EqualFold(strings.TrimSpace(actual), strings.TrimSpace(expected))
+	}
+	a, err1 := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	e, err2 := strconv.ParseFloat(strings.TrimSpace(expected), 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	switch op {
+	case ">":
+		return a > e
+	case ">=":
+		return a >= e
+	case "<":
+		return a < e
+	case "<=":
+		return a <= e
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package checker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"status/app/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeHomeAssistant serves /api/ and /api/states/{entity_id} from the given states.
+func fakeHomeAssistant(t *testing.T, states map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer hatoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/api/" {
+			w.Write([]byte(`{"message":"API running."}`))
+			return
+		}
+		body, ok := states[strings.TrimPrefix(r.URL.Path, "/api/states/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func haOptions(url string, checks ...models.EntityCheck) CheckOptions {
+	return CheckOptions{URL: url + "/api/", Timeout: 2 * time.Second, ServiceType: "homeassistant",
+		APIToken: "hatoken", EntityChecks: checks}
+}
+
+func TestRun_EntityChecks_AllPass(t *testing.T) {
+	srv := fakeHomeAssistant(t, map[string]string{
+		"sensor.ups_status":  `{"state":"Online","attributes":{"battery_level":95}}`,
+		"binary_sensor.door": `{"state":"off","attributes":{}}`,
+	})
+
+	res := Run(haOptions(srv.URL,
+		models.EntityCheck{EntityID: "sensor.ups_status", Operator: "==", Value: "online"},
+		models.EntityCheck{EntityID: "sensor.ups_status", Attribute: "battery_level", Operator: ">=", Value: "50", Severity: "degraded"},
+		models.EntityCheck{EntityID: "binary_sensor.door", Operator: "!=", Value: "on"},
+	))
+	if !res.OK || len(res.Warnings) != 0 {
+		t.Fatalf("expected all rules to pass, got %+v", res)
+	}
+	if len(res.Entities) != 3 || res.Entities[1].Value != "95" {
+		t.Errorf("unexpected entity results %+v", res.Entities)
+	}
+}
+
+func TestRun_EntityChecks_DegradedRule(t *testing.T) {
+	srv := fakeHomeAssistant(t, map[string]string{
+		"sensor.ups_status": `{"state":"on_battery","attributes":{"battery_level":20}}`,
+	})
+
+	res := Run(haOptions(srv.URL,
+		models.EntityCheck{EntityID: "sensor.ups_status", Attribute: "battery_level", Operator: ">=", Value: "50", Severity: "degraded"},
+	))
+	if !res.OK {
+		t.Fatalf("degraded rule should not fail the check: %q", res.Err)
+	}
+	if len(res.Warnings) != 1 || !IsDegraded(res, "") {
+		t.Errorf("expected a degrading warning, got %v", res.Warnings)
+	}
+}
+
+func TestRun_EntityChecks_DownRule(t *testing.T) {
+	srv := fakeHomeAssistant(t, map[string]string{
+		"sensor.backup": `{"state":"failed","attributes":{}}`,
+	})
+
+	res := Run(haOptions(srv.URL,
+		models.EntityCheck{EntityID: "sensor.backup", Operator: "==", Value: "success"},
+		models.EntityCheck{EntityID: "sensor.missing", Operator: "==", Value: "on"},
+	))
+	if res.OK {
+		t.Fatal("down rule should fail the check")
+	}
+	if !strings.Contains(res.Err, "sensor.backup") || !strings.Contains(res.Err, "entity not found") {
+		t.Errorf("error should describe both failures: %q", res.Err)
+	}
+}
+
+func TestCompareEntityValue(t *testing.T) {
+	cases := []struct {
+		actual, op, expected string
+		want                 bool
+	}{
+		{"ON", "==", "on", true},
+		{"on", "!=", "off", true},
+		{"21.5", ">", "20", true},
+		{"20", ">=", "20", true},
+		{"19", "<", "20", true},
+		{"20", "<=", "19", false},
+		{"unavailable", ">", "5", false},
+	}
+	for _, c := range cases {
+		if got := compareEntityValue(c.actual, c.op, c.expected); got != c.want {
+			t.Errorf("%q %s %q = %v, want %v", c.actual, c.op, c.expected, got, c.want)
+		}
+	}
+}
+
+func TestValidateEntityCheck(t *testing.T) {
+	valid := models.EntityCheck{EntityID: "sensor.ups", Operator: "<", Value: "30", Severity: "degraded"}
+	if err := ValidateEntityCheck(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	invalid := []models.EntityCheck{
+		{EntityID: "ups", Operator: "==", Value: "on"},
+		{EntityID: "sensor.ups", Operator: "~", Value: "on"},
+		{EntityID: "sensor.ups", Operator: ">", Value: "high"},
+		{EntityID: "sensor.ups", Operator: "==", Value: "on", Severity: "critical"},
+	}
+	for _, ec := range invalid {
+		if err := ValidateEntityCheck(ec); err == nil {
+			t.Errorf("expected error for %+v", ec)
+		}
+	}
+}
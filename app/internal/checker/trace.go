@@ -0,0 +1,72 @@
+package checker
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"status/app/internal/models"
+	"sync"
+	"time"
+)
+
+// phaseTrace records the timestamps of each phase of an HTTP request.
+// Callbacks may fire from dialer goroutines, so access is guarded by a mutex.
+type phaseTrace struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+// clientTrace returns the httptrace hooks that populate the trace.
+func (p *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	mark := func(field *time.Time, onlyFirst bool) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if onlyFirst && !field.IsZero() {
+			return
+		}
+		*field = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { mark(&p.dnsStart, true) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { mark(&p.dnsDone, false) },
+		// With dual-stack dialing several connects may race; measure from the first start to the winner.
+		ConnectStart: func(string, string) { mark(&p.connectStart, true) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				mark(&p.connectDone, true)
+			}
+		},
+		TLSHandshakeStart:    func() { mark(&p.tlsStart, true) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { mark(&p.tlsDone, false) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { mark(&p.wroteRequest, false) },
+		GotFirstResponseByte: func() { mark(&p.firstByte, false) },
+	}
+}
+
+// timings converts the recorded timestamps into phase durations.
+// end is the moment the response body finished reading, or zero when it was not read.
+func (p *phaseTrace) timings(end time.Time) *models.HTTPTimings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &models.HTTPTimings{
+		DNSMs:      spanMs(p.dnsStart, p.dnsDone),
+		ConnectMs:  spanMs(p.connectStart, p.connectDone),
+		TLSMs:      spanMs(p.tlsStart, p.tlsDone),
+		TTFBMs:     spanMs(p.wroteRequest, p.firstByte),
+		TransferMs: spanMs(p.firstByte, end),
+	}
+}
+
+// spanMs returns the milliseconds between two timestamps, or 0 if either is missing.
+func spanMs(start, end time.Time) int {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return int(end.Sub(start).Milliseconds())
+}
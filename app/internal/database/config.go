@@ -2,24 +2,38 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
+	"log"
+	"status/app/internal/crypto"
 	"status/app/internal/models"
 )
 
 // LoadAlertConfig loads email alert configuration from database
 func LoadAlertConfig() (*models.AlertConfig, error) {
 	var config models.AlertConfig
+	var quietHours string
 	err := DB.QueryRow(`SELECT enabled, smtp_host, smtp_port, smtp_user, smtp_password, alert_email, from_email,
 		COALESCE(status_page_url, ''), COALESCE(smtp_skip_verify, 0), alert_on_down, alert_on_degraded, alert_on_up,
 		COALESCE(discord_webhook_url, ''), COALESCE(discord_enabled, 0),
 		COALESCE(telegram_bot_token, ''), COALESCE(telegram_chat_id, ''), COALESCE(telegram_enabled, 0),
-		COALESCE(webhook_url, ''), COALESCE(webhook_secret, ''), COALESCE(webhook_enabled, 0)
+		COALESCE(webhook_url, ''), COALESCE(webhook_secret, ''), COALESCE(webhook_enabled, 0),
+		COALESCE(repeat_interval_minutes, 0), COALESCE(escalate_after_minutes, 0), COALESCE(escalation_channels, ''),
+		COALESCE(group_window_seconds, 0), COALESCE(quiet_hours, ''),
+		COALESCE(flap_threshold, 0), COALESCE(flap_window_minutes, 0), COALESCE(flap_stable_minutes, 0),
+		COALESCE(latency_sigma, 0), COALESCE(latency_sustain_minutes, 0),
+		COALESCE(inbound_token, ''), COALESCE(inbound_labels, ''), COALESCE(inbound_banners, 0)
 		FROM alert_config WHERE id = 1`).Scan(
 		&config.Enabled, &config.SMTPHost, &config.SMTPPort, &config.SMTPUser,
 		&config.SMTPPassword, &config.AlertEmail, &config.FromEmail, &config.StatusPageURL, &config.SMTPSkipVerify,
 		&config.AlertOnDown, &config.AlertOnDegraded, &config.AlertOnUp,
 		&config.DiscordWebhookURL, &config.DiscordEnabled,
 		&config.TelegramBotToken, &config.TelegramChatID, &config.TelegramEnabled,
-		&config.WebhookURL, &config.WebhookSecret, &config.WebhookEnabled)
+		&config.WebhookURL, &config.WebhookSecret, &config.WebhookEnabled,
+		&config.RepeatIntervalMinutes, &config.EscalateAfterMinutes, &config.EscalationChannels,
+		&config.GroupWindowSeconds, &quietHours,
+		&config.FlapThreshold, &config.FlapWindowMinutes, &config.FlapStableMinutes,
+		&config.LatencySigma, &config.LatencySustainMinutes,
+		&config.InboundToken, &config.InboundLabels, &config.InboundBanners)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -27,35 +41,94 @@ func LoadAlertConfig() (*models.AlertConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	config.QuietHours = decodeQuietHours(quietHours)
+	if config.InboundToken != "" {
+		plain, err := crypto.Decrypt(config.InboundToken)
+		if err != nil {
+			log.Printf("Warning: failed to decrypt inbound webhook token: %v", err)
+			// Keep the raw value — may be legacy plaintext
+		} else {
+			config.InboundToken = plain
+		}
+	}
 	return &config, nil
 }
 
+// decodeQuietHours parses a stored quiet-hours schedule; empty or invalid JSON is a disabled schedule
+func decodeQuietHours(raw string) models.QuietHours {
+	var q models.QuietHours
+	if raw == "" {
+		return q
+	}
+	if err := json.Unmarshal([]byte(raw), &q); err != nil {
+		log.Printf("Warning: invalid quiet hours schedule: %v", err)
+		return models.QuietHours{}
+	}
+	return q
+}
+
+// encodeQuietHours serialises a quiet-hours schedule for storage
+func encodeQuietHours(q models.QuietHours) string {
+	if !q.Enabled && q.Start == "" && q.End == "" && len(q.Actions) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(q)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 // SaveAlertConfig saves email alert configuration to database
 func SaveAlertConfig(config *models.AlertConfig) error {
+	inboundToken := config.InboundToken
+	if inboundToken != "" {
+		enc, err := crypto.Encrypt(inboundToken)
+		if err != nil {
+			log.Printf("Warning: failed to encrypt inbound webhook token: %v", err) // fallback to plaintext
+		} else {
+			inboundToken = enc
+		}
+	}
+
 	_, err := DB.Exec(`INSERT INTO alert_config (id, enabled, smtp_host, smtp_port, smtp_user, smtp_password, alert_email, from_email, status_page_url, smtp_skip_verify,
 		alert_on_down, alert_on_degraded, alert_on_up,
 		discord_webhook_url, discord_enabled,
 		telegram_bot_token, telegram_chat_id, telegram_enabled,
-		webhook_url, webhook_secret, webhook_enabled, updated_at)
-		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		webhook_url, webhook_secret, webhook_enabled,
+		repeat_interval_minutes, escalate_after_minutes, escalation_channels, group_window_seconds, quiet_hours,
+		flap_threshold, flap_window_minutes, flap_stable_minutes, latency_sigma, latency_sustain_minutes,
+		inbound_token, inbound_labels, inbound_banners, updated_at)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
 		ON CONFLICT(id) DO UPDATE SET 
 			enabled=?, smtp_host=?, smtp_port=?, smtp_user=?, smtp_password=?, alert_email=?, from_email=?, status_page_url=?, smtp_skip_verify=?,
 			alert_on_down=?, alert_on_degraded=?, alert_on_up=?,
 			discord_webhook_url=?, discord_enabled=?,
 			telegram_bot_token=?, telegram_chat_id=?, telegram_enabled=?,
-			webhook_url=?, webhook_secret=?, webhook_enabled=?, updated_at=datetime('now')`,
+			webhook_url=?, webhook_secret=?, webhook_enabled=?,
+			repeat_interval_minutes=?, escalate_after_minutes=?, escalation_channels=?, group_window_seconds=?, quiet_hours=?,
+			flap_threshold=?, flap_window_minutes=?, flap_stable_minutes=?, latency_sigma=?, latency_sustain_minutes=?,
+			inbound_token=?, inbound_labels=?, inbound_banners=?, updated_at=datetime('now')`,
 		config.Enabled, config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPassword,
 		config.AlertEmail, config.FromEmail, config.StatusPageURL, config.SMTPSkipVerify,
 		config.AlertOnDown, config.AlertOnDegraded, config.AlertOnUp,
 		config.DiscordWebhookURL, config.DiscordEnabled,
 		config.TelegramBotToken, config.TelegramChatID, config.TelegramEnabled,
 		config.WebhookURL, config.WebhookSecret, config.WebhookEnabled,
+		config.RepeatIntervalMinutes, config.EscalateAfterMinutes, config.EscalationChannels, config.GroupWindowSeconds, encodeQuietHours(config.QuietHours),
+		config.FlapThreshold, config.FlapWindowMinutes, config.FlapStableMinutes,
+		config.LatencySigma, config.LatencySustainMinutes,
+		inboundToken, config.InboundLabels, config.InboundBanners,
 		config.Enabled, config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPassword,
 		config.AlertEmail, config.FromEmail, config.StatusPageURL, config.SMTPSkipVerify,
 		config.AlertOnDown, config.AlertOnDegraded, config.AlertOnUp,
 		config.DiscordWebhookURL, config.DiscordEnabled,
 		config.TelegramBotToken, config.TelegramChatID, config.TelegramEnabled,
-		config.WebhookURL, config.WebhookSecret, config.WebhookEnabled)
+		config.WebhookURL, config.WebhookSecret, config.WebhookEnabled,
+		config.RepeatIntervalMinutes, config.EscalateAfterMinutes, config.EscalationChannels, config.GroupWindowSeconds, encodeQuietHours(config.QuietHours),
+		config.FlapThreshold, config.FlapWindowMinutes, config.FlapStableMinutes,
+		config.LatencySigma, config.LatencySustainMinutes,
+		inboundToken, config.InboundLabels, config.InboundBanners)
 	return err
 }
 
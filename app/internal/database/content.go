@@ -0,0 +1,53 @@
+package database
+
+import (
+	"database/sql"
+	"status/app/internal/models"
+	"time"
+)
+
+// ContentHistoryLimit is the number of content hash changes kept per service.
+const ContentHistoryLimit = 20
+
+// GetLatestContentHash returns the most recently recorded body hash for a service,
+// or an empty string if none has been recorded yet.
+func GetLatestContentHash(serviceKey string) (string, error) {
+	var hash string
+	err := DB.QueryRow(`SELECT hash FROM content_hashes WHERE service_key = ? ORDER BY id DESC LIMIT 1`, serviceKey).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// RecordContentHash appends a new body hash for a service and trims its history.
+func RecordContentHash(serviceKey, hash string) error {
+	_, err := DB.Exec(`INSERT INTO content_hashes (service_key, hash, seen_at) VALUES (?, ?, ?)`,
+		serviceKey, hash, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`DELETE FROM content_hashes WHERE service_key = ? AND id NOT IN (
+		SELECT id FROM content_hashes WHERE service_key = ? ORDER BY id DESC LIMIT ?
+	)`, serviceKey, serviceKey, ContentHistoryLimit)
+	return err
+}
+
+// GetContentHistory returns the recorded body hashes for a service, newest first.
+func GetContentHistory(serviceKey string) ([]models.ContentChange, error) {
+	rows, err := DB.Query(`SELECT hash, seen_at FROM content_hashes WHERE service_key = ? ORDER BY id DESC`, serviceKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.ContentChange{}
+	for rows.Next() {
+		var c models.ContentChange
+		if err := rows.Scan(&c.Hash, &c.SeenAt); err != nil {
+			return nil, err
+		}
+		history = append(history, c)
+	}
+	return history, nil
+}
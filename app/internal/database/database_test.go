@@ -1,7 +1,12 @@
 package database
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"status/app/internal/crypto"
 	"status/app/internal/models"
+	"strings"
 	"testing"
 	"time"
 )
@@ -544,6 +549,28 @@ func TestSaveAlertConfig_Upsert(t *testing.T) {
 	}
 }
 
+func TestSaveAlertConfig_EncryptsInboundToken(t *testing.T) {
+	initTestDB(t)
+	crypto.SetKey([]byte("test-secret-key-at-least-32-bytes!!"))
+	const token = "0123456789abcdef0123"
+	SaveAlertConfig(&models.AlertConfig{Enabled: true, InboundToken: token})
+
+	var stored string
+	DB.QueryRow(`SELECT inbound_token FROM alert_config WHERE id = 1`).Scan(&stored)
+	if stored == "" || stored == token {
+		t.Errorf("inbound token should be stored encrypted, got %q", stored)
+	}
+	if loaded, _ := LoadAlertConfig(); loaded.InboundToken != token {
+		t.Errorf("loaded token = %q, want the decrypted token", loaded.InboundToken)
+	}
+
+	// Tokens saved in plaintext before encryption are still accepted
+	DB.Exec(`UPDATE alert_config SET inbound_token = ? WHERE id = 1`, token)
+	if loaded, _ := LoadAlertConfig(); loaded.InboundToken != token {
+		t.Errorf("legacy plaintext token = %q", loaded.InboundToken)
+	}
+}
+
 // --------------- ResourcesUIConfig ---------------
 
 func TestLoadResourcesUIConfig_NoRow(t *testing.T) {
@@ -755,3 +782,567 @@ func TestPruneLogs(t *testing.T) {
 		t.Errorf("expected 5 logs after prune, got %d", count)
 	}
 }
+
+// --------------- Content hashes ---------------
+
+func TestGetLatestContentHash_Empty(t *testing.T) {
+	initTestDB(t)
+	hash, err := GetLatestContentHash("none")
+	if err != nil || hash != "" {
+		t.Errorf("expected empty hash, got %q (err=%v)", hash, err)
+	}
+}
+
+func TestRecordContentHash_TrimsHistory(t *testing.T) {
+	initTestDB(t)
+	for i := 0; i < ContentHistoryLimit+5; i++ {
+		if err := RecordContentHash("svc", fmt.Sprintf("h%d", i)); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	latest, _ := GetLatestContentHash("svc")
+	if want := fmt.Sprintf("h%d", ContentHistoryLimit+4); latest != want {
+		t.Errorf("latest = %q, want %q", latest, want)
+	}
+	history, _ := GetContentHistory("svc")
+	if len(history) != ContentHistoryLimit {
+		t.Errorf("expected %d entries, got %d", ContentHistoryLimit, len(history))
+	}
+}
+
+func TestServiceContentCheck_RoundTrip(t *testing.T) {
+	initTestDB(t)
+	svc := sampleService("svc-content")
+	svc.ContentCheck = true
+	svc.ContentIgnore = `\d{4}-\d{2}-\d{2}`
+	id, err := CreateService(svc)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	got, _ := GetServiceByID(int(id))
+	if !got.ContentCheck || got.ContentIgnore != svc.ContentIgnore {
+		t.Errorf("content settings not persisted: %+v", got)
+	}
+}
+
+// --------------- Service metrics ---------------
+
+func TestSaveServiceMetrics_Upsert(t *testing.T) {
+	initTestDB(t)
+	if err := SaveServiceMetrics("mc", map[string]float64{"players": 3, "max_players": 20}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := SaveServiceMetrics("mc", map[string]float64{"players": 5}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	all, err := GetAllServiceMetrics()
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if all["mc"]["players"] != 5 || all["mc"]["max_players"] != 20 {
+		t.Errorf("unexpected metrics %v", all["mc"])
+	}
+}
+
+func TestServiceEntityChecks_RoundTrip(t *testing.T) {
+	initTestDB(t)
+	svc := sampleService("svc-ha")
+	svc.ServiceType = "homeassistant"
+	svc.EntityChecks = []models.EntityCheck{
+		{EntityID: "sensor.ups", Attribute: "battery_level", Operator: "<", Value: "30", Severity: "degraded"},
+	}
+	id, err := CreateService(svc)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	got, _ := GetServiceByID(int(id))
+	if len(got.EntityChecks) != 1 || got.EntityChecks[0] != svc.EntityChecks[0] {
+		t.Errorf("entity checks not persisted: %+v", got.EntityChecks)
+	}
+
+	got.EntityChecks = nil
+	if err := UpdateService(got); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got, _ = GetServiceByID(int(id))
+	if len(got.EntityChecks) != 0 {
+		t.Errorf("entity checks should be cleared, got %+v", got.EntityChecks)
+	}
+}
+
+// --------------- Notification channels ---------------
+
+func TestNotificationChannels_CRUD(t *testing.T) {
+	initTestDB(t)
+	ch := &models.NotificationChannel{
+		Type:     models.ChannelDiscord,
+		Name:     "Ops Discord",
+		Settings: map[string]string{"webhook_url": "https://discord.test/hook"},
+		Enabled:  true,
+	}
+	id, err := CreateNotificationChannel(ch)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	got, err := GetNotificationChannel(int(id))
+	if err != nil || got == nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Name != "Ops Discord" || !got.Enabled || got.Settings["webhook_url"] != "https://discord.test/hook" {
+		t.Errorf("unexpected channel %+v", got)
+	}
+
+	got.Enabled = false
+	got.Settings["webhook_url"] = "https://discord.test/other"
+	if err := UpdateNotificationChannel(got); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	all, _ := GetNotificationChannels()
+	if len(all) != 1 || all[0].Enabled || all[0].Settings["webhook_url"] != "https://discord.test/other" {
+		t.Errorf("update not persisted: %+v", all)
+	}
+
+	if err := DeleteNotificationChannel(int(id)); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, _ := GetNotificationChannel(int(id)); got != nil {
+		t.Error("channel should be deleted")
+	}
+}
+
+func TestNotificationChannels_QuietHoursRoundTrip(t *testing.T) {
+	initTestDB(t)
+	q := models.QuietHours{Enabled: true, Timezone: "UTC", Days: "sat,sun", Start: "22:00", End: "07:00",
+		Actions: map[string]string{"up": "suppress"}}
+	id, err := CreateNotificationChannel(&models.NotificationChannel{Type: "webhook", Name: "Hook",
+		Settings: map[string]string{"url": "https://x"}, Enabled: true, QuietHours: q})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	ch, _ := GetNotificationChannel(int(id))
+	if !reflect.DeepEqual(ch.QuietHours, q) {
+		t.Errorf("quiet hours = %+v, want %+v", ch.QuietHours, q)
+	}
+
+	if err := SaveAlertConfig(&models.AlertConfig{Enabled: true, QuietHours: q}); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	cfg, _ := LoadAlertConfig()
+	if !reflect.DeepEqual(cfg.QuietHours, q) {
+		t.Errorf("global quiet hours = %+v, want %+v", cfg.QuietHours, q)
+	}
+}
+
+func TestMigrateAlertConfigChannels(t *testing.T) {
+	initTestDB(t)
+	cfg := &models.AlertConfig{
+		Enabled:           true,
+		SMTPHost:          "smtp.test",
+		SMTPPort:          465,
+		AlertEmail:        "a@test, b@test",
+		DiscordWebhookURL: "https://discord.test/hook",
+		DiscordEnabled:    true,
+		TelegramBotToken:  "tok",
+		TelegramChatID:    "42",
+		TelegramEnabled:   false,
+	}
+	if err := SaveAlertConfig(cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	n, err := MigrateAlertConfigChannels()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("migrated %d channels, want 3", n)
+	}
+
+	byType := map[string]models.NotificationChannel{}
+	all, _ := GetNotificationChannels()
+	for _, ch := range all {
+		byType[ch.Type] = ch
+	}
+	if byType["email"].Settings["smtp_port"] != "465" || byType["email"].Settings["to"] != "a@test, b@test" {
+		t.Errorf("email settings = %v", byType["email"].Settings)
+	}
+	if !byType["discord"].Enabled {
+		t.Error("discord channel should be enabled")
+	}
+	if byType["telegram"].Enabled {
+		t.Error("telegram channel should keep its disabled state")
+	}
+
+	// Second run is a no-op
+	if n, _ := MigrateAlertConfigChannels(); n != 0 {
+		t.Errorf("second migration created %d channels", n)
+	}
+}
+
+func TestMigrateAlertConfigChannels_NoConfig(t *testing.T) {
+	initTestDB(t)
+	n, err := MigrateAlertConfigChannels()
+	if err != nil || n != 0 {
+		t.Errorf("got n=%d err=%v, want 0, nil", n, err)
+	}
+}
+
+func TestDeleteNotificationChannel_RemovesServiceRouting(t *testing.T) {
+	initTestDB(t)
+	id, _ := CreateNotificationChannel(&models.NotificationChannel{Type: "discord", Name: "D",
+		Settings: map[string]string{"webhook_url": "https://x"}, Enabled: true})
+
+	svc := sampleService("svc-routed")
+	svc.NotifyChannels = fmt.Sprintf("%d,42", id)
+	svc.NotifyEvents = "down,up"
+	svcID, err := CreateService(svc)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	got, _ := GetServiceByID(int(svcID))
+	if got.NotifyChannels != svc.NotifyChannels || got.NotifyEvents != "down,up" {
+		t.Fatalf("routing not persisted: %q %q", got.NotifyChannels, got.NotifyEvents)
+	}
+
+	if err := DeleteNotificationChannel(int(id)); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	got, _ = GetServiceByID(int(svcID))
+	if got.NotifyChannels != "42" {
+		t.Errorf("notify_channels = %q, want deleted channel removed", got.NotifyChannels)
+	}
+}
+
+func TestDeleteNotificationChannel_RefusesLastRoute(t *testing.T) {
+	initTestDB(t)
+	id, _ := CreateNotificationChannel(&models.NotificationChannel{Type: "telegram", Name: "Family",
+		Settings: map[string]string{"bot_token": "t", "chat_id": "1"}, Enabled: true})
+	other, _ := CreateNotificationChannel(&models.NotificationChannel{Type: "discord", Name: "D",
+		Settings: map[string]string{"webhook_url": "https://x"}, Enabled: true})
+
+	only := sampleService("svc-only")
+	only.Name = "Photos"
+	only.NotifyChannels = fmt.Sprintf("%d", id)
+	onlyID, _ := CreateService(only)
+	shared := sampleService("svc-shared")
+	shared.NotifyChannels = fmt.Sprintf("%d,%d", id, other)
+	sharedID, _ := CreateService(shared)
+
+	err := DeleteNotificationChannel(int(id))
+	if !errors.Is(err, ErrChannelInUse) || !strings.Contains(err.Error(), "Photos") {
+		t.Fatalf("err = %v, want ErrChannelInUse naming the service", err)
+	}
+	// Nothing changes when the delete is refused
+	if ch, _ := GetNotificationChannel(int(id)); ch == nil {
+		t.Error("channel should not be deleted")
+	}
+	if got, _ := GetServiceByID(int(sharedID)); got.NotifyChannels != shared.NotifyChannels {
+		t.Errorf("shared routing rewritten to %q despite the refusal", got.NotifyChannels)
+	}
+
+	// Once the service routes elsewhere the channel can go
+	only.ID = int(onlyID)
+	only.NotifyChannels = fmt.Sprintf("%d", other)
+	if err := UpdateService(only); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := DeleteNotificationChannel(int(id)); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, _ := GetServiceByID(int(sharedID)); got.NotifyChannels != fmt.Sprintf("%d", other) {
+		t.Errorf("notify_channels = %q", got.NotifyChannels)
+	}
+}
+
+// --------------- Notification outbox ---------------
+
+func TestNotificationOutbox_ClaimOnce(t *testing.T) {
+	initTestDB(t)
+	id, err := EnqueueNotification(&models.NotificationDelivery{ChannelID: 1, ChannelType: "discord",
+		ChannelName: "D", Subject: "Down", Payload: "{}"})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	due, _ := GetDueNotificationIDs(10)
+	if len(due) != 1 || due[0] != id {
+		t.Fatalf("due = %v, want [%d]", due, id)
+	}
+
+	if ok, err := ClaimNotification(id, time.Minute); !ok || err != nil {
+		t.Fatalf("first claim: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := ClaimNotification(id, time.Minute); ok {
+		t.Error("claimed delivery should not be claimable again during its lease")
+	}
+	if due, _ := GetDueNotificationIDs(10); len(due) != 0 {
+		t.Errorf("claimed delivery should not be due, got %v", due)
+	}
+
+	d, _ := GetNotificationDelivery(id)
+	if d.Attempts != 1 || d.Status != models.DeliveryPending {
+		t.Errorf("unexpected delivery %+v", d)
+	}
+}
+
+func TestNotificationOutbox_ListAndPrune(t *testing.T) {
+	initTestDB(t)
+	for i := 0; i < 3; i++ {
+		id, _ := EnqueueNotification(&models.NotificationDelivery{ChannelID: 1, ChannelType: "discord",
+			ChannelName: "D", Subject: fmt.Sprintf("alert %d", i), Payload: "{}"})
+		if i == 0 {
+			MarkNotificationDelivered(id)
+		}
+		if i == 1 {
+			MarkNotificationDead(id, "boom")
+		}
+	}
+
+	all, _ := GetNotificationDeliveries("", 10)
+	if len(all) != 3 || all[0].Subject != "alert 2" {
+		t.Fatalf("expected newest first, got %+v", all)
+	}
+	dead, _ := GetNotificationDeliveries(models.DeliveryDead, 10)
+	if len(dead) != 1 || dead[0].LastError != "boom" {
+		t.Errorf("dead = %+v", dead)
+	}
+
+	DB.Exec(`UPDATE notification_outbox SET created_at = '2000-01-01T00:00:00Z'`)
+	n, err := PruneNotificationDeliveries(24 * time.Hour)
+	if err != nil || n != 2 {
+		t.Errorf("pruned %d (err %v), want 2 finished deliveries", n, err)
+	}
+	if pending, _ := GetNotificationDeliveries(models.DeliveryPending, 10); len(pending) != 1 {
+		t.Error("pending deliveries must not be pruned")
+	}
+}
+
+func TestCancelDeferredNotifications(t *testing.T) {
+	initTestDB(t)
+	later := time.Now().Add(time.Hour)
+	schedule := func(channelID int, key, event string, due time.Time) int64 {
+		id, _ := ScheduleNotification(&models.NotificationDelivery{ChannelID: channelID, ChannelType: "discord",
+			ChannelName: "D", ServiceKey: key, Subject: event, Payload: `{"event":"` + event + `"}`}, due)
+		return id
+	}
+	schedule(1, "nas", "down", later)
+	schedule(2, "nas", "down", later)
+	schedule(1, "nas", "up", later)
+	schedule(1, "plex", "down", later)
+	dueNow := schedule(1, "nas", "down", time.Now().Add(-time.Minute))
+
+	n, err := CancelDeferredNotifications(1, "nas", "down", "repeat")
+	if err != nil || n != 1 {
+		t.Fatalf("cancelled %d (err %v), want the one deferred down on channel 1", n, err)
+	}
+	if n, _ := CancelDeferredNotifications(0, "nas", "down"); n != 1 {
+		t.Errorf("channel 0 should match every channel, cancelled %d", n)
+	}
+	if d, _ := GetNotificationDelivery(dueNow); d == nil {
+		t.Error("a delivery that is already due must not be cancelled")
+	}
+	if all, _ := GetNotificationDeliveries("", 10); len(all) != 3 {
+		t.Errorf("expected up, plex and the due delivery to remain, got %d", len(all))
+	}
+}
+
+// --------------- Incident tests ---------------
+
+func TestIncidents_OpenResolve(t *testing.T) {
+	initTestDB(t)
+
+	id, err := OpenIncident("nas", "NAS", "down")
+	if err != nil {
+		t.Fatalf("OpenIncident: %v", err)
+	}
+	open, err := GetOpenIncident("nas")
+	if err != nil || open == nil || open.ID != id {
+		t.Fatalf("GetOpenIncident = %+v, %v", open, err)
+	}
+
+	DB.Exec(`UPDATE incident_events SET started_at = ? WHERE id = ?`, sortableTime(time.Now().Add(-90*time.Second)), id)
+	if err := ResolveIncident("nas"); err != nil {
+		t.Fatalf("ResolveIncident: %v", err)
+	}
+	if open, _ := GetOpenIncident("nas"); open != nil {
+		t.Error("incident should no longer be open")
+	}
+	inc, _ := GetIncident(id)
+	if inc.ResolvedAt == "" || inc.DurationS < 89 || inc.DurationS > 91 {
+		t.Errorf("resolved incident = %+v, want ~90s duration", inc)
+	}
+
+	// Resolving with nothing open is a no-op
+	if err := ResolveIncident("nas"); err != nil {
+		t.Errorf("ResolveIncident without open incident: %v", err)
+	}
+}
+
+func TestIncidents_Acknowledge(t *testing.T) {
+	initTestDB(t)
+	id, _ := OpenIncident("nas", "NAS", "down")
+
+	inc, err := AcknowledgeIncident(id, "alice")
+	if err != nil || inc == nil {
+		t.Fatalf("AcknowledgeIncident = %v, %v", inc, err)
+	}
+	if inc.AcknowledgedBy != "alice" || inc.AcknowledgedAt == "" {
+		t.Errorf("ack not recorded: %+v", inc)
+	}
+
+	inc, _ = AcknowledgeIncident(id, "bob")
+	if inc.AcknowledgedBy != "alice" {
+		t.Errorf("second ack should keep the first, got %q", inc.AcknowledgedBy)
+	}
+
+	if inc, err := AcknowledgeIncident(999, "alice"); inc != nil || err != nil {
+		t.Errorf("missing incident = %v, %v; want nil, nil", inc, err)
+	}
+
+	other, _ := OpenIncident("db", "DB", "down")
+	_ = ResolveIncident("db")
+	if _, err := AcknowledgeIncident(other, "alice"); err != ErrIncidentResolved {
+		t.Errorf("acknowledging a resolved incident: err = %v, want ErrIncidentResolved", err)
+	}
+
+	list, _ := GetIncidents(10)
+	if len(list) != 2 || list[0].ID != other {
+		t.Errorf("GetIncidents should list newest first, got %+v", list)
+	}
+}
+
+func TestNotificationTemplates_CRUD(t *testing.T) {
+	initTestDB(t)
+
+	if tmpl, err := GetNotificationTemplate("down", ""); tmpl != nil || err != nil {
+		t.Fatalf("missing template = %v, %v; want nil, nil", tmpl, err)
+	}
+
+	if err := SaveNotificationTemplate(&models.NotificationTemplate{Event: "down", Subject: "A", Body: "a"}); err != nil {
+		t.Fatalf("SaveNotificationTemplate: %v", err)
+	}
+	SaveNotificationTemplate(&models.NotificationTemplate{Event: "down", ChannelType: "email", Subject: "E"})
+	SaveNotificationTemplate(&models.NotificationTemplate{Event: "down", Subject: "B", Body: "b"})
+
+	tmpl, _ := GetNotificationTemplate("down", "")
+	if tmpl == nil || tmpl.Subject != "B" || tmpl.Body != "b" || tmpl.UpdatedAt == "" {
+		t.Errorf("saving again should replace the override, got %+v", tmpl)
+	}
+	if list, _ := GetNotificationTemplates(); len(list) != 2 {
+		t.Errorf("expected 2 overrides, got %d", len(list))
+	}
+
+	if err := DeleteNotificationTemplate("down", "email"); err != nil {
+		t.Fatalf("DeleteNotificationTemplate: %v", err)
+	}
+	if tmpl, _ := GetNotificationTemplate("down", "email"); tmpl != nil {
+		t.Error("deleted override should be gone")
+	}
+	if tmpl, _ := GetNotificationTemplate("down", ""); tmpl == nil {
+		t.Error("deleting one channel type should keep the others")
+	}
+}
+
+func TestNotificationThreads(t *testing.T) {
+	initTestDB(t)
+
+	if id, err := GetNotificationThread("matrix:!r", "nas"); id != "" || err != nil {
+		t.Fatalf("missing thread = %q, %v", id, err)
+	}
+	SaveNotificationThread("matrix:!r", "nas", "$a")
+	SaveNotificationThread("matrix:!r", "nas", "$b")
+	SaveNotificationThread("matrix:!other", "nas", "$c")
+	if id, _ := GetNotificationThread("matrix:!r", "nas"); id != "$b" {
+		t.Errorf("thread = %q, want the latest message", id)
+	}
+
+	DeleteNotificationThread("matrix:!r", "nas")
+	if id, _ := GetNotificationThread("matrix:!r", "nas"); id != "" {
+		t.Error("deleted thread should be gone")
+	}
+	if id, _ := GetNotificationThread("matrix:!other", "nas"); id != "$c" {
+		t.Error("threads are kept per scope")
+	}
+}
+
+// --------------- Inbound alerts ---------------
+
+func TestInboundAlerts_FiringResolvedAndRefiring(t *testing.T) {
+	initTestDB(t)
+
+	a := models.InboundAlert{Source: "alertmanager", Fingerprint: "abc", ServiceKey: "plex", Name: "PlexDown",
+		Severity: "critical", Status: "firing"}
+	if err := SaveInboundAlert(&a); err != nil {
+		t.Fatalf("SaveInboundAlert: %v", err)
+	}
+	DB.Exec(`UPDATE inbound_alerts SET started_at = '2020-01-01T00:00:00Z'`)
+
+	// Firing again keeps the original start
+	if err := SaveInboundAlert(&a); err != nil {
+		t.Fatalf("SaveInboundAlert: %v", err)
+	}
+	got, _ := GetInboundAlert("alertmanager", "abc")
+	if got == nil || got.StartedAt != "2020-01-01T00:00:00Z" {
+		t.Fatalf("expected the start time to be kept, got %+v", got)
+	}
+	if firing, _ := GetFiringInboundAlerts("plex"); len(firing) != 1 {
+		t.Errorf("expected one firing alert, got %d", len(firing))
+	}
+
+	a.Status = "resolved"
+	SaveInboundAlert(&a)
+	got, _ = GetInboundAlert("alertmanager", "abc")
+	if got.ResolvedAt == "" {
+		t.Error("expected a resolved time")
+	}
+	if firing, _ := GetFiringInboundAlerts("plex"); len(firing) != 0 {
+		t.Errorf("expected no firing alerts, got %d", len(firing))
+	}
+
+	// Firing after being resolved starts anew
+	a.Status = "firing"
+	SaveInboundAlert(&a)
+	got, _ = GetInboundAlert("alertmanager", "abc")
+	if got.StartedAt == "2020-01-01T00:00:00Z" || got.ResolvedAt != "" {
+		t.Errorf("expected a new firing period, got %+v", got)
+	}
+	if missing, _ := GetInboundAlert("grafana", "abc"); missing != nil {
+		t.Error("alerts are keyed by source and fingerprint")
+	}
+}
+
+func TestMaintenanceWindows_ActiveAndEnded(t *testing.T) {
+	initTestDB(t)
+
+	if w, err := GetActiveMaintenance("nas"); err != nil || w != nil {
+		t.Fatalf("expected no window, got %v, %v", w, err)
+	}
+	if _, err := CreateMaintenanceWindow("nas", "Paused", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	CreateMaintenanceWindow("plex", "Expired", time.Now().Add(-time.Minute))
+	if w, _ := GetActiveMaintenance("nas"); w == nil || w.Title != "Paused" {
+		t.Errorf("expected the active window, got %+v", w)
+	}
+	if w, _ := GetActiveMaintenance("plex"); w != nil {
+		t.Errorf("an ended window should not be active, got %+v", w)
+	}
+
+	// A window without a service covers every service
+	CreateMaintenanceWindow("", "Everything", time.Now().Add(time.Hour))
+	if w, _ := GetActiveMaintenance("plex"); w == nil || w.Title != "Everything" {
+		t.Errorf("expected the global window, got %+v", w)
+	}
+
+	if n, err := EndMaintenance("nas"); err != nil || n != 1 {
+		t.Fatalf("EndMaintenance = %d, %v", n, err)
+	}
+	if w, _ := GetActiveMaintenance("nas"); w == nil || w.Title != "Everything" {
+		t.Errorf("ending a service's pause should leave global windows, got %+v", w)
+	}
+}
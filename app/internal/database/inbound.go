@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"status/app/internal/models"
+	"time"
+)
+
+const inboundAlertColumns = `id, source, fingerprint, service_key, name, severity, status, summary, status_alert_id,
+	started_at, updated_at, COALESCE(resolved_at, '')`
+
+func scanInboundAlert(row rowScanner) (*models.InboundAlert, error) {
+	var a models.InboundAlert
+	err := row.Scan(&a.ID, &a.Source, &a.Fingerprint, &a.ServiceKey, &a.Name, &a.Severity, &a.Status, &a.Summary,
+		&a.StatusAlertID, &a.StartedAt, &a.UpdatedAt, &a.ResolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func queryInboundAlerts(query string, args ...any) ([]models.InboundAlert, error) {
+	rows, err := DB.Query(`SELECT `+inboundAlertColumns+` FROM inbound_alerts `+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.InboundAlert
+	for rows.Next() {
+		a, err := scanInboundAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *a)
+	}
+	return list, rows.Err()
+}
+
+// GetInboundAlert returns an inbound alert by source and fingerprint, or nil if it has not been seen
+func GetInboundAlert(source, fingerprint string) (*models.InboundAlert, error) {
+	a, err := scanInboundAlert(DB.QueryRow(`SELECT `+inboundAlertColumns+` FROM inbound_alerts
+		WHERE source = ? AND fingerprint = ?`, source, fingerprint))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return a, err
+}
+
+// GetFiringInboundAlerts returns a service's firing inbound alerts, oldest first
+func GetFiringInboundAlerts(serviceKey string) ([]models.InboundAlert, error) {
+	return queryInboundAlerts(`WHERE service_key = ? AND status = 'firing' ORDER BY started_at, id`, serviceKey)
+}
+
+// GetInboundAlerts returns the most recently updated inbound alerts, firing or resolved
+func GetInboundAlerts(limit int) ([]models.InboundAlert, error) {
+	return queryInboundAlerts(`ORDER BY updated_at DESC, id DESC LIMIT ?`, limit)
+}
+
+// SaveInboundAlert records an alert as firing or resolved. A firing alert keeps the start
+// time of its first notification; firing again after being resolved starts it anew.
+func SaveInboundAlert(a *models.InboundAlert) error {
+	now := sortableTime(time.Now())
+	var resolvedAt any
+	if a.Status == "resolved" {
+		resolvedAt = now
+	}
+	_, err := DB.Exec(`INSERT INTO inbound_alerts (source, fingerprint, service_key, name, severity, status, summary,
+			status_alert_id, started_at, updated_at, resolved_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(source, fingerprint) DO UPDATE SET service_key=excluded.service_key, name=excluded.name,
+			severity=excluded.severity, summary=excluded.summary, status_alert_id=excluded.status_alert_id,
+			started_at = CASE WHEN inbound_alerts.status = 'firing' THEN inbound_alerts.started_at ELSE excluded.started_at END,
+			status=excluded.status, updated_at=excluded.updated_at, resolved_at=excluded.resolved_at`,
+		a.Source, a.Fingerprint, a.ServiceKey, a.Name, a.Severity, a.Status, a.Summary,
+		a.StatusAlertID, now, now, resolvedAt)
+	return err
+}
+
+// CreateStatusBanner posts a status banner and returns its ID
+func CreateStatusBanner(serviceKey, message, level string) (string, error) {
+	id := fmt.Sprintf("alert_%d", time.Now().UnixNano())
+	var key any
+	if serviceKey != "" {
+		key = serviceKey
+	}
+	_, err := DB.Exec(`INSERT INTO status_alerts (id, service_key, message, level, created_at) VALUES (?, ?, ?, ?, ?)`,
+		id, key, message, level, time.Now().UTC().Format(time.RFC3339))
+	return id, err
+}
+
+// DeleteStatusBanner removes a status banner
+func DeleteStatusBanner(id string) error {
+	_, err := DB.Exec(`DELETE FROM status_alerts WHERE id = ?`, id)
+	return err
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"status/app/internal/models"
+	"time"
+)
+
+// ErrIncidentResolved is returned when acknowledging an incident that has already recovered
+var ErrIncidentResolved = errors.New("incident already resolved")
+
+const incidentColumns = `id, service_key, service_name, event_type, started_at, COALESCE(resolved_at, ''),
+	COALESCE(duration_s, 0), COALESCE(acknowledged_at, ''), COALESCE(acknowledged_by, '')`
+
+func scanIncident(row rowScanner) (*models.Incident, error) {
+	var inc models.Incident
+	err := row.Scan(&inc.ID, &inc.ServiceKey, &inc.ServiceName, &inc.EventType, &inc.StartedAt, &inc.ResolvedAt,
+		&inc.DurationS, &inc.AcknowledgedAt, &inc.AcknowledgedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &inc, nil
+}
+
+// OpenIncident starts a new incident for a service
+func OpenIncident(serviceKey, serviceName, eventType string) (int64, error) {
+	result, err := DB.Exec(`INSERT INTO incident_events (service_key, service_name, event_type, started_at) VALUES (?, ?, ?, ?)`,
+		serviceKey, serviceName, eventType, sortableTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetOpenIncident returns a service's ongoing incident, or nil if there is none
+func GetOpenIncident(serviceKey string) (*models.Incident, error) {
+	inc, err := scanIncident(DB.QueryRow(`SELECT `+incidentColumns+` FROM incident_events
+		WHERE service_key = ? AND resolved_at IS NULL ORDER BY id DESC LIMIT 1`, serviceKey))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return inc, err
+}
+
+// GetIncident returns one incident by ID, or nil if it does not exist
+func GetIncident(id int64) (*models.Incident, error) {
+	inc, err := scanIncident(DB.QueryRow(`SELECT `+incidentColumns+` FROM incident_events WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return inc, err
+}
+
+// GetIncidents returns the most recent incidents, newest first
+func GetIncidents(limit int) ([]models.Incident, error) {
+	rows, err := DB.Query(`SELECT `+incidentColumns+` FROM incident_events ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *inc)
+	}
+	return list, rows.Err()
+}
+
+// GetOpenIncidents returns the ongoing incidents, oldest first
+func GetOpenIncidents() ([]models.Incident, error) {
+	rows, err := DB.Query(`SELECT ` + incidentColumns + ` FROM incident_events WHERE resolved_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *inc)
+	}
+	return list, rows.Err()
+}
+
+// ResolveIncident closes a service's ongoing incident, recording its duration
+func ResolveIncident(serviceKey string) error {
+	inc, err := GetOpenIncident(serviceKey)
+	if err != nil || inc == nil {
+		return err
+	}
+	now := time.Now()
+	var duration int64
+	if started, err := time.Parse(time.RFC3339, inc.StartedAt); err == nil {
+		duration = int64(now.Sub(started).Seconds())
+	}
+	_, err = DB.Exec(`UPDATE incident_events SET resolved_at = ?, duration_s = ? WHERE id = ?`, sortableTime(now), duration, inc.ID)
+	return err
+}
+
+// AcknowledgeIncident records who acknowledged an ongoing incident. Acknowledging an
+// incident twice keeps the first acknowledgement; it returns the updated incident.
+func AcknowledgeIncident(id int64, by string) (*models.Incident, error) {
+	inc, err := GetIncident(id)
+	if err != nil || inc == nil {
+		return nil, err
+	}
+	if inc.AcknowledgedAt != "" {
+		return inc, nil
+	}
+	if inc.ResolvedAt != "" {
+		return inc, ErrIncidentResolved
+	}
+	if _, err := DB.Exec(`UPDATE incident_events SET acknowledged_at = ?, acknowledged_by = ? WHERE id = ? AND acknowledged_at IS NULL`,
+		sortableTime(time.Now()), by, id); err != nil {
+		return nil, err
+	}
+	return GetIncident(id)
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"database/sql"
+	"status/app/internal/models"
+	"time"
+)
+
+const maintenanceColumns = `id, service_key, title, start_time, end_time, created_at`
+
+// CreateMaintenanceWindow silences a service's alerts from now until the given time
+func CreateMaintenanceWindow(serviceKey, title string, until time.Time) (int64, error) {
+	result, err := DB.Exec(`INSERT INTO maintenance_windows (service_key, title, start_time, end_time) VALUES (?, ?, ?, ?)`,
+		serviceKey, title, sortableTime(time.Now()), sortableTime(until))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetActiveMaintenance returns the window currently silencing a service, the one ending
+// last if several overlap, or nil if there is none. Windows without a service cover all.
+func GetActiveMaintenance(serviceKey string) (*models.MaintenanceWindow, error) {
+	now := sortableTime(time.Now())
+	var w models.MaintenanceWindow
+	err := DB.QueryRow(`SELECT `+maintenanceColumns+` FROM maintenance_windows
+		WHERE (service_key = ? OR service_key = '') AND start_time <= ? AND end_time > ?
+		ORDER BY end_time DESC LIMIT 1`, serviceKey, now, now).
+		Scan(&w.ID, &w.ServiceKey, &w.Title, &w.StartTime, &w.EndTime, &w.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// EndMaintenance ends a service's active maintenance windows now; it returns how many were ended
+func EndMaintenance(serviceKey string) (int64, error) {
+	now := sortableTime(time.Now())
+	result, err := DB.Exec(`UPDATE maintenance_windows SET end_time = ?
+		WHERE service_key = ? AND start_time <= ? AND end_time > ?`, now, serviceKey, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
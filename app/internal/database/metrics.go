@@ -0,0 +1,48 @@
+package database
+
+import "time"
+
+// SaveServiceMetrics stores the latest value of each extra metric reported by a check.
+func SaveServiceMetrics(serviceKey string, metrics map[string]float64) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for name, value := range metrics {
+		if _, err := tx.Exec(`INSERT INTO service_metrics (service_key, metric, value, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(service_key, metric) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+			serviceKey, name, value, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAllServiceMetrics returns the latest extra metrics keyed by service key.
+func GetAllServiceMetrics() (map[string]map[string]float64, error) {
+	rows, err := DB.Query(`SELECT service_key, metric, value FROM service_metrics`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]map[string]float64{}
+	for rows.Next() {
+		var key, metric string
+		var value float64
+		if err := rows.Scan(&key, &metric, &value); err != nil {
+			return nil, err
+		}
+		if out[key] == nil {
+			out[key] = map[string]float64{}
+		}
+		out[key][metric] = value
+	}
+	return out, rows.Err()
+}
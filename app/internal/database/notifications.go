@@ -0,0 +1,241 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"status/app/internal/crypto"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+)
+
+const channelColumns = `id, type, name, settings, enabled, created_at, COALESCE(updated_at, ''), COALESCE(quiet_hours, '')`
+
+// scanChannel reads one row selected with channelColumns and decrypts its settings.
+func scanChannel(row rowScanner) (*models.NotificationChannel, error) {
+	var ch models.NotificationChannel
+	var settings, quietHours string
+	var enabled int
+	if err := row.Scan(&ch.ID, &ch.Type, &ch.Name, &settings, &enabled, &ch.CreatedAt, &ch.UpdatedAt, &quietHours); err != nil {
+		return nil, err
+	}
+	ch.Enabled = enabled != 0
+	ch.QuietHours = decodeQuietHours(quietHours)
+	ch.Settings = map[string]string{}
+	if settings != "" {
+		plain, err := crypto.Decrypt(settings)
+		if err != nil {
+			log.Printf("Warning: failed to decrypt settings for notification channel %d: %v", ch.ID, err)
+		} else if err := json.Unmarshal([]byte(plain), &ch.Settings); err != nil {
+			log.Printf("Warning: invalid settings for notification channel %d: %v", ch.ID, err)
+		}
+	}
+	return &ch, nil
+}
+
+// encodeChannelSettings serialises and encrypts channel settings for storage.
+func encodeChannelSettings(ch *models.NotificationChannel) string {
+	b, err := json.Marshal(ch.Settings)
+	if err != nil {
+		return ""
+	}
+	enc, err := crypto.Encrypt(string(b))
+	if err != nil {
+		log.Printf("Warning: failed to encrypt settings for notification channel %q: %v", ch.Name, err)
+		return string(b) // fallback to plaintext if encryption fails
+	}
+	return enc
+}
+
+// GetNotificationChannels returns all notification channels ordered by ID
+func GetNotificationChannels() ([]models.NotificationChannel, error) {
+	rows, err := DB.Query(`SELECT ` + channelColumns + ` FROM notification_channels ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := []models.NotificationChannel{}
+	for rows.Next() {
+		ch, err := scanChannel(rows)
+		if err != nil {
+			return nil, err
+		}
+		channels = append(channels, *ch)
+	}
+	return channels, rows.Err()
+}
+
+// GetNotificationChannel returns a channel by ID, or nil if it does not exist
+func GetNotificationChannel(id int) (*models.NotificationChannel, error) {
+	ch, err := scanChannel(DB.QueryRow(`SELECT `+channelColumns+` FROM notification_channels WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return ch, err
+}
+
+// CreateNotificationChannel inserts a new channel and returns its ID
+func CreateNotificationChannel(ch *models.NotificationChannel) (int64, error) {
+	result, err := DB.Exec(`INSERT INTO notification_channels (type, name, settings, enabled, quiet_hours, created_at)
+		VALUES (?, ?, ?, ?, ?, datetime('now'))`,
+		ch.Type, ch.Name, encodeChannelSettings(ch), ch.Enabled, encodeQuietHours(ch.QuietHours))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateNotificationChannel updates an existing channel
+func UpdateNotificationChannel(ch *models.NotificationChannel) error {
+	_, err := DB.Exec(`UPDATE notification_channels SET type=?, name=?, settings=?, enabled=?, quiet_hours=?, updated_at=datetime('now')
+		WHERE id = ?`,
+		ch.Type, ch.Name, encodeChannelSettings(ch), ch.Enabled, encodeQuietHours(ch.QuietHours), ch.ID)
+	return err
+}
+
+// ErrChannelInUse is returned when deleting the only channel some services route their alerts
+// to; removing it would silently send their alerts to every channel instead
+var ErrChannelInUse = errors.New("channel is the only alert route of some services")
+
+// DeleteNotificationChannel removes a channel and drops it from the services routed to it. It
+// refuses with ErrChannelInUse when a service routes to no other channel.
+func DeleteNotificationChannel(id int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, name, notify_channels FROM services WHERE COALESCE(notify_channels, '') != ''`)
+	if err != nil {
+		return err
+	}
+	updates := map[int]string{}
+	var orphaned []string
+	idStr := strconv.Itoa(id)
+	for rows.Next() {
+		var svcID int
+		var name, list string
+		if err := rows.Scan(&svcID, &name, &list); err != nil {
+			rows.Close()
+			return err
+		}
+		parts := strings.Split(list, ",")
+		var kept []string
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" && p != idStr {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(parts) {
+			continue
+		}
+		if len(kept) == 0 {
+			orphaned = append(orphaned, name)
+		}
+		updates[svcID] = strings.Join(kept, ",")
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(orphaned) > 0 {
+		return fmt.Errorf("%w: %s", ErrChannelInUse, strings.Join(orphaned, ", "))
+	}
+
+	if _, err := tx.Exec(`DELETE FROM notification_channels WHERE id = ?`, id); err != nil {
+		return err
+	}
+	for svcID, list := range updates {
+		if _, err := tx.Exec(`UPDATE services SET notify_channels = ? WHERE id = ?`, list, svcID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MigrateAlertConfigChannels copies the legacy single-target channels from alert_config
+// into notification_channels. It runs once; the number of channels created is returned.
+func MigrateAlertConfigChannels() (int, error) {
+	var migrated int
+	err := DB.QueryRow(`SELECT COALESCE(channels_migrated, 0) FROM alert_config WHERE id = 1`).Scan(&migrated)
+	if err == sql.ErrNoRows || migrated == 1 {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	config, err := LoadAlertConfig()
+	if err != nil || config == nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, ch := range LegacyChannels(config) {
+		if _, err := CreateNotificationChannel(&ch); err != nil {
+			return created, err
+		}
+		created++
+	}
+	_, err = DB.Exec(`UPDATE alert_config SET channels_migrated = 1 WHERE id = 1`)
+	return created, err
+}
+
+// ResetChannelMigration makes the next MigrateAlertConfigChannels run again, for alert settings
+// restored from a backup made before notification channels existed.
+func ResetChannelMigration() error {
+	_, err := DB.Exec(`UPDATE alert_config SET channels_migrated = 0 WHERE id = 1`)
+	return err
+}
+
+// LegacyChannels builds notification channels from the single-target alert_config fields.
+// Channels that were configured but switched off are returned disabled.
+func LegacyChannels(config *models.AlertConfig) []models.NotificationChannel {
+	var channels []models.NotificationChannel
+	if config.SMTPHost != "" && config.AlertEmail != "" {
+		channels = append(channels, models.NotificationChannel{
+			Type: models.ChannelEmail,
+			Name: "Email",
+			Settings: map[string]string{
+				"smtp_host":        config.SMTPHost,
+				"smtp_port":        strconv.Itoa(config.SMTPPort),
+				"smtp_user":        config.SMTPUser,
+				"smtp_password":    config.SMTPPassword,
+				"from_email":       config.FromEmail,
+				"to":               config.AlertEmail,
+				"smtp_skip_verify": strconv.FormatBool(config.SMTPSkipVerify),
+			},
+			Enabled: true,
+		})
+	}
+	if config.DiscordWebhookURL != "" {
+		channels = append(channels, models.NotificationChannel{
+			Type:     models.ChannelDiscord,
+			Name:     "Discord",
+			Settings: map[string]string{"webhook_url": config.DiscordWebhookURL},
+			Enabled:  config.DiscordEnabled,
+		})
+	}
+	if config.TelegramBotToken != "" && config.TelegramChatID != "" {
+		channels = append(channels, models.NotificationChannel{
+			Type:     models.ChannelTelegram,
+			Name:     "Telegram",
+			Settings: map[string]string{"bot_token": config.TelegramBotToken, "chat_id": config.TelegramChatID},
+			Enabled:  config.TelegramEnabled,
+		})
+	}
+	if config.WebhookURL != "" {
+		channels = append(channels, models.NotificationChannel{
+			Type:     models.ChannelWebhook,
+			Name:     "Webhook",
+			Settings: map[string]string{"url": config.WebhookURL, "secret": config.WebhookSecret},
+			Enabled:  config.WebhookEnabled,
+		})
+	}
+	return channels
+}
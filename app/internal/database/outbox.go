@@ -0,0 +1,171 @@
+package database
+
+import (
+	"database/sql"
+	"status/app/internal/models"
+	"strings"
+	"time"
+)
+
+const outboxColumns = `id, channel_id, channel_type, channel_name, service_key, subject, payload, status,
+	attempts, last_error, next_attempt_at, created_at, COALESCE(delivered_at, '')`
+
+// sortableTime formats timestamps so they compare correctly as strings
+func sortableTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func scanDelivery(row rowScanner) (*models.NotificationDelivery, error) {
+	var d models.NotificationDelivery
+	err := row.Scan(&d.ID, &d.ChannelID, &d.ChannelType, &d.ChannelName, &d.ServiceKey, &d.Subject, &d.Payload, &d.Status,
+		&d.Attempts, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// EnqueueNotification adds a pending delivery that is due immediately
+func EnqueueNotification(d *models.NotificationDelivery) (int64, error) {
+	return ScheduleNotification(d, time.Now())
+}
+
+// ScheduleNotification adds a pending delivery that becomes due at the given time
+func ScheduleNotification(d *models.NotificationDelivery, due time.Time) (int64, error) {
+	result, err := DB.Exec(`INSERT INTO notification_outbox
+		(channel_id, channel_type, channel_name, service_key, subject, payload, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.ChannelID, d.ChannelType, d.ChannelName, d.ServiceKey, d.Subject, d.Payload, models.DeliveryPending,
+		sortableTime(due), sortableTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// CancelDeferredNotifications removes a service's deferred deliveries that have not been
+// attempted yet and carry one of the given alert events. channelID 0 matches every channel.
+// It returns how many were removed.
+func CancelDeferredNotifications(channelID int, serviceKey string, events ...string) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	query := `DELETE FROM notification_outbox WHERE status = ? AND attempts = 0 AND next_attempt_at > ?
+		AND service_key = ? AND json_extract(payload, '$.event') IN (?` + strings.Repeat(", ?", len(events)-1) + `)`
+	args := []any{models.DeliveryPending, sortableTime(time.Now()), serviceKey}
+	for _, e := range events {
+		args = append(args, e)
+	}
+	if channelID != 0 {
+		query += ` AND channel_id = ?`
+		args = append(args, channelID)
+	}
+	result, err := DB.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClaimNotification takes a due pending delivery for sending: it counts the attempt and
+// pushes next_attempt_at out by the lease so no other sender picks it up meanwhile. If the
+// process dies mid-send the delivery becomes due again once the lease expires.
+func ClaimNotification(id int64, lease time.Duration) (bool, error) {
+	now := time.Now()
+	result, err := DB.Exec(`UPDATE notification_outbox SET attempts = attempts + 1, next_attempt_at = ?
+		WHERE id = ? AND status = ? AND next_attempt_at <= ?`,
+		sortableTime(now.Add(lease)), id, models.DeliveryPending, sortableTime(now))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// GetDueNotificationIDs returns pending deliveries whose next attempt is due, oldest first
+func GetDueNotificationIDs(limit int) ([]int64, error) {
+	rows, err := DB.Query(`SELECT id FROM notification_outbox WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, id LIMIT ?`, models.DeliveryPending, sortableTime(time.Now()), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetNotificationDelivery returns a delivery by ID, or nil if it does not exist
+func GetNotificationDelivery(id int64) (*models.NotificationDelivery, error) {
+	d, err := scanDelivery(DB.QueryRow(`SELECT `+outboxColumns+` FROM notification_outbox WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// GetNotificationDeliveries returns the most recent deliveries, optionally filtered by status
+func GetNotificationDeliveries(status string, limit int) ([]models.NotificationDelivery, error) {
+	query := `SELECT ` + outboxColumns + ` FROM notification_outbox`
+	args := []any{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.NotificationDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// MarkNotificationDelivered records a successful delivery
+func MarkNotificationDelivered(id int64) error {
+	_, err := DB.Exec(`UPDATE notification_outbox SET status = ?, last_error = '', delivered_at = ? WHERE id = ?`,
+		models.DeliveryDelivered, sortableTime(time.Now()), id)
+	return err
+}
+
+// RescheduleNotification records a failed attempt and when to try again
+func RescheduleNotification(id int64, lastError string, next time.Time) error {
+	_, err := DB.Exec(`UPDATE notification_outbox SET last_error = ?, next_attempt_at = ? WHERE id = ?`,
+		lastError, sortableTime(next), id)
+	return err
+}
+
+// MarkNotificationDead dead-letters a delivery that will not be retried
+func MarkNotificationDead(id int64, lastError string) error {
+	_, err := DB.Exec(`UPDATE notification_outbox SET status = ?, last_error = ? WHERE id = ?`,
+		models.DeliveryDead, lastError, id)
+	return err
+}
+
+// PruneNotificationDeliveries removes delivered and dead-lettered rows older than maxAge
+func PruneNotificationDeliveries(maxAge time.Duration) (int64, error) {
+	result, err := DB.Exec(`DELETE FROM notification_outbox WHERE status != ? AND created_at < ?`,
+		models.DeliveryPending, sortableTime(time.Now().Add(-maxAge)))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"database/sql"
+	"status/app/internal/models"
+)
+
+const resourceRuleColumns = `id, name, metric, operator, threshold, hysteresis, for_minutes, container, enabled, created_at`
+
+func scanResourceRule(row rowScanner) (*models.ResourceAlertRule, error) {
+	var r models.ResourceAlertRule
+	err := row.Scan(&r.ID, &r.Name, &r.Metric, &r.Operator, &r.Threshold, &r.Hysteresis, &r.ForMinutes,
+		&r.Container, &r.Enabled, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetResourceAlertRules returns every resource alert rule in creation order
+func GetResourceAlertRules() ([]models.ResourceAlertRule, error) {
+	rows, err := DB.Query(`SELECT ` + resourceRuleColumns + ` FROM resource_alert_rules ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.ResourceAlertRule
+	for rows.Next() {
+		r, err := scanResourceRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *r)
+	}
+	return list, rows.Err()
+}
+
+// GetResourceAlertRule returns a rule by ID, or nil if it does not exist
+func GetResourceAlertRule(id int) (*models.ResourceAlertRule, error) {
+	r, err := scanResourceRule(DB.QueryRow(`SELECT `+resourceRuleColumns+` FROM resource_alert_rules WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// CreateResourceAlertRule inserts a rule and returns its ID
+func CreateResourceAlertRule(r *models.ResourceAlertRule) (int64, error) {
+	res, err := DB.Exec(`INSERT INTO resource_alert_rules (name, metric, operator, threshold, hysteresis, for_minutes, container, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Name, r.Metric, r.Operator, r.Threshold, r.Hysteresis, r.ForMinutes, r.Container, r.Enabled)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateResourceAlertRule saves an existing rule
+func UpdateResourceAlertRule(r *models.ResourceAlertRule) error {
+	_, err := DB.Exec(`UPDATE resource_alert_rules SET name=?, metric=?, operator=?, threshold=?, hysteresis=?, for_minutes=?,
+		container=?, enabled=? WHERE id=?`,
+		r.Name, r.Metric, r.Operator, r.Threshold, r.Hysteresis, r.ForMinutes, r.Container, r.Enabled, r.ID)
+	return err
+}
+
+// DeleteResourceAlertRule removes a rule
+func DeleteResourceAlertRule(id int) error {
+	_, err := DB.Exec(`DELETE FROM resource_alert_rules WHERE id = ?`, id)
+	return err
+}
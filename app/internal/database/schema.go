@@ -190,6 +190,149 @@ CREATE INDEX IF NOT EXISTS idx_logs_service ON system_logs(service);
 	// Connected/integrated services
 	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN connected_to TEXT DEFAULT '';`)
 
+	// Degraded decision based on a single HTTP timing phase
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN degraded_phase TEXT DEFAULT '';`)
+
+	// Content change detection
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN content_check INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN content_ignore TEXT DEFAULT '';`)
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS content_hashes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		service_key TEXT NOT NULL,
+		hash TEXT NOT NULL,
+		seen_at TEXT NOT NULL
+	);`)
+	_, _ = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_content_hashes_service ON content_hashes(service_key, id);`)
+
+	// App health mode (*arr health warnings, media server sessions)
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN app_health INTEGER NOT NULL DEFAULT 0;`)
+
+	// Home Assistant entity rules (JSON array of models.EntityCheck)
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN entity_checks TEXT DEFAULT '';`)
+
+	// Per-service notification routing
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN notify_channels TEXT DEFAULT '';`)
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN notify_events TEXT DEFAULT '';`)
+
+	// Latest extra metrics reported by checks (e.g. game server player counts)
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS service_metrics (
+		service_key TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		value REAL NOT NULL,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (service_key, metric)
+	);`)
+
+	// Notification channels (settings column holds encrypted JSON)
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS notification_channels (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		name TEXT NOT NULL,
+		settings TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL DEFAULT (datetime('now')),
+		updated_at TEXT
+	);`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN channels_migrated INTEGER NOT NULL DEFAULT 0;`)
+
+	// Notification outbox: one row per alert per channel, retried until delivered or dead-lettered
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS notification_outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel_id INTEGER NOT NULL,
+		channel_type TEXT NOT NULL,
+		channel_name TEXT NOT NULL,
+		service_key TEXT NOT NULL DEFAULT '',
+		subject TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		delivered_at TEXT
+	);`)
+	_, _ = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_outbox_due ON notification_outbox(status, next_attempt_at);`)
+
+	// Repeat and escalation policy for ongoing outages
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN repeat_interval_minutes INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN escalate_after_minutes INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN escalation_channels TEXT DEFAULT '';`)
+	_, _ = DB.Exec(`ALTER TABLE service_status_history ADD COLUMN down_since TEXT;`)
+	_, _ = DB.Exec(`ALTER TABLE service_status_history ADD COLUMN last_notified_at TEXT;`)
+	_, _ = DB.Exec(`ALTER TABLE service_status_history ADD COLUMN escalated_at TEXT;`)
+
+	// Notification message templates (overrides of the built-in defaults)
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS notification_templates (
+		event TEXT NOT NULL,
+		channel_type TEXT NOT NULL DEFAULT '',
+		subject TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL DEFAULT '',
+		updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+		PRIMARY KEY (event, channel_type)
+	);`)
+
+	// Message IDs of the first alert of an outage per delivery target, so follow-ups can thread onto it
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS notification_threads (
+		scope TEXT NOT NULL,
+		service_key TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		created_at TEXT NOT NULL DEFAULT (datetime('now')),
+		PRIMARY KEY (scope, service_key)
+	);`)
+
+	// Outage grouping window for digest alerts
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN group_window_seconds INTEGER NOT NULL DEFAULT 0;`)
+
+	// Quiet hours (JSON schedules) and critical services that bypass them
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN quiet_hours TEXT DEFAULT '';`)
+	_, _ = DB.Exec(`ALTER TABLE notification_channels ADD COLUMN quiet_hours TEXT DEFAULT '';`)
+	_, _ = DB.Exec(`ALTER TABLE services ADD COLUMN critical INTEGER NOT NULL DEFAULT 0;`)
+
+	// Flapping detection thresholds and the per-service flapping marker
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN flap_threshold INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN flap_window_minutes INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN flap_stable_minutes INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE service_status_history ADD COLUMN flapping_since TEXT;`)
+
+	// Latency anomaly detection
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN latency_sigma REAL NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN latency_sustain_minutes INTEGER NOT NULL DEFAULT 0;`)
+
+	// Threshold alert rules evaluated against Glances resource snapshots
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS resource_alert_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		operator TEXT NOT NULL DEFAULT '>',
+		threshold REAL NOT NULL DEFAULT 0,
+		hysteresis REAL NOT NULL DEFAULT 0,
+		for_minutes INTEGER NOT NULL DEFAULT 0,
+		container TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL DEFAULT (datetime('now'))
+	);`)
+
+	// Inbound alert webhooks (Alertmanager, Grafana, generic JSON) and the alerts they report
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN inbound_token TEXT NOT NULL DEFAULT '';`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN inbound_labels TEXT NOT NULL DEFAULT '';`)
+	_, _ = DB.Exec(`ALTER TABLE alert_config ADD COLUMN inbound_banners INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS inbound_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		service_key TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		severity TEXT NOT NULL DEFAULT 'critical',
+		status TEXT NOT NULL DEFAULT 'firing',
+		summary TEXT NOT NULL DEFAULT '',
+		status_alert_id TEXT NOT NULL DEFAULT '',
+		started_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		resolved_at TEXT,
+		UNIQUE(source, fingerprint)
+	);`)
+	_, _ = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_inbound_alerts_service ON inbound_alerts(service_key, status);`)
+
 	// Maintenance windows
 	_, _ = DB.Exec(`CREATE TABLE IF NOT EXISTS maintenance_windows (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -216,5 +359,9 @@ CREATE INDEX IF NOT EXISTS idx_logs_service ON system_logs(service);
 	_, _ = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_incidents_service ON incident_events(service_key);`)
 	_, _ = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_incidents_started ON incident_events(started_at);`)
 
+	// Incident acknowledgement
+	_, _ = DB.Exec(`ALTER TABLE incident_events ADD COLUMN acknowledged_at TEXT;`)
+	_, _ = DB.Exec(`ALTER TABLE incident_events ADD COLUMN acknowledged_by TEXT DEFAULT '';`)
+
 	return nil
 }
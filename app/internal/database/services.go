@@ -1,12 +1,29 @@
 package database
 
 import (
+	"encoding/json"
 	"log"
 	"status/app/internal/crypto"
 	"status/app/internal/models"
+	"sync/atomic"
 	"time"
 )
 
+// servicesVersion is bumped whenever services are written or wiped, so data derived from
+// them (such as the alert manager's dependency graph) knows when to reload
+var servicesVersion atomic.Int64
+
+// ServicesVersion returns the current services version
+func ServicesVersion() int64 {
+	return servicesVersion.Load()
+}
+
+// ServicesChanged bumps the services version. Code that rewrites the services or their
+// status history with raw SQL calls it; the service CRUD functions do so themselves.
+func ServicesChanged() {
+	servicesVersion.Add(1)
+}
+
 // InsertSample records a service check sample
 func InsertSample(ts time.Time, key string, ok bool, status int, ms *int) {
 	okInt := 0
@@ -48,42 +65,46 @@ func SetServiceDisabledState(key string, disabled bool) error {
 	return err
 }
 
-// GetAllServices returns all services from the database ordered by display_order
-func GetAllServices() ([]models.ServiceConfig, error) {
-	rows, err := DB.Query(`
-		SELECT id, key, name, url, service_type, COALESCE(icon, ''), COALESCE(icon_url, ''), COALESCE(api_token, ''),
+// serviceColumns is the column list shared by all service SELECT queries; keep in sync with scanService.
+const serviceColumns = `id, key, name, url, service_type, COALESCE(icon, ''), COALESCE(icon_url, ''), COALESCE(api_token, ''),
 		       display_order, visible, check_type, check_interval, timeout, expected_min, expected_max,
-		       COALESCE(depends_on, ''), COALESCE(connected_to, ''), created_at, COALESCE(updated_at, '')
-		FROM services ORDER BY display_order ASC, id ASC`)
+		       COALESCE(depends_on, ''), COALESCE(connected_to, ''), COALESCE(degraded_phase, ''),
+		       COALESCE(content_check, 0), COALESCE(content_ignore, ''), COALESCE(app_health, 0), COALESCE(entity_checks, ''),
+		       COALESCE(notify_channels, ''), COALESCE(notify_events, ''), COALESCE(critical, 0), created_at, COALESCE(updated_at, '')`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanService reads one row selected with serviceColumns and decrypts its token.
+func scanService(row rowScanner) (*models.ServiceConfig, error) {
+	var s models.ServiceConfig
+	var visible, contentCheck, appHealth, critical int
+	var entityChecks string
+	err := row.Scan(&s.ID, &s.Key, &s.Name, &s.URL, &s.ServiceType, &s.Icon, &s.IconURL, &s.APIToken,
+		&s.DisplayOrder, &visible, &s.CheckType, &s.CheckInterval, &s.Timeout,
+		&s.ExpectedMin, &s.ExpectedMax, &s.DependsOn, &s.ConnectedTo, &s.DegradedPhase,
+		&contentCheck, &s.ContentIgnore, &appHealth, &entityChecks, &s.NotifyChannels, &s.NotifyEvents, &critical, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var services []models.ServiceConfig
-	for rows.Next() {
-		var s models.ServiceConfig
-		var visible int
-		err := rows.Scan(&s.ID, &s.Key, &s.Name, &s.URL, &s.ServiceType, &s.Icon, &s.IconURL, &s.APIToken,
-			&s.DisplayOrder, &visible, &s.CheckType, &s.CheckInterval, &s.Timeout,
-			&s.ExpectedMin, &s.ExpectedMax, &s.DependsOn, &s.ConnectedTo, &s.CreatedAt, &s.UpdatedAt)
-		if err != nil {
-			return nil, err
+	s.Visible = visible != 0
+	s.ContentCheck = contentCheck != 0
+	s.AppHealth = appHealth != 0
+	s.Critical = critical != 0
+	if entityChecks != "" {
+		if err := json.Unmarshal([]byte(entityChecks), &s.EntityChecks); err != nil {
+			log.Printf("Warning: invalid entity_checks for service %s: %v", s.Key, err)
 		}
-		s.Visible = visible != 0
-		decryptServiceToken(&s)
-		services = append(services, s)
 	}
-	return services, nil
+	decryptServiceToken(&s)
+	return &s, nil
 }
 
-// GetVisibleServices returns only visible services from the database
-func GetVisibleServices() ([]models.ServiceConfig, error) {
-	rows, err := DB.Query(`
-		SELECT id, key, name, url, service_type, COALESCE(icon, ''), COALESCE(icon_url, ''), COALESCE(api_token, ''),
-		       display_order, visible, check_type, check_interval, timeout, expected_min, expected_max,
-		       COALESCE(depends_on, ''), COALESCE(connected_to, ''), created_at, COALESCE(updated_at, '')
-		FROM services WHERE visible = 1 ORDER BY display_order ASC, id ASC`)
+// queryServices runs a service SELECT and collects the results.
+func queryServices(query string, args ...any) ([]models.ServiceConfig, error) {
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -91,59 +112,33 @@ func GetVisibleServices() ([]models.ServiceConfig, error) {
 
 	var services []models.ServiceConfig
 	for rows.Next() {
-		var s models.ServiceConfig
-		var visible int
-		err := rows.Scan(&s.ID, &s.Key, &s.Name, &s.URL, &s.ServiceType, &s.Icon, &s.IconURL, &s.APIToken,
-			&s.DisplayOrder, &visible, &s.CheckType, &s.CheckInterval, &s.Timeout,
-			&s.ExpectedMin, &s.ExpectedMax, &s.DependsOn, &s.ConnectedTo, &s.CreatedAt, &s.UpdatedAt)
+		s, err := scanService(rows)
 		if err != nil {
 			return nil, err
 		}
-		s.Visible = visible != 0
-		decryptServiceToken(&s)
-		services = append(services, s)
+		services = append(services, *s)
 	}
 	return services, nil
 }
 
+// GetAllServices returns all services from the database ordered by display_order
+func GetAllServices() ([]models.ServiceConfig, error) {
+	return queryServices(`SELECT ` + serviceColumns + ` FROM services ORDER BY display_order ASC, id ASC`)
+}
+
+// GetVisibleServices returns only visible services from the database
+func GetVisibleServices() ([]models.ServiceConfig, error) {
+	return queryServices(`SELECT ` + serviceColumns + ` FROM services WHERE visible = 1 ORDER BY display_order ASC, id ASC`)
+}
+
 // GetServiceByID returns a service by its ID
 func GetServiceByID(id int) (*models.ServiceConfig, error) {
-	var s models.ServiceConfig
-	var visible int
-	err := DB.QueryRow(`
-		SELECT id, key, name, url, service_type, COALESCE(icon, ''), COALESCE(icon_url, ''), COALESCE(api_token, ''),
-		       display_order, visible, check_type, check_interval, timeout, expected_min, expected_max,
-		       COALESCE(depends_on, ''), COALESCE(connected_to, ''), created_at, COALESCE(updated_at, '')
-		FROM services WHERE id = ?`, id).Scan(
-		&s.ID, &s.Key, &s.Name, &s.URL, &s.ServiceType, &s.Icon, &s.IconURL, &s.APIToken,
-		&s.DisplayOrder, &visible, &s.CheckType, &s.CheckInterval, &s.Timeout,
-		&s.ExpectedMin, &s.ExpectedMax, &s.DependsOn, &s.ConnectedTo, &s.CreatedAt, &s.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	s.Visible = visible != 0
-	decryptServiceToken(&s)
-	return &s, nil
+	return scanService(DB.QueryRow(`SELECT `+serviceColumns+` FROM services WHERE id = ?`, id))
 }
 
 // GetServiceByKey returns a service by its key
 func GetServiceByKey(key string) (*models.ServiceConfig, error) {
-	var s models.ServiceConfig
-	var visible int
-	err := DB.QueryRow(`
-		SELECT id, key, name, url, service_type, COALESCE(icon, ''), COALESCE(icon_url, ''), COALESCE(api_token, ''),
-		       display_order, visible, check_type, check_interval, timeout, expected_min, expected_max,
-		       COALESCE(depends_on, ''), COALESCE(connected_to, ''), created_at, COALESCE(updated_at, '')
-		FROM services WHERE key = ?`, key).Scan(
-		&s.ID, &s.Key, &s.Name, &s.URL, &s.ServiceType, &s.Icon, &s.IconURL, &s.APIToken,
-		&s.DisplayOrder, &visible, &s.CheckType, &s.CheckInterval, &s.Timeout,
-		&s.ExpectedMin, &s.ExpectedMax, &s.DependsOn, &s.ConnectedTo, &s.CreatedAt, &s.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	s.Visible = visible != 0
-	decryptServiceToken(&s)
-	return &s, nil
+	return scanService(DB.QueryRow(`SELECT `+serviceColumns+` FROM services WHERE key = ?`, key))
 }
 
 // decryptServiceToken decrypts api_token in-place on a ServiceConfig.
@@ -159,6 +154,18 @@ func decryptServiceToken(s *models.ServiceConfig) {
 	}
 }
 
+// encodeEntityChecks serialises entity rules for the entity_checks column.
+func encodeEntityChecks(checks []models.EntityCheck) string {
+	if len(checks) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(checks)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 // CreateService inserts a new service into the database
 func CreateService(s *models.ServiceConfig) (int64, error) {
 	visible := 0
@@ -182,13 +189,18 @@ func CreateService(s *models.ServiceConfig) (int64, error) {
 
 	result, err := DB.Exec(`
 		INSERT INTO services (key, name, url, service_type, icon, icon_url, api_token, display_order, visible,
-		                      check_type, check_interval, timeout, expected_min, expected_max, depends_on, connected_to, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
+		                      check_type, check_interval, timeout, expected_min, expected_max, depends_on, connected_to,
+		                      degraded_phase, content_check, content_ignore, app_health, entity_checks,
+		                      notify_channels, notify_events, critical, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))`,
 		s.Key, s.Name, s.URL, s.ServiceType, s.Icon, s.IconURL, encToken, s.DisplayOrder, visible,
-		s.CheckType, s.CheckInterval, s.Timeout, s.ExpectedMin, s.ExpectedMax, s.DependsOn, s.ConnectedTo)
+		s.CheckType, s.CheckInterval, s.Timeout, s.ExpectedMin, s.ExpectedMax, s.DependsOn, s.ConnectedTo,
+		s.DegradedPhase, s.ContentCheck, s.ContentIgnore, s.AppHealth, encodeEntityChecks(s.EntityChecks),
+		s.NotifyChannels, s.NotifyEvents, s.Critical)
 	if err != nil {
 		return 0, err
 	}
+	ServicesChanged()
 	return result.LastInsertId()
 }
 
@@ -209,16 +221,22 @@ func UpdateService(s *models.ServiceConfig) error {
 	_, err = DB.Exec(`
 		UPDATE services SET name=?, url=?, service_type=?, icon=?, icon_url=?, api_token=?, display_order=?,
 		                    visible=?, check_type=?, check_interval=?, timeout=?, expected_min=?,
-		                    expected_max=?, depends_on=?, connected_to=?, updated_at=datetime('now')
+		                    expected_max=?, depends_on=?, connected_to=?, degraded_phase=?,
+		                    content_check=?, content_ignore=?, app_health=?, entity_checks=?,
+		                    notify_channels=?, notify_events=?, critical=?, updated_at=datetime('now')
 		WHERE id = ?`,
 		s.Name, s.URL, s.ServiceType, s.Icon, s.IconURL, encToken, s.DisplayOrder, visible,
-		s.CheckType, s.CheckInterval, s.Timeout, s.ExpectedMin, s.ExpectedMax, s.DependsOn, s.ConnectedTo, s.ID)
+		s.CheckType, s.CheckInterval, s.Timeout, s.ExpectedMin, s.ExpectedMax, s.DependsOn, s.ConnectedTo,
+		s.DegradedPhase, s.ContentCheck, s.ContentIgnore, s.AppHealth, encodeEntityChecks(s.EntityChecks),
+		s.NotifyChannels, s.NotifyEvents, s.Critical, s.ID)
+	ServicesChanged()
 	return err
 }
 
 // DeleteService removes a service from the database
 func DeleteService(id int) error {
 	_, err := DB.Exec(`DELETE FROM services WHERE id = ?`, id)
+	ServicesChanged()
 	return err
 }
 
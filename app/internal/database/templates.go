@@ -0,0 +1,55 @@
+package database
+
+import (
+	"database/sql"
+	"status/app/internal/models"
+)
+
+// GetNotificationTemplates returns every saved template override
+func GetNotificationTemplates() ([]models.NotificationTemplate, error) {
+	rows, err := DB.Query(`SELECT event, channel_type, subject, body, updated_at FROM notification_templates ORDER BY event, channel_type`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.NotificationTemplate
+	for rows.Next() {
+		var t models.NotificationTemplate
+		if err := rows.Scan(&t.Event, &t.ChannelType, &t.Subject, &t.Body, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// GetNotificationTemplate returns the override for an event and channel type, or nil if there is none
+func GetNotificationTemplate(event, channelType string) (*models.NotificationTemplate, error) {
+	var t models.NotificationTemplate
+	err := DB.QueryRow(`SELECT event, channel_type, subject, body, updated_at FROM notification_templates
+		WHERE event = ? AND channel_type = ?`, event, channelType).
+		Scan(&t.Event, &t.ChannelType, &t.Subject, &t.Body, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveNotificationTemplate creates or replaces the override for an event and channel type
+func SaveNotificationTemplate(t *models.NotificationTemplate) error {
+	_, err := DB.Exec(`INSERT INTO notification_templates (event, channel_type, subject, body, updated_at)
+		VALUES (?, ?, ?, ?, datetime('now'))
+		ON CONFLICT(event, channel_type) DO UPDATE SET subject=excluded.subject, body=excluded.body, updated_at=excluded.updated_at`,
+		t.Event, t.ChannelType, t.Subject, t.Body)
+	return err
+}
+
+// DeleteNotificationTemplate removes an override so the default applies again
+func DeleteNotificationTemplate(event, channelType string) error {
+	_, err := DB.Exec(`DELETE FROM notification_templates WHERE event = ? AND channel_type = ?`, event, channelType)
+	return err
+}
@@ -0,0 +1,28 @@
+package database
+
+import "database/sql"
+
+// GetNotificationThread returns the message ID an outage's alerts thread onto for a delivery
+// scope (e.g. one Matrix room), or "" if none is recorded
+func GetNotificationThread(scope, serviceKey string) (string, error) {
+	var id string
+	err := DB.QueryRow(`SELECT message_id FROM notification_threads WHERE scope = ? AND service_key = ?`, scope, serviceKey).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return id, err
+}
+
+// SaveNotificationThread records the first message of a service's outage for a delivery scope
+func SaveNotificationThread(scope, serviceKey, messageID string) error {
+	_, err := DB.Exec(`INSERT INTO notification_threads (scope, service_key, message_id, created_at) VALUES (?, ?, ?, datetime('now'))
+		ON CONFLICT(scope, service_key) DO UPDATE SET message_id=excluded.message_id, created_at=excluded.created_at`,
+		scope, serviceKey, messageID)
+	return err
+}
+
+// DeleteNotificationThread forgets a service's outage thread once the outage is over
+func DeleteNotificationThread(scope, serviceKey string) error {
+	_, err := DB.Exec(`DELETE FROM notification_threads WHERE scope = ? AND service_key = ?`, scope, serviceKey)
+	return err
+}
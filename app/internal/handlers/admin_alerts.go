@@ -2,12 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"status/app/internal/alerts"
+	"status/app/internal/auth"
+	"status/app/internal/crypto"
 	"status/app/internal/database"
 	"status/app/internal/models"
+	"strconv"
 	"strings"
 )
 
@@ -26,8 +30,11 @@ func HandleGetAlertsConfig(alertMgr *alerts.Manager) http.HandlerFunc {
 			}
 		}
 
+		// Mask the inbound webhook token — never expose plaintext
+		masked := *config
+		masked.InboundToken = crypto.MaskToken(masked.InboundToken)
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(config)
+		_ = json.NewEncoder(w).Encode(masked)
 	}
 }
 
@@ -39,6 +46,37 @@ func HandleSaveAlertsConfig(alertMgr *alerts.Manager) http.HandlerFunc {
 			http.Error(w, "bad request", http.StatusBadRequest)
 			return
 		}
+		// A token submitted still masked was left untouched in the UI
+		if strings.HasPrefix(config.InboundToken, "•") {
+			config.InboundToken = ""
+			if existing := alertMgr.GetConfig(); existing != nil {
+				config.InboundToken = existing.InboundToken
+			}
+		}
+		if err := alerts.ValidateEscalation(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateGrouping(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateFlapping(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateLatencyAnomaly(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateInbound(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateQuietHours(&config.QuietHours); err != nil {
+			http.Error(w, "quiet hours: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		if err := database.SaveAlertConfig(&config); err != nil {
 			http.Error(w, "server error", http.StatusInternalServerError)
@@ -56,7 +94,7 @@ func HandleSaveAlertsConfig(alertMgr *alerts.Manager) http.HandlerFunc {
 	}
 }
 
-// HandleTestEmail sends a test email
+// HandleTestEmail sends a test email through every enabled email channel
 func HandleTestEmail(alertMgr *alerts.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		config := alertMgr.GetConfig()
@@ -64,36 +102,44 @@ func HandleTestEmail(alertMgr *alerts.Manager) http.HandlerFunc {
 			http.Error(w, "alerts not configured or disabled", http.StatusBadRequest)
 			return
 		}
+		testChannelsOfType(w, r, alertMgr, models.ChannelEmail)
+	}
+}
 
-		subject := "Test Alert from Servicarr"
-		statusURL := alertMgr.ResolveStatusPageURL(inferRequestBaseURL(r))
-		body := alerts.CreateHTMLEmail(
-			subject,
-			"up",
-			"Test Service",
-			"test",
-			"This is a test email from your Servicarr monitoring system. If you received this, your email configuration is working correctly!",
-			statusURL,
-		)
-
-		err := alertMgr.SendEmail(subject, body)
-		if err != nil {
-			log.Printf("Test email failed: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			_ = json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"message": "Failed to send test email. Check your SMTP settings.",
-			})
-			return
+// testChannelsOfType sends a test notification through every enabled channel of one type
+func testChannelsOfType(w http.ResponseWriter, r *http.Request, alertMgr *alerts.Manager, channelType string) {
+	var names []string
+	var failed []string
+	n := testNotification(alertMgr, r)
+	for _, ch := range alertMgr.Channels() {
+		if ch.Type != channelType || !ch.Enabled {
+			continue
+		}
+		names = append(names, ch.Name)
+		if err := alertMgr.Deliver(ch, n); err != nil {
+			log.Printf("Test %s notification via %q failed: %v", channelType, ch.Name, err)
+			failed = append(failed, ch.Name)
 		}
+	}
 
-		w.Header().Set("Content-Type", "application/json")
+	if len(names) == 0 {
+		http.Error(w, "no enabled "+channelType+" channel configured", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(failed) > 0 {
+		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"message": "Test email sent successfully to " + config.AlertEmail,
+			"success": false,
+			"message": "Test notification failed for: " + strings.Join(failed, ", "),
 		})
+		return
 	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Test notification sent via " + strings.Join(names, ", "),
+	})
 }
 
 func inferRequestBaseURL(r *http.Request) string {
@@ -118,51 +164,138 @@ func inferRequestBaseURL(r *http.Request) string {
 	return fmt.Sprintf("%s://%s", proto, host)
 }
 
-// HandleTestNotification sends a test notification to a specific channel
+// HandleTestNotification sends a test notification to every enabled channel of a type
 func HandleTestNotification(alertMgr *alerts.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
-			Channel string `json:"channel"` // discord, telegram, webhook
+			Channel string `json:"channel"` // email, discord, telegram, webhook, slack, teams, ntfy, gotify, pushover, matrix
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			http.Error(w, "bad request", http.StatusBadRequest)
 			return
 		}
 
-		config := alertMgr.GetConfig()
-		if config == nil {
-			http.Error(w, "alerts not configured", http.StatusBadRequest)
+		known := false
+		for _, t := range alerts.ChannelTypes() {
+			known = known || t == req.Channel
+		}
+		if !known {
+			http.Error(w, "unknown channel: "+req.Channel, http.StatusBadRequest)
 			return
 		}
+		testChannelsOfType(w, r, alertMgr, req.Channel)
+	}
+}
 
-		subject := "🔔 Test Notification from Servicarr"
-		statusPageURL := alertMgr.ResolveStatusPageURL("")
+// HandleSimulateAlerts runs a sequence of state transitions for a service through the alert
+// pipeline and returns the notifications it produced. Dry runs (the default) send nothing;
+// neither mode touches the real status history.
+func HandleSimulateAlerts(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Service string                  `json:"service"`
+			Steps   []alerts.SimulationStep `json:"steps"`
+			Live    bool                    `json:"live"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if req.Service == "" {
+			http.Error(w, "service is required", http.StatusBadRequest)
+			return
+		}
 
-		switch req.Channel {
-		case "discord":
-			if config.DiscordWebhookURL == "" {
-				http.Error(w, "Discord webhook URL not configured", http.StatusBadRequest)
-				return
-			}
-			alertMgr.SendDiscord(subject, "up", "Test Service", "This is a test notification from Servicarr. If you see this, Discord notifications are working!", statusPageURL)
-		case "telegram":
-			if config.TelegramBotToken == "" || config.TelegramChatID == "" {
-				http.Error(w, "Telegram bot token or chat ID not configured", http.StatusBadRequest)
-				return
-			}
-			alertMgr.SendTelegram(subject, "up", "Test Service", "This is a test notification from Servicarr. If you see this, Telegram notifications are working!")
-		case "webhook":
-			if config.WebhookURL == "" {
-				http.Error(w, "Webhook URL not configured", http.StatusBadRequest)
+		if err := alerts.ValidateSimulation(req.Steps); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result, err := alertMgr.Simulate(req.Service, req.Steps, req.Live)
+		switch {
+		case errors.Is(err, alerts.ErrServiceNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, alerts.ErrAlertsDisabled):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, "Failed to run the simulation", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+// HandleAcknowledgeIncident acknowledges an ongoing incident, either from the signed token
+// in an alert's ack link or by incident ID from the admin panel, recording the signed-in user.
+func HandleAcknowledgeIncident(authMgr *auth.Auth, alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess, err := authMgr.ParseSession(r)
+		if err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Token      string `json:"token"`
+			IncidentID int64  `json:"incident_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		id := req.IncidentID
+		if req.Token != "" {
+			subject, err := authMgr.VerifyToken(alerts.AckTokenPurpose, req.Token)
+			if err != nil {
+				http.Error(w, "Invalid or expired acknowledgement link", http.StatusBadRequest)
 				return
 			}
-			alertMgr.SendWebhook(subject, "up", "Test Service", "test", "This is a test notification from Servicarr.")
-		default:
-			http.Error(w, "unknown channel: "+req.Channel, http.StatusBadRequest)
+			id, _ = strconv.ParseInt(subject, 10, 64)
+		}
+		if id <= 0 {
+			http.Error(w, "Missing incident ID", http.StatusBadRequest)
+			return
+		}
+
+		incident, err := alertMgr.Acknowledge(id, sess.U)
+		if errors.Is(err, database.ErrIncidentResolved) {
+			http.Error(w, "Incident already resolved", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		if incident == nil {
+			http.Error(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "incident": incident})
+	}
+}
+
+// HandleListIncidents returns the incident timeline, newest first
+func HandleListIncidents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 50
+		}
+
+		incidents, err := database.GetIncidents(limit)
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "Test " + req.Channel + " notification sent"})
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"incidents": incidents})
 	}
 }
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"status/app/internal/alerts"
 	"status/app/internal/checker"
 	"status/app/internal/database"
 	"status/app/internal/models"
@@ -28,26 +29,15 @@ func HandleIngestNow(tracker *monitor.FailureTracker) http.HandlerFunc {
 				continue
 			}
 
-			timeout := time.Duration(sc.Timeout) * time.Second
-			if timeout == 0 {
-				timeout = 5 * time.Second
-			}
+			res := checker.Run(checker.OptionsForService(&sc))
+
+			failures := tracker.Update(sc.Key, res.OK)
+			ok := res.OK || failures < 2
+			ok, _, _ = alerts.InboundOverride(sc.Key, ok, false)
 
-			checkOK, code, ms, errMsg := checker.Check(checker.CheckOptions{
-				URL:         sc.URL,
-				Timeout:     timeout,
-				ExpectedMin: sc.ExpectedMin,
-				ExpectedMax: sc.ExpectedMax,
-				CheckType:   sc.CheckType,
-				ServiceType: sc.ServiceType,
-				APIToken:    sc.APIToken,
-			})
-
-			failures := tracker.Update(sc.Key, checkOK)
-			ok := checkOK || failures < 2
-
-			stats.RecordHeartbeat(sc.Key, ok, ms, code, errMsg)
-			database.InsertSample(now, sc.Key, ok, code, ms)
+			stats.RecordHeartbeatWithTimings(sc.Key, ok, res.MS, res.Code, res.Err, res.Timings)
+			database.InsertSample(now, sc.Key, ok, res.Code, res.MS)
+			_ = database.SaveServiceMetrics(sc.Key, res.Metrics)
 		}
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]any{"saved": true, "t": now})
@@ -100,29 +90,19 @@ func HandleAdminCheck(tracker *monitor.FailureTracker) http.HandlerFunc {
 		}
 
 		now := time.Now().UTC()
-		timeout := time.Duration(sc.Timeout) * time.Second
-		if timeout == 0 {
-			timeout = 5 * time.Second
-		}
-
-		checkOK, code, ms, errMsg := checker.Check(checker.CheckOptions{
-			URL:         sc.URL,
-			Timeout:     timeout,
-			ExpectedMin: sc.ExpectedMin,
-			ExpectedMax: sc.ExpectedMax,
-			CheckType:   sc.CheckType,
-			ServiceType: sc.ServiceType,
-			APIToken:    sc.APIToken,
-		})
+		res := checker.Run(checker.OptionsForService(sc))
 
-		failures := tracker.Update(sc.Key, checkOK)
-		ok := checkOK || failures < 2
-		stats.RecordHeartbeat(sc.Key, ok, ms, code, errMsg)
-		database.InsertSample(now, sc.Key, ok, code, ms)
+		failures := tracker.Update(sc.Key, res.OK)
+		ok := res.OK || failures < 2
+		degraded := ok && checker.IsDegraded(res, sc.DegradedPhase)
+		ok, degraded, _ = alerts.InboundOverride(sc.Key, ok, degraded)
+		stats.RecordHeartbeatWithTimings(sc.Key, ok, res.MS, res.Code, res.Err, res.Timings)
+		database.InsertSample(now, sc.Key, ok, res.Code, res.MS)
+		_ = database.SaveServiceMetrics(sc.Key, res.Metrics)
 
-		degraded := ok && ms != nil && *ms > 200
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(models.LiveResult{Label: sc.Name, OK: ok, Status: code, MS: ms, Degraded: degraded, CheckType: sc.CheckType})
+		_ = json.NewEncoder(w).Encode(models.LiveResult{Label: sc.Name, OK: ok, Status: res.Code, MS: res.MS, Degraded: degraded,
+			Flapping: stats.GetCalculator(sc.Key).Flapping(), CheckType: sc.CheckType, Timings: res.Timings})
 	}
 }
 
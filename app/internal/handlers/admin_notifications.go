@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"status/app/internal/alerts"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"strconv"
+	"strings"
+)
+
+// channelIDFromRequest reads the channel ID set by the router
+func channelIDFromRequest(w http.ResponseWriter, r *http.Request) (int, bool) {
+	idStr := r.URL.Query().Get("_id")
+	if idStr == "" {
+		http.Error(w, "Missing channel ID", http.StatusBadRequest)
+		return 0, false
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid channel ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// channelRequest is a channel as submitted by the client: type and settings, or a
+// notification URL (e.g. discord://token@id) that is parsed into them
+type channelRequest struct {
+	*models.NotificationChannel
+	URL string `json:"url"`
+}
+
+// decodeChannel decodes and normalises a channel from the request body.
+// When the body has no settings object, a copy of defaults is used.
+func decodeChannel(r *http.Request, ch *models.NotificationChannel, defaults map[string]string) error {
+	ch.Settings = nil
+	req := channelRequest{NotificationChannel: ch}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errors.New("Invalid request body")
+	}
+	if req.URL != "" {
+		parsed, err := alerts.ParseChannelURL(req.URL)
+		if err != nil {
+			return err
+		}
+		ch.Type = parsed.Type
+		ch.Settings = parsed.Settings
+		if strings.TrimSpace(ch.Name) == "" {
+			ch.Name = parsed.Name
+		}
+	}
+	ch.Type = strings.ToLower(strings.TrimSpace(ch.Type))
+	ch.Name = strings.TrimSpace(ch.Name)
+	if ch.Settings == nil {
+		ch.Settings = make(map[string]string, len(defaults))
+		for k, v := range defaults {
+			ch.Settings[k] = v
+		}
+	}
+	for k, v := range ch.Settings {
+		ch.Settings[k] = strings.TrimSpace(v)
+	}
+	return nil
+}
+
+// HandleListNotificationChannels returns all notification channels with secrets masked
+func HandleListNotificationChannels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		channels, err := database.GetNotificationChannels()
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		for i := range channels {
+			channels[i] = alerts.MaskChannel(channels[i])
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"channels": channels,
+			"types":    alerts.ChannelTypes(),
+			"events":   alerts.Events,
+		})
+	}
+}
+
+// HandleCreateNotificationChannel creates a notification channel
+func HandleCreateNotificationChannel(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ch := models.NotificationChannel{Enabled: true}
+		if err := decodeChannel(r, &ch, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateChannel(&ch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateQuietHours(&ch.QuietHours); err != nil {
+			http.Error(w, "quiet hours: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, err := database.CreateNotificationChannel(&ch)
+		if err != nil {
+			http.Error(w, "Failed to create channel", http.StatusInternalServerError)
+			return
+		}
+		ch.ID = int(id)
+		_ = alertMgr.ReloadChannels()
+		_ = database.InsertLog(database.LogLevelInfo, database.LogCategorySystem, "", "Notification channel created", ch.Type+": "+ch.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(alerts.MaskChannel(ch))
+	}
+}
+
+// HandleUpdateNotificationChannel updates a notification channel. Secret settings
+// submitted still masked keep their stored value.
+func HandleUpdateNotificationChannel(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := channelIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+		existing, err := database.GetNotificationChannel(id)
+		if err != nil || existing == nil {
+			http.Error(w, "Channel not found", http.StatusNotFound)
+			return
+		}
+
+		// Decode over the stored values so fields omitted by the client are preserved
+		ch := *existing
+		if err := decodeChannel(r, &ch, existing.Settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ch.ID = id
+		alerts.KeepMaskedSecrets(&ch, existing)
+		if err := alerts.ValidateChannel(&ch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateQuietHours(&ch.QuietHours); err != nil {
+			http.Error(w, "quiet hours: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := database.UpdateNotificationChannel(&ch); err != nil {
+			http.Error(w, "Failed to update channel", http.StatusInternalServerError)
+			return
+		}
+		_ = alertMgr.ReloadChannels()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(alerts.MaskChannel(ch))
+	}
+}
+
+// HandleDeleteNotificationChannel deletes a notification channel
+func HandleDeleteNotificationChannel(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := channelIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+		existing, err := database.GetNotificationChannel(id)
+		if err != nil || existing == nil {
+			http.Error(w, "Channel not found", http.StatusNotFound)
+			return
+		}
+
+		if err := database.DeleteNotificationChannel(id); err != nil {
+			if errors.Is(err, database.ErrChannelInUse) {
+				http.Error(w, "Cannot delete the only channel of some services; route them elsewhere first ("+
+					strings.TrimPrefix(err.Error(), database.ErrChannelInUse.Error()+": ")+")", http.StatusConflict)
+				return
+			}
+			http.Error(w, "Failed to delete channel", http.StatusInternalServerError)
+			return
+		}
+		_ = alertMgr.ReloadChannels()
+		_ = database.InsertLog(database.LogLevelInfo, database.LogCategorySystem, "", "Notification channel deleted", existing.Type+": "+existing.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+	}
+}
+
+// HandleTestNotificationChannel sends a test notification through one channel, enabled or not
+func HandleTestNotificationChannel(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := channelIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+		ch, err := database.GetNotificationChannel(id)
+		if err != nil || ch == nil {
+			http.Error(w, "Channel not found", http.StatusNotFound)
+			return
+		}
+
+		if err := alertMgr.Deliver(*ch, testNotification(alertMgr, r)); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "Test notification failed: " + err.Error(),
+			})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "message": "Test notification sent via " + ch.Name})
+	}
+}
+
+// testNotification builds the notification used by the channel test endpoints
+func testNotification(alertMgr *alerts.Manager, r *http.Request) alerts.Notification {
+	return alerts.Notification{
+		Subject:       "🔔 Test Notification from Servicarr",
+		StatusType:    "up",
+		ServiceName:   "Test Service",
+		ServiceKey:    "test",
+		Message:       "This is a test notification from Servicarr. If you see this, the <strong>channel is working</strong>!",
+		StatusPageURL: alertMgr.ResolveStatusPageURL(inferRequestBaseURL(r)),
+	}
+}
+
+// HandleListNotificationDeliveries returns recent outbox entries, optionally filtered by ?status=
+func HandleListNotificationDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+		default:
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 500 {
+			limit = 100
+		}
+
+		deliveries, err := database.GetNotificationDeliveries(status, limit)
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"deliveries": deliveries})
+	}
+}
+
+// HandleResendNotification queues a copy of an earlier delivery
+func HandleResendNotification(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.URL.Query().Get("_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+			return
+		}
+		existing, err := database.GetNotificationDelivery(id)
+		if err != nil || existing == nil {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+			return
+		}
+
+		newID, err := alertMgr.Resend(id)
+		if err != nil {
+			http.Error(w, "Failed to queue notification", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "id": newID})
+	}
+}
+
+// HandleListNotificationTemplates returns the saved template overrides together with the
+// defaults, events and variables the template editor needs
+func HandleListNotificationTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates, err := database.GetNotificationTemplates()
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		if templates == nil {
+			templates = []models.NotificationTemplate{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"templates": templates,
+			"defaults":  alerts.DefaultTemplates,
+			"events":    alerts.TemplateEvents,
+			"variables": alerts.TemplateVariables,
+		})
+	}
+}
+
+// HandleSaveNotificationTemplate creates or replaces the override for an event and channel type
+func HandleSaveNotificationTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var t models.NotificationTemplate
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateTemplate(&t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := database.SaveNotificationTemplate(&t); err != nil {
+			http.Error(w, "Failed to save template", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "template": t})
+	}
+}
+
+// HandleDeleteNotificationTemplate removes an override (?event=&channel_type=) so the default applies
+func HandleDeleteNotificationTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		event := r.URL.Query().Get("event")
+		if event == "" {
+			http.Error(w, "Missing event", http.StatusBadRequest)
+			return
+		}
+		if err := database.DeleteNotificationTemplate(event, r.URL.Query().Get("channel_type")); err != nil {
+			http.Error(w, "Failed to delete template", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+	}
+}
+
+// HandlePreviewNotificationTemplate renders a template with sample data without saving it
+func HandlePreviewNotificationTemplate(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var t models.NotificationTemplate
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		subject, body, err := alertMgr.PreviewTemplate(&t)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"subject": subject, "body": body})
+	}
+}
+
+// HandleExportNotificationURLs returns every channel as a notification URL. Unlike the
+// channel list, secrets are included so the URLs can be used elsewhere.
+func HandleExportNotificationURLs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		channels, err := database.GetNotificationChannels()
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+
+		out := make([]map[string]interface{}, 0, len(channels))
+		for _, ch := range channels {
+			entry := map[string]interface{}{"id": ch.ID, "name": ch.Name, "type": ch.Type, "enabled": ch.Enabled}
+			if u, err := alerts.ChannelURL(ch); err != nil {
+				entry["error"] = err.Error()
+			} else {
+				entry["url"] = u
+			}
+			out = append(out, entry)
+		}
+		_ = database.InsertLog(database.LogLevelInfo, database.LogCategorySystem, "", "Notification channels exported as URLs", strconv.Itoa(len(out))+" channels")
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"channels": out})
+	}
+}
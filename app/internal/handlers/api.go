@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"status/app/internal/alerts"
 	"status/app/internal/cache"
 	"status/app/internal/checker"
 	"status/app/internal/database"
 	"status/app/internal/models"
 	"status/app/internal/monitor"
 	"status/app/internal/stats"
+	"status/app/internal/topology"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -47,44 +50,62 @@ func HandleCheck(tracker *monitor.FailureTracker) http.HandlerFunc {
 				continue
 			}
 
-			timeout := time.Duration(sc.Timeout) * time.Second
-			if timeout == 0 {
-				timeout = 5 * time.Second
-			}
-
-			checkOK, code, ms, _ := checker.Check(checker.CheckOptions{
-				URL:         sc.URL,
-				Timeout:     timeout,
-				ExpectedMin: sc.ExpectedMin,
-				ExpectedMax: sc.ExpectedMax,
-				CheckType:   sc.CheckType,
-				ServiceType: sc.ServiceType,
-				APIToken:    sc.APIToken,
-			})
+			res := checker.Run(checker.OptionsForService(&sc))
 
-			failures := tracker.Update(sc.Key, checkOK)
+			failures := tracker.Update(sc.Key, res.OK)
 
 			// Service is only DOWN after 2 consecutive failures
-			ok := checkOK || failures < 2
-			degraded := ok && ms != nil && *ms > 200
+			ok := res.OK || failures < 2
+			degraded := ok && checker.IsDegraded(res, sc.DegradedPhase)
+			ok, degraded, inbound := alerts.InboundOverride(sc.Key, ok, degraded)
+			if inbound != "" {
+				res.Warnings = append(res.Warnings, inbound)
+			}
 			out.Status[sc.Key] = models.LiveResult{
 				Label:       sc.Name,
 				OK:          ok,
-				Status:      code,
-				MS:          ms,
+				Status:      res.Code,
+				MS:          res.MS,
 				Disabled:    false,
 				Degraded:    degraded,
+				Flapping:    stats.GetCalculator(sc.Key).Flapping(),
 				CheckType:   sc.CheckType,
 				DependsOn:   sc.DependsOn,
 				ConnectedTo: sc.ConnectedTo,
+				Timings:     res.Timings,
+				Game:        res.Game,
+				Metrics:     res.Metrics,
+				Warnings:    res.Warnings,
+				Entities:    res.Entities,
 			}
 		}
+		markImpacted(dbServices, out.Status)
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(out)
 	}
 }
 
+// markImpacted flags failing services whose outage is explained by a failing upstream
+// dependency, naming the root causes
+func markImpacted(services []models.ServiceConfig, status map[string]models.LiveResult) {
+	graph := topology.New(services)
+	down := func(key string) bool {
+		r, ok := status[key]
+		return ok && !r.OK && !r.Disabled
+	}
+	for key, r := range status {
+		if !down(key) {
+			continue
+		}
+		if roots := graph.RootCauses(key, down); len(roots) > 0 {
+			r.Impacted = true
+			r.RootCause = strings.Join(roots, ",")
+			status[key] = r
+		}
+	}
+}
+
 // HandleMetrics returns historical uptime metrics
 func HandleMetrics() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -357,10 +378,11 @@ func HandleDayDetail() http.HandlerFunc {
 		defer rows.Close()
 
 		type HourBucket struct {
-			Hour   string   `json:"hour"`
-			Uptime float64  `json:"uptime"`
-			AvgMs  *float64 `json:"avg_ms,omitempty"`
-			Checks int      `json:"checks"`
+			Hour    string              `json:"hour"`
+			Uptime  float64             `json:"uptime"`
+			AvgMs   *float64            `json:"avg_ms,omitempty"`
+			Checks  int                 `json:"checks"`
+			Timings *models.HTTPTimings `json:"timings,omitempty"` // Average HTTP phase timings
 		}
 
 		hourMap := map[string]*HourBucket{}
@@ -382,6 +404,36 @@ func HandleDayDetail() http.HandlerFunc {
 			hourMap[hourBin] = bucket
 		}
 
+		// Average HTTP phase timings per hour from heartbeats
+		timingRows, err := database.DB.Query(`
+			SELECT substr(time,1,13) AS hour_bin,
+			       AVG(dns_ms), AVG(connect_ms), AVG(tls_ms), AVG(ttfb_ms), AVG(transfer_ms)
+			FROM heartbeats
+			WHERE service_key = ? AND time >= ? AND time < ? AND ttfb_ms IS NOT NULL
+			GROUP BY hour_bin`,
+			serviceKey, startStr, endStr)
+		if err == nil {
+			for timingRows.Next() {
+				var hourBin string
+				var dns, connect, tlsMs, ttfb, transfer sql.NullFloat64
+				if timingRows.Scan(&hourBin, &dns, &connect, &tlsMs, &ttfb, &transfer) != nil {
+					continue
+				}
+				b, ok := hourMap[hourBin]
+				if !ok {
+					continue
+				}
+				b.Timings = &models.HTTPTimings{
+					DNSMs:      int(dns.Float64 + 0.5),
+					ConnectMs:  int(connect.Float64 + 0.5),
+					TLSMs:      int(tlsMs.Float64 + 0.5),
+					TTFBMs:     int(ttfb.Float64 + 0.5),
+					TransferMs: int(transfer.Float64 + 0.5),
+				}
+			}
+			timingRows.Close()
+		}
+
 		// Build 24 hour buckets
 		hours := make([]HourBucket, 24)
 		for h := 0; h < 24; h++ {
@@ -403,7 +455,8 @@ func HandleDayDetail() http.HandlerFunc {
 
 		// Downtime events: exact timestamps when service went down
 		rows2, err := database.DB.Query(`
-			SELECT hb.time, hb.http_status, hb.msg, hb.ping
+			SELECT hb.time, hb.http_status, hb.msg, hb.ping,
+			       hb.dns_ms, hb.connect_ms, hb.tls_ms, hb.ttfb_ms, hb.transfer_ms
 			FROM heartbeats hb
 			WHERE hb.service_key = ? AND hb.status = 0 AND hb.time >= ? AND hb.time < ?
 			ORDER BY hb.time ASC`,
@@ -415,10 +468,11 @@ func HandleDayDetail() http.HandlerFunc {
 		defer rows2.Close()
 
 		type DownEvent struct {
-			Time       string `json:"time"`
-			HTTPStatus *int64 `json:"http_status,omitempty"`
-			Error      string `json:"error,omitempty"`
-			LatencyMs  *int64 `json:"latency_ms,omitempty"`
+			Time       string              `json:"time"`
+			HTTPStatus *int64              `json:"http_status,omitempty"`
+			Error      string              `json:"error,omitempty"`
+			LatencyMs  *int64              `json:"latency_ms,omitempty"`
+			Timings    *models.HTTPTimings `json:"timings,omitempty"`
 		}
 
 		var downEvents []DownEvent
@@ -426,8 +480,18 @@ func HandleDayDetail() http.HandlerFunc {
 			var ts string
 			var st, ping sql.NullInt64
 			var msg sql.NullString
-			_ = rows2.Scan(&ts, &st, &msg, &ping)
+			var dns, connect, tlsMs, ttfb, transfer sql.NullInt64
+			_ = rows2.Scan(&ts, &st, &msg, &ping, &dns, &connect, &tlsMs, &ttfb, &transfer)
 			ev := DownEvent{Time: ts}
+			if ttfb.Valid {
+				ev.Timings = &models.HTTPTimings{
+					DNSMs:      int(dns.Int64),
+					ConnectMs:  int(connect.Int64),
+					TLSMs:      int(tlsMs.Int64),
+					TTFBMs:     int(ttfb.Int64),
+					TransferMs: int(transfer.Int64),
+				}
+			}
 			if st.Valid {
 				ev.HTTPStatus = &st.Int64
 			}
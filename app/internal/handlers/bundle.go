@@ -60,6 +60,7 @@ var publicJSFiles = []string{
 // Admin JS bundle — lazy-loaded for authenticated users only
 var adminJSFiles = []string{
 	"web/static/js/admin-ui.js",
+	"web/static/js/notifications.js",
 	"web/static/js/service-mgmt.js",
 	"web/static/js/settings-tab.js",
 	"web/static/js/logs-tab.js",
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"status/app/internal/alerts"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/monitor"
+	"strings"
+)
+
+// maxInboundBody caps the size of an inbound webhook payload
+const maxInboundBody = 1 << 20
+
+// HandleInboundWebhook receives Alertmanager, Grafana and generic alert webhooks at
+// /api/webhooks/{source}. Requests authenticate with the inbound token as a bearer token
+// or a token query parameter; ?service= maps every alert to one service.
+func HandleInboundWebhook(alertMgr *alerts.Manager, tracker *monitor.FailureTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		source := strings.TrimPrefix(r.URL.Path, "/api/webhooks/")
+		if !slices.Contains(alerts.InboundSources, source) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		config := alertMgr.GetConfig()
+		if config == nil || config.InboundToken == "" {
+			http.Error(w, "inbound webhooks are disabled", http.StatusNotFound)
+			return
+		}
+		token := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(config.InboundToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxInboundBody))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		received, err := alerts.ParseInbound(source, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		checkOK := func(serviceKey string) bool { return tracker.Failures(serviceKey) < 2 }
+		result, err := alertMgr.ReceiveInbound(source, r.URL.Query().Get("service"), received, checkOK)
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(result)
+	}
+}
+
+// HandleListInboundAlerts returns the most recent inbound alerts, firing or resolved
+func HandleListInboundAlerts() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := database.GetInboundAlerts(50)
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			list = []models.InboundAlert{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"alerts": list})
+	}
+}
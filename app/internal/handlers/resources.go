@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"status/app/internal/database"
 	"status/app/internal/resources"
-	"strings"
 	"sync"
 	"time"
 )
@@ -24,11 +23,7 @@ func getGlancesClient(glancesURL string) *resources.Client {
 	}
 
 	// Build full URL: http://{host:port}/api/4
-	fullURL := glancesURL
-	if !strings.HasPrefix(fullURL, "http://") && !strings.HasPrefix(fullURL, "https://") {
-		fullURL = "http://" + fullURL
-	}
-	fullURL = strings.TrimSuffix(fullURL, "/") + "/api/4"
+	fullURL := resources.APIURL(glancesURL)
 
 	glClientMu.RLock()
 	if glClient != nil && glClientURL == fullURL {
@@ -3,8 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"slices"
+	"status/app/internal/alerts"
 	"status/app/internal/database"
 	"status/app/internal/models"
+	"status/app/internal/resources"
+	"strconv"
 )
 
 func defaultResourcesUIConfig() *models.ResourcesUIConfig {
@@ -57,3 +61,115 @@ func HandleSaveResourcesUIConfig() http.HandlerFunc {
 		})
 	}
 }
+
+// resourceRuleIDFromRequest reads the rule ID the router put in the _id query parameter
+func resourceRuleIDFromRequest(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.URL.Query().Get("_id"))
+	if err != nil {
+		http.Error(w, "Invalid rule ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// HandleListResourceAlertRules returns the resource alert rules and the metrics they can use
+func HandleListResourceAlertRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := database.GetResourceAlertRules()
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		if rules == nil {
+			rules = []models.ResourceAlertRule{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"rules":     rules,
+			"metrics":   append(slices.Clone(resources.Metrics), resources.MetricContainer),
+			"operators": alerts.ResourceOperators,
+		})
+	}
+}
+
+// HandleCreateResourceAlertRule creates a resource alert rule
+func HandleCreateResourceAlertRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule := models.ResourceAlertRule{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if err := alerts.ValidateResourceRule(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, err := database.CreateResourceAlertRule(&rule)
+		if err != nil {
+			http.Error(w, "Failed to create rule", http.StatusInternalServerError)
+			return
+		}
+		rule.ID = int(id)
+		_ = database.InsertLog(database.LogLevelInfo, database.LogCategorySystem, "", "Resource alert rule created", rule.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// HandleUpdateResourceAlertRule updates a resource alert rule and restarts its evaluation
+func HandleUpdateResourceAlertRule(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := resourceRuleIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+		existing, err := database.GetResourceAlertRule(id)
+		if err != nil || existing == nil {
+			http.Error(w, "Rule not found", http.StatusNotFound)
+			return
+		}
+
+		// Decode over the stored values so fields omitted by the client are preserved
+		rule := *existing
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		rule.ID = id
+		if err := alerts.ValidateResourceRule(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := database.UpdateResourceAlertRule(&rule); err != nil {
+			http.Error(w, "Failed to update rule", http.StatusInternalServerError)
+			return
+		}
+		alertMgr.ResetResourceRule(id)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// HandleDeleteResourceAlertRule deletes a resource alert rule
+func HandleDeleteResourceAlertRule(alertMgr *alerts.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := resourceRuleIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+		if err := database.DeleteResourceAlertRule(id); err != nil {
+			http.Error(w, "Failed to delete rule", http.StatusInternalServerError)
+			return
+		}
+		alertMgr.ResetResourceRule(id)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"success": true})
+	}
+}
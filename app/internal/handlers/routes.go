@@ -36,6 +36,8 @@ func SetupRoutes(authMgr *auth.Auth, alertMgr *alerts.Manager, tracker *monitor.
 	api.HandleFunc("/api/resources/config", HandleGetResourcesUIConfig())
 	api.HandleFunc("/api/services", HandleGetServices) // Public services list
 	api.HandleFunc("/api/services/templates", HandleGetServiceTemplates)
+	api.HandleFunc("/api/topology", HandleTopology())
+	api.HandleFunc("/api/webhooks/", HandleInboundWebhook(alertMgr, tracker)) // Token-authenticated inbound alerts
 
 	// Admin API routes (with authentication)
 	authAPI := http.NewServeMux()
@@ -91,8 +93,128 @@ func SetupRoutes(authMgr *auth.Auth, alertMgr *alerts.Manager, tracker *monitor.
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	}))
+	authAPI.HandleFunc("/api/admin/resources/alerts", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			HandleListResourceAlertRules()(w, r)
+		case http.MethodPost:
+			HandleCreateResourceAlertRule()(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	authAPI.HandleFunc("/api/admin/resources/alerts/", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		// Handle /api/admin/resources/alerts/{id}
+		q := r.URL.Query()
+		q.Set("_id", strings.TrimPrefix(r.URL.Path, "/api/admin/resources/alerts/"))
+		r.URL.RawQuery = q.Encode()
+		switch r.Method {
+		case http.MethodPut:
+			HandleUpdateResourceAlertRule(alertMgr)(w, r)
+		case http.MethodDelete:
+			HandleDeleteResourceAlertRule(alertMgr)(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
 	authAPI.HandleFunc("/api/admin/alerts/test", authMgr.RequireAuth(HandleTestEmail(alertMgr)))
 	authAPI.HandleFunc("/api/admin/alerts/test-channel", authMgr.RequireAuth(HandleTestNotification(alertMgr)))
+	authAPI.HandleFunc("/api/admin/alerts/simulate", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			HandleSimulateAlerts(alertMgr)(w, r)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	authAPI.HandleFunc("/api/admin/alerts/ack", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			HandleAcknowledgeIncident(authMgr, alertMgr)(w, r)
+		} else {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	authAPI.HandleFunc("/api/admin/alerts/incidents", authMgr.RequireAuth(HandleListIncidents()))
+	authAPI.HandleFunc("/api/admin/alerts/inbound", authMgr.RequireAuth(HandleListInboundAlerts()))
+	authAPI.HandleFunc("/api/admin/notifications", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			HandleListNotificationChannels()(w, r)
+		case http.MethodPost:
+			HandleCreateNotificationChannel(alertMgr)(w, r)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	authAPI.HandleFunc("/api/admin/notifications/", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		// Handle /api/admin/notifications/{id}, /api/admin/notifications/{id}/test,
+		// /api/admin/notifications/deliveries[/{id}/resend], /api/admin/notifications/templates[/preview]
+		// and /api/admin/notifications/urls
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/admin/notifications/"), "/")
+		if parts[0] == "" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if parts[0] == "urls" && len(parts) == 1 && r.Method == http.MethodGet {
+			HandleExportNotificationURLs()(w, r)
+			return
+		}
+		if parts[0] == "templates" {
+			if len(parts) == 2 && parts[1] == "preview" && r.Method == http.MethodPost {
+				HandlePreviewNotificationTemplate(alertMgr)(w, r)
+				return
+			}
+			if len(parts) != 1 {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				HandleListNotificationTemplates()(w, r)
+			case http.MethodPut:
+				HandleSaveNotificationTemplate()(w, r)
+			case http.MethodDelete:
+				HandleDeleteNotificationTemplate()(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if parts[0] == "deliveries" {
+			if len(parts) == 1 && r.Method == http.MethodGet {
+				HandleListNotificationDeliveries()(w, r)
+			} else if len(parts) == 3 && parts[2] == "resend" && r.Method == http.MethodPost {
+				q := r.URL.Query()
+				q.Set("_id", parts[1])
+				r.URL.RawQuery = q.Encode()
+				HandleResendNotification(alertMgr)(w, r)
+			} else {
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+			return
+		}
+		q := r.URL.Query()
+		q.Set("_id", parts[0])
+		r.URL.RawQuery = q.Encode()
+
+		if len(parts) == 1 {
+			switch r.Method {
+			case http.MethodPut:
+				HandleUpdateNotificationChannel(alertMgr)(w, r)
+			case http.MethodDelete:
+				HandleDeleteNotificationChannel(alertMgr)(w, r)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if len(parts) == 2 && parts[1] == "test" {
+			if r.Method == http.MethodPost {
+				HandleTestNotificationChannel(alertMgr)(w, r)
+			} else {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
 	authAPI.HandleFunc("/api/admin/status-alerts", authMgr.RequireAuth(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
@@ -110,7 +232,7 @@ func SetupRoutes(authMgr *auth.Auth, alertMgr *alerts.Manager, tracker *monitor.
 	authAPI.HandleFunc("/api/admin/settings/app-name", authMgr.RequireAuth(HandleUpdateAppName()))
 	authAPI.HandleFunc("/api/admin/settings/password", authMgr.RequireAuth(HandleChangePassword(authMgr)))
 	authAPI.HandleFunc("/api/admin/settings/export", authMgr.RequireAuth(HandleExportDatabase()))
-	authAPI.HandleFunc("/api/admin/settings/import", authMgr.RequireAuth(HandleImportDatabase()))
+	authAPI.HandleFunc("/api/admin/settings/import", authMgr.RequireAuth(HandleImportDatabase(alertMgr)))
 	authAPI.HandleFunc("/api/admin/settings/reset", authMgr.RequireAuth(HandleResetDatabase(authMgr)))
 
 	// Logging routes (admin only)
@@ -196,6 +318,13 @@ func SetupRoutes(authMgr *auth.Auth, alertMgr *alerts.Manager, tracker *monitor.
 			} else {
 				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			}
+		} else if len(parts) == 2 && parts[1] == "content-history" {
+			// /api/admin/services/{id}/content-history
+			if r.Method == http.MethodGet {
+				HandleGetContentHistory(w, r)
+			} else {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
 		} else {
 			http.Error(w, "not found", http.StatusNotFound)
 		}
@@ -214,7 +343,7 @@ func SetupRoutes(authMgr *auth.Auth, alertMgr *alerts.Manager, tracker *monitor.
 	mux.Handle("/api/setup", RateLimitMiddleware(ratelimit.SetupLimiter, http.HandlerFunc(HandleCompleteSetup(authMgr))))
 	mux.Handle("/api/setup/status", RateLimitMiddleware(ratelimit.APILimiter, http.HandlerFunc(HandleSetupStatus)))
 	mux.Handle("/api/setup/service", RateLimitMiddleware(ratelimit.SetupLimiter, http.HandlerFunc(HandleAddFirstService)))
-	mux.Handle("/api/setup/import", RateLimitMiddleware(ratelimit.SetupLimiter, http.HandlerFunc(HandleSetupImport(authMgr))))
+	mux.Handle("/api/setup/import", RateLimitMiddleware(ratelimit.SetupLimiter, http.HandlerFunc(HandleSetupImport(authMgr, alertMgr))))
 
 	// Self-unblock endpoint (accessible even when blocked, but rate limited)
 	mux.Handle("/api/self-unblock", RateLimitMiddleware(ratelimit.SetupLimiter, http.HandlerFunc(HandleSelfUnblock())))
@@ -6,14 +6,17 @@ import (
 	"net"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"status/app/internal/alerts"
 	"status/app/internal/checker"
 	"status/app/internal/crypto"
 	"status/app/internal/database"
 	"status/app/internal/models"
+	"status/app/internal/topology"
 )
 
 // ServiceTemplates defines presets for popular services
@@ -29,6 +32,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Plex-Token",
 		HelpText:      "Enter your Plex server URL and token. The token can be found in Plex settings.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "overseerr",
@@ -51,6 +55,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		URLSuffix:     "/System/Ping",
 		RequiresToken: false,
 		HelpText:      "Enter your Jellyfin server URL.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "emby",
@@ -63,6 +68,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Emby-Token",
 		HelpText:      "Enter your Emby server URL and API key.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "sonarr",
@@ -75,6 +81,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Api-Key",
 		HelpText:      "Enter your Sonarr URL and API key from Settings > General.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "radarr",
@@ -87,6 +94,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Api-Key",
 		HelpText:      "Enter your Radarr URL and API key from Settings > General.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "prowlarr",
@@ -99,6 +107,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Api-Key",
 		HelpText:      "Enter your Prowlarr URL and API key from Settings > General.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "lidarr",
@@ -111,6 +120,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Api-Key",
 		HelpText:      "Enter your Lidarr URL and API key from Settings > General.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "readarr",
@@ -123,6 +133,7 @@ var ServiceTemplates = []models.ServiceTemplate{
 		RequiresToken: true,
 		TokenHeader:   "X-Api-Key",
 		HelpText:      "Enter your Readarr URL and API key from Settings > General.",
+		AppHealth:     true,
 	},
 	{
 		Type:          "bazarr",
@@ -276,6 +287,13 @@ func HandleGetServices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Attach the latest extra metrics (e.g. player counts) for the service cards
+	if metrics, err := database.GetAllServiceMetrics(); err == nil {
+		for i := range services {
+			services[i].Metrics = metrics[services[i].Key]
+		}
+	}
+
 	// Don't expose API tokens or internal URLs to non-admin
 	if !isAdmin {
 		for i := range services {
@@ -307,6 +325,11 @@ func HandleCreateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateServiceOptions(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Generate key from name if not provided
 	if s.Key == "" {
 		s.Key = generateServiceKey(s.Name)
@@ -318,6 +341,10 @@ func HandleCreateService(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "A service with this key already exists", http.StatusConflict)
 		return
 	}
+	if err := validateDependencies(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Set defaults
 	if s.ServiceType == "" {
@@ -371,7 +398,18 @@ func HandleUpdateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var s models.ServiceConfig
+	// Check service exists
+	existing, err := database.GetServiceByID(id)
+	if err != nil || existing == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	// Decode over the stored values so fields omitted by the client are preserved. Slices and
+	// maps are cleared first: decoding would otherwise fill the stored elements in place, so a
+	// rule omitting a field would inherit it from the rule previously at its index.
+	s := *existing
+	s.EntityChecks, s.Metrics = nil, nil
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
@@ -385,16 +423,17 @@ func HandleUpdateService(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Name and URL are required", http.StatusBadRequest)
 		return
 	}
-
-	// Check service exists
-	existing, err := database.GetServiceByID(id)
-	if err != nil || existing == nil {
-		http.Error(w, "Service not found", http.StatusNotFound)
+	if err := validateServiceOptions(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Keep the original key
 	s.Key = existing.Key
+	if err := validateDependencies(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	// Preserve display order (reordering handled separately)
 	s.DisplayOrder = existing.DisplayOrder
 
@@ -493,6 +532,69 @@ func HandleReorderServices(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// validateServiceOptions checks the optional per-service check settings
+func validateServiceOptions(s *models.ServiceConfig) error {
+	s.DegradedPhase = strings.ToLower(strings.TrimSpace(s.DegradedPhase))
+	if !slices.Contains(checker.DegradedPhases, s.DegradedPhase) {
+		return fmt.Errorf("invalid degraded_phase %q", s.DegradedPhase)
+	}
+	if _, err := checker.ParseIgnorePatterns(s.ContentIgnore); err != nil {
+		return err
+	}
+	if s.AppHealth && !checker.SupportsAppHealth(s.ServiceType) {
+		return fmt.Errorf("app health mode is not supported for service type %q", s.ServiceType)
+	}
+	if len(s.EntityChecks) > 0 && strings.ToLower(s.ServiceType) != "homeassistant" {
+		return fmt.Errorf("entity checks require the homeassistant service type")
+	}
+	for i := range s.EntityChecks {
+		ec := &s.EntityChecks[i]
+		ec.EntityID = strings.TrimSpace(ec.EntityID)
+		ec.Severity = strings.ToLower(strings.TrimSpace(ec.Severity))
+		if err := checker.ValidateEntityCheck(*ec); err != nil {
+			return err
+		}
+	}
+	return alerts.ValidateRouting(s)
+}
+
+// validateDependencies checks the service's depends_on against the saved services
+func validateDependencies(s *models.ServiceConfig) error {
+	services, err := database.GetAllServices()
+	if err != nil {
+		return fmt.Errorf("failed to load services")
+	}
+	return topology.Validate(services, s)
+}
+
+// HandleGetContentHistory returns the recorded response-body hashes for a service
+func HandleGetContentHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("_id"))
+	if err != nil {
+		http.Error(w, "Invalid service ID", http.StatusBadRequest)
+		return
+	}
+
+	svc, err := database.GetServiceByID(id)
+	if err != nil || svc == nil {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	history, err := database.GetContentHistory(svc.Key)
+	if err != nil {
+		http.Error(w, "Failed to load content history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"service_key":   svc.Key,
+		"content_check": svc.ContentCheck,
+		"history":       history,
+	})
+}
+
 // generateServiceKey creates a URL-safe key from a service name
 func generateServiceKey(name string) string {
 	// Convert to lowercase
@@ -4,10 +4,14 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"io"
+	"log"
 	"net/http"
+	"status/app/internal/alerts"
 	"status/app/internal/auth"
 	"status/app/internal/database"
 	"status/app/internal/models"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -22,6 +26,9 @@ type DatabaseExport struct {
 	AlertConfig *exportAlertConfig     `json:"alert_config"`
 	Resources   *exportResourcesConfig `json:"resources_config"`
 	Samples     []exportSample         `json:"samples"`
+
+	NotificationChannels []exportChannel      `json:"notification_channels,omitempty"`
+	ResourceAlertRules   []exportResourceRule `json:"resource_alert_rules,omitempty"`
 }
 
 type exportService struct {
@@ -38,6 +45,15 @@ type exportService struct {
 	Timeout       int    `json:"timeout"`
 	ExpectedMin   int    `json:"expected_min"`
 	ExpectedMax   int    `json:"expected_max"`
+
+	DegradedPhase  string               `json:"degraded_phase"`
+	ContentCheck   bool                 `json:"content_check"`
+	ContentIgnore  string               `json:"content_ignore"`
+	AppHealth      bool                 `json:"app_health"`
+	EntityChecks   []models.EntityCheck `json:"entity_checks,omitempty"`
+	NotifyChannels string               `json:"notify_channels"` // Channel IDs as exported in notification_channels
+	NotifyEvents   string               `json:"notify_events"`
+	Critical       bool                 `json:"critical"`
 }
 
 type exportAppSettings struct {
@@ -47,17 +63,42 @@ type exportAppSettings struct {
 
 type exportAlertConfig struct {
 	Enabled         bool   `json:"enabled"`
-	SMTPHost        string `json:"smtp_host"`
-	SMTPPort        int    `json:"smtp_port"`
-	SMTPUser        string `json:"smtp_user"`
-	AlertEmail      string `json:"alert_email"`
-	FromEmail       string `json:"from_email"`
 	StatusPageURL   string `json:"status_page_url"`
-	SMTPSkipVerify  bool   `json:"smtp_skip_verify"`
 	AlertOnDown     bool   `json:"alert_on_down"`
 	AlertOnDegraded bool   `json:"alert_on_degraded"`
 	AlertOnUp       bool   `json:"alert_on_up"`
-	// SMTP password is NOT exported for security
+
+	// Single-target email settings, only read from backups made before notification channels;
+	// importing them recreates the email channel
+	SMTPHost       string `json:"smtp_host,omitempty"`
+	SMTPPort       int    `json:"smtp_port,omitempty"`
+	SMTPUser       string `json:"smtp_user,omitempty"`
+	AlertEmail     string `json:"alert_email,omitempty"`
+	FromEmail      string `json:"from_email,omitempty"`
+	SMTPSkipVerify bool   `json:"smtp_skip_verify,omitempty"`
+
+	RepeatIntervalMinutes int               `json:"repeat_interval_minutes"`
+	EscalateAfterMinutes  int               `json:"escalate_after_minutes"`
+	EscalationChannels    string            `json:"escalation_channels"`
+	GroupWindowSeconds    int               `json:"group_window_seconds"`
+	QuietHours            models.QuietHours `json:"quiet_hours"`
+	FlapThreshold         int               `json:"flap_threshold"`
+	FlapWindowMinutes     int               `json:"flap_window_minutes"`
+	FlapStableMinutes     int               `json:"flap_stable_minutes"`
+	LatencySigma          float64           `json:"latency_sigma"`
+	LatencySustainMinutes int               `json:"latency_sustain_minutes"`
+	InboundLabels         string            `json:"inbound_labels"`
+	InboundBanners        bool              `json:"inbound_banners"`
+	// SMTP password and inbound webhook token are NOT exported for security
+}
+
+type exportChannel struct {
+	ID         int               `json:"id"` // Referenced by service routing and escalation channels
+	Type       string            `json:"type"`
+	Name       string            `json:"name"`
+	Settings   map[string]string `json:"settings"` // Secret settings are NOT exported
+	Enabled    bool              `json:"enabled"`
+	QuietHours models.QuietHours `json:"quiet_hours"`
 }
 
 type exportResourcesConfig struct {
@@ -76,6 +117,17 @@ type exportResourcesConfig struct {
 	Uptime     bool   `json:"uptime"`
 }
 
+type exportResourceRule struct {
+	Name       string  `json:"name"`
+	Metric     string  `json:"metric"`
+	Operator   string  `json:"operator"`
+	Threshold  float64 `json:"threshold"`
+	Hysteresis float64 `json:"hysteresis"`
+	ForMinutes int     `json:"for_minutes"`
+	Container  string  `json:"container,omitempty"`
+	Enabled    bool    `json:"enabled"`
+}
+
 type exportSample struct {
 	TakenAt    string `json:"taken_at"`
 	ServiceKey string `json:"service_key"`
@@ -122,24 +174,55 @@ func HandleExportDatabase() http.HandlerFunc {
 					Timeout:       s.Timeout,
 					ExpectedMin:   s.ExpectedMin,
 					ExpectedMax:   s.ExpectedMax,
+
+					DegradedPhase:  s.DegradedPhase,
+					ContentCheck:   s.ContentCheck,
+					ContentIgnore:  s.ContentIgnore,
+					AppHealth:      s.AppHealth,
+					EntityChecks:   s.EntityChecks,
+					NotifyChannels: s.NotifyChannels,
+					NotifyEvents:   s.NotifyEvents,
+					Critical:       s.Critical,
 				})
 			}
 		}
 
-		// Export alert config (without SMTP password)
+		// Export alert config
 		if alertCfg, err := database.LoadAlertConfig(); err == nil && alertCfg != nil {
 			export.AlertConfig = &exportAlertConfig{
 				Enabled:         alertCfg.Enabled,
-				SMTPHost:        alertCfg.SMTPHost,
-				SMTPPort:        alertCfg.SMTPPort,
-				SMTPUser:        alertCfg.SMTPUser,
-				AlertEmail:      alertCfg.AlertEmail,
-				FromEmail:       alertCfg.FromEmail,
 				StatusPageURL:   alertCfg.StatusPageURL,
-				SMTPSkipVerify:  alertCfg.SMTPSkipVerify,
 				AlertOnDown:     alertCfg.AlertOnDown,
 				AlertOnDegraded: alertCfg.AlertOnDegraded,
 				AlertOnUp:       alertCfg.AlertOnUp,
+
+				RepeatIntervalMinutes: alertCfg.RepeatIntervalMinutes,
+				EscalateAfterMinutes:  alertCfg.EscalateAfterMinutes,
+				EscalationChannels:    alertCfg.EscalationChannels,
+				GroupWindowSeconds:    alertCfg.GroupWindowSeconds,
+				QuietHours:            alertCfg.QuietHours,
+				FlapThreshold:         alertCfg.FlapThreshold,
+				FlapWindowMinutes:     alertCfg.FlapWindowMinutes,
+				FlapStableMinutes:     alertCfg.FlapStableMinutes,
+				LatencySigma:          alertCfg.LatencySigma,
+				LatencySustainMinutes: alertCfg.LatencySustainMinutes,
+				InboundLabels:         alertCfg.InboundLabels,
+				InboundBanners:        alertCfg.InboundBanners,
+			}
+		}
+
+		// Export notification channels (without secrets)
+		if channels, err := database.GetNotificationChannels(); err == nil {
+			for _, ch := range channels {
+				ch = alerts.StripSecrets(ch)
+				export.NotificationChannels = append(export.NotificationChannels, exportChannel{
+					ID:         ch.ID,
+					Type:       ch.Type,
+					Name:       ch.Name,
+					Settings:   ch.Settings,
+					Enabled:    ch.Enabled,
+					QuietHours: ch.QuietHours,
+				})
 			}
 		}
 
@@ -162,6 +245,22 @@ func HandleExportDatabase() http.HandlerFunc {
 			}
 		}
 
+		// Export resource alert rules
+		if rules, err := database.GetResourceAlertRules(); err == nil {
+			for _, r := range rules {
+				export.ResourceAlertRules = append(export.ResourceAlertRules, exportResourceRule{
+					Name:       r.Name,
+					Metric:     r.Metric,
+					Operator:   r.Operator,
+					Threshold:  r.Threshold,
+					Hysteresis: r.Hysteresis,
+					ForMinutes: r.ForMinutes,
+					Container:  r.Container,
+					Enabled:    r.Enabled,
+				})
+			}
+		}
+
 		// Export all samples
 		rows, err := database.DB.Query(`
 			SELECT taken_at, service_key, ok, COALESCE(http_status, 0), latency_ms 
@@ -188,7 +287,7 @@ func HandleExportDatabase() http.HandlerFunc {
 }
 
 // HandleImportDatabase imports a database backup
-func HandleImportDatabase() http.HandlerFunc {
+func HandleImportDatabase(alertMgr *alerts.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -237,6 +336,9 @@ func HandleImportDatabase() http.HandlerFunc {
 			return
 		}
 
+		// Import notification channels (replacing existing ones)
+		channelIDs := importChannels(export.NotificationChannels)
+
 		// Import services (clear existing first)
 		if len(export.Services) > 0 {
 			_, _ = database.DB.Exec(`DELETE FROM services`)
@@ -246,6 +348,7 @@ func HandleImportDatabase() http.HandlerFunc {
 			_, _ = database.DB.Exec(`DELETE FROM stat_hourly`)
 			_, _ = database.DB.Exec(`DELETE FROM stat_daily`)
 			_, _ = database.DB.Exec(`DELETE FROM heartbeats`)
+			database.ServicesChanged()
 			for _, s := range export.Services {
 				svc := &models.ServiceConfig{
 					Key:           s.Key,
@@ -261,6 +364,15 @@ func HandleImportDatabase() http.HandlerFunc {
 					Timeout:       s.Timeout,
 					ExpectedMin:   s.ExpectedMin,
 					ExpectedMax:   s.ExpectedMax,
+
+					DegradedPhase:  s.DegradedPhase,
+					ContentCheck:   s.ContentCheck,
+					ContentIgnore:  s.ContentIgnore,
+					AppHealth:      s.AppHealth,
+					EntityChecks:   s.EntityChecks,
+					NotifyChannels: remapChannelIDs(s.NotifyChannels, channelIDs),
+					NotifyEvents:   s.NotifyEvents,
+					Critical:       s.Critical,
 				}
 				_, _ = database.CreateService(svc)
 			}
@@ -280,8 +392,24 @@ func HandleImportDatabase() http.HandlerFunc {
 				AlertOnDown:     export.AlertConfig.AlertOnDown,
 				AlertOnDegraded: export.AlertConfig.AlertOnDegraded,
 				AlertOnUp:       export.AlertConfig.AlertOnUp,
+
+				RepeatIntervalMinutes: export.AlertConfig.RepeatIntervalMinutes,
+				EscalateAfterMinutes:  export.AlertConfig.EscalateAfterMinutes,
+				EscalationChannels:    remapChannelIDs(export.AlertConfig.EscalationChannels, channelIDs),
+				GroupWindowSeconds:    export.AlertConfig.GroupWindowSeconds,
+				QuietHours:            export.AlertConfig.QuietHours,
+				FlapThreshold:         export.AlertConfig.FlapThreshold,
+				FlapWindowMinutes:     export.AlertConfig.FlapWindowMinutes,
+				FlapStableMinutes:     export.AlertConfig.FlapStableMinutes,
+				LatencySigma:          export.AlertConfig.LatencySigma,
+				LatencySustainMinutes: export.AlertConfig.LatencySustainMinutes,
+				InboundLabels:         export.AlertConfig.InboundLabels,
+				InboundBanners:        export.AlertConfig.InboundBanners,
 			}
 			_ = database.SaveAlertConfig(alertCfg)
+			if export.NotificationChannels == nil {
+				_ = database.ResetChannelMigration()
+			}
 		}
 
 		// Import resources config
@@ -304,6 +432,26 @@ func HandleImportDatabase() http.HandlerFunc {
 			_ = database.SaveResourcesUIConfig(resCfg)
 		}
 
+		// Import resource alert rules (replacing existing ones)
+		if export.ResourceAlertRules != nil {
+			_, _ = database.DB.Exec(`DELETE FROM resource_alert_rules`)
+			for _, r := range export.ResourceAlertRules {
+				rule := &models.ResourceAlertRule{
+					Name:       r.Name,
+					Metric:     r.Metric,
+					Operator:   r.Operator,
+					Threshold:  r.Threshold,
+					Hysteresis: r.Hysteresis,
+					ForMinutes: r.ForMinutes,
+					Container:  r.Container,
+					Enabled:    r.Enabled,
+				}
+				if alerts.ValidateResourceRule(rule) == nil {
+					_, _ = database.CreateResourceAlertRule(rule)
+				}
+			}
+		}
+
 		// Import samples
 		if len(export.Samples) > 0 {
 			_, _ = database.DB.Exec(`DELETE FROM samples`)
@@ -317,11 +465,68 @@ func HandleImportDatabase() http.HandlerFunc {
 			}
 		}
 
+		finishImport(alertMgr)
+
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "services_imported": len(export.Services)})
 	}
 }
 
+// importChannels replaces the notification channels with the backed-up ones and returns the
+// ID each was restored under, by its ID in the backup. Backups without channels leave the
+// existing ones in place and return nil.
+func importChannels(channels []exportChannel) map[int]int {
+	if channels == nil {
+		return nil
+	}
+	_, _ = database.DB.Exec(`DELETE FROM notification_channels`)
+	ids := make(map[int]int, len(channels))
+	for _, c := range channels {
+		id, err := database.CreateNotificationChannel(&models.NotificationChannel{
+			Type:       c.Type,
+			Name:       c.Name,
+			Settings:   c.Settings,
+			Enabled:    c.Enabled,
+			QuietHours: c.QuietHours,
+		})
+		if err == nil {
+			ids[c.ID] = int(id)
+		}
+	}
+	return ids
+}
+
+// remapChannelIDs rewrites a comma-separated list of backed-up channel IDs to the IDs the
+// channels were restored under, dropping any that were not restored. A nil ids map (a backup
+// without channels) keeps the list as is.
+func remapChannelIDs(list string, ids map[int]int) string {
+	if ids == nil || list == "" {
+		return list
+	}
+	var out []string
+	for _, part := range strings.Split(list, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if newID, ok := ids[id]; ok {
+			out = append(out, strconv.Itoa(newID))
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// finishImport turns the single-target alert settings of an older backup into notification
+// channels and has the alert manager pick up the restored configuration
+func finishImport(alertMgr *alerts.Manager) {
+	if n, err := database.MigrateAlertConfigChannels(); err != nil {
+		log.Printf("Warning: Failed to migrate notification channels from backup: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated %d notification channel(s) from backup alert settings", n)
+	}
+	_ = alertMgr.ReloadConfig()
+}
+
 // HandleResetDatabase resets the database to initial state
 func HandleResetDatabase(authMgr *auth.Auth) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -373,9 +578,16 @@ func HandleResetDatabase(authMgr *auth.Auth) http.HandlerFunc {
 			"ip_blacklist",
 			"service_state",
 			"alert_config",
+			"notification_channels",
+			"notification_outbox",
+			"notification_templates",
+			"notification_threads",
 			"resources_ui_config",
+			"resource_alert_rules",
 			"status_alerts",
+			"inbound_alerts",
 			"service_status_history",
+			"incident_events",
 			"app_settings",
 			"stat_minutely",
 			"stat_hourly",
@@ -387,6 +599,7 @@ func HandleResetDatabase(authMgr *auth.Auth) http.HandlerFunc {
 		for _, table := range tables {
 			_, _ = database.DB.Exec(`DELETE FROM ` + table)
 		}
+		database.ServicesChanged()
 
 		// Generate a fresh random temporary secret
 		tempSecret := make([]byte, 32)
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"status/app/internal/alerts"
 	"status/app/internal/auth"
 	"status/app/internal/crypto"
 	"status/app/internal/database"
@@ -289,7 +290,7 @@ func HandleAddFirstService(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleSetupImport handles importing a backup during setup
-func HandleSetupImport(authMgr *auth.Auth) http.HandlerFunc {
+func HandleSetupImport(authMgr *auth.Auth, alertMgr *alerts.Manager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -347,6 +348,9 @@ func HandleSetupImport(authMgr *auth.Auth) http.HandlerFunc {
 			return
 		}
 
+		// Import notification channels
+		channelIDs := importChannels(export.NotificationChannels)
+
 		// Import services
 		if len(export.Services) > 0 {
 			_, _ = database.DB.Exec(`DELETE FROM services`)
@@ -356,6 +360,7 @@ func HandleSetupImport(authMgr *auth.Auth) http.HandlerFunc {
 			_, _ = database.DB.Exec(`DELETE FROM stat_hourly`)
 			_, _ = database.DB.Exec(`DELETE FROM stat_daily`)
 			_, _ = database.DB.Exec(`DELETE FROM heartbeats`)
+			database.ServicesChanged()
 			for _, s := range export.Services {
 				svc := &models.ServiceConfig{
 					Key:           s.Key,
@@ -371,6 +376,15 @@ func HandleSetupImport(authMgr *auth.Auth) http.HandlerFunc {
 					Timeout:       s.Timeout,
 					ExpectedMin:   s.ExpectedMin,
 					ExpectedMax:   s.ExpectedMax,
+
+					DegradedPhase:  s.DegradedPhase,
+					ContentCheck:   s.ContentCheck,
+					ContentIgnore:  s.ContentIgnore,
+					AppHealth:      s.AppHealth,
+					EntityChecks:   s.EntityChecks,
+					NotifyChannels: remapChannelIDs(s.NotifyChannels, channelIDs),
+					NotifyEvents:   s.NotifyEvents,
+					Critical:       s.Critical,
 				}
 				_, _ = database.CreateService(svc)
 			}
@@ -390,8 +404,24 @@ func HandleSetupImport(authMgr *auth.Auth) http.HandlerFunc {
 				AlertOnDown:     export.AlertConfig.AlertOnDown,
 				AlertOnDegraded: export.AlertConfig.AlertOnDegraded,
 				AlertOnUp:       export.AlertConfig.AlertOnUp,
+
+				RepeatIntervalMinutes: export.AlertConfig.RepeatIntervalMinutes,
+				EscalateAfterMinutes:  export.AlertConfig.EscalateAfterMinutes,
+				EscalationChannels:    remapChannelIDs(export.AlertConfig.EscalationChannels, channelIDs),
+				GroupWindowSeconds:    export.AlertConfig.GroupWindowSeconds,
+				QuietHours:            export.AlertConfig.QuietHours,
+				FlapThreshold:         export.AlertConfig.FlapThreshold,
+				FlapWindowMinutes:     export.AlertConfig.FlapWindowMinutes,
+				FlapStableMinutes:     export.AlertConfig.FlapStableMinutes,
+				LatencySigma:          export.AlertConfig.LatencySigma,
+				LatencySustainMinutes: export.AlertConfig.LatencySustainMinutes,
+				InboundLabels:         export.AlertConfig.InboundLabels,
+				InboundBanners:        export.AlertConfig.InboundBanners,
 			}
 			_ = database.SaveAlertConfig(alertCfg)
+			if export.NotificationChannels == nil {
+				_ = database.ResetChannelMigration()
+			}
 		}
 
 		// Import resources config
@@ -427,6 +457,8 @@ func HandleSetupImport(authMgr *auth.Auth) http.HandlerFunc {
 			}
 		}
 
+		finishImport(alertMgr)
+
 		// Now we need credentials - prompt user to create them
 		// But for import, we'll require them in a separate step or use the backup username
 		// For now, redirect to main setup to create credentials
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"status/app/internal/database"
+	"status/app/internal/models"
+	"status/app/internal/stats"
+	"status/app/internal/topology"
+	"time"
+)
+
+// Topology node statuses
+const (
+	topologyUp       = "up"
+	topologyDown     = "down"
+	topologyImpacted = "impacted"
+	topologyDisabled = "disabled"
+	topologyUnknown  = "unknown"
+)
+
+// HandleTopology returns the dependency graph of the visible services with their last
+// recorded status and the services currently behind downstream outages
+func HandleTopology() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		services, err := database.GetVisibleServices()
+		if err != nil {
+			http.Error(w, "server error", http.StatusInternalServerError)
+			return
+		}
+		graph := topology.New(services)
+
+		// Last recorded status per service, from the background checks' heartbeats
+		status := make(map[string]string, len(services))
+		for _, s := range services {
+			if disabled, _ := database.GetServiceDisabledState(s.Key); disabled {
+				status[s.Key] = topologyDisabled
+				continue
+			}
+			hb := stats.GetCalculator(s.Key).GetRecentHeartbeats(1)
+			switch {
+			case len(hb) == 0:
+				status[s.Key] = topologyUnknown
+			case hb[0].Status == 1:
+				status[s.Key] = topologyUp
+			case hb[0].Status == 0:
+				status[s.Key] = topologyDown
+			default:
+				status[s.Key] = topologyUnknown
+			}
+		}
+		down := func(key string) bool { return status[key] == topologyDown || status[key] == topologyImpacted }
+
+		out := models.Topology{T: time.Now().UTC(), Nodes: []models.TopologyNode{}, Edges: []models.TopologyEdge{}, RootCauses: []models.TopologyRootCause{}}
+		impactedBy := make(map[string][]string)
+		for _, s := range services {
+			if !down(s.Key) {
+				continue
+			}
+			roots := graph.RootCauses(s.Key, down)
+			if len(roots) > 0 {
+				status[s.Key] = topologyImpacted
+			}
+			for _, root := range roots {
+				impactedBy[root] = append(impactedBy[root], s.Key)
+			}
+		}
+
+		for _, s := range services {
+			out.Nodes = append(out.Nodes, models.TopologyNode{Key: s.Key, Name: s.Name, Status: status[s.Key]})
+			for _, dep := range graph.DependsOn(s.Key) {
+				out.Edges = append(out.Edges, models.TopologyEdge{From: s.Key, To: dep, Type: "depends_on"})
+			}
+			for _, peer := range topology.SplitKeys(s.ConnectedTo) {
+				if _, ok := status[peer]; ok && peer != s.Key {
+					out.Edges = append(out.Edges, models.TopologyEdge{From: s.Key, To: peer, Type: "connected_to"})
+				}
+			}
+			if impacted := impactedBy[s.Key]; len(impacted) > 0 {
+				out.RootCauses = append(out.RootCauses, models.TopologyRootCause{Key: s.Key, Impacted: impacted})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
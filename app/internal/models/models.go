@@ -17,25 +17,35 @@ type Service struct {
 
 // ServiceConfig represents a service stored in the database
 type ServiceConfig struct {
-	ID            int    `json:"id"`
-	Key           string `json:"key"`
-	Name          string `json:"name"`
-	URL           string `json:"url"`
-	ServiceType   string `json:"service_type"`   // plex, overseerr, jellyfin, sonarr, radarr, custom, etc.
-	Icon          string `json:"icon"`           // Icon name or custom icon path
-	IconURL       string `json:"icon_url"`       // Custom icon URL (overrides built-in icons)
-	APIToken      string `json:"api_token"`      // Optional API token for services that need it
-	DisplayOrder  int    `json:"display_order"`  // Order in the UI
-	Visible       bool   `json:"visible"`        // Whether to show in the UI
-	CheckType     string `json:"check_type"`     // http, tcp, ping
-	CheckInterval int    `json:"check_interval"` // Seconds between checks
-	Timeout       int    `json:"timeout"`        // Timeout in seconds
-	ExpectedMin   int    `json:"expected_min"`   // Min HTTP status code for OK
-	ExpectedMax   int    `json:"expected_max"`   // Max HTTP status code for OK
-	DependsOn     string `json:"depends_on"`     // Comma-separated keys of upstream dependencies
-	ConnectedTo   string `json:"connected_to"`   // Comma-separated keys of connected/integrated services
-	CreatedAt     string `json:"created_at"`
-	UpdatedAt     string `json:"updated_at"`
+	ID             int    `json:"id"`
+	Key            string `json:"key"`
+	Name           string `json:"name"`
+	URL            string `json:"url"`
+	ServiceType    string `json:"service_type"`    // plex, overseerr, jellyfin, sonarr, radarr, custom, etc.
+	Icon           string `json:"icon"`            // Icon name or custom icon path
+	IconURL        string `json:"icon_url"`        // Custom icon URL (overrides built-in icons)
+	APIToken       string `json:"api_token"`       // Optional API token for services that need it
+	DisplayOrder   int    `json:"display_order"`   // Order in the UI
+	Visible        bool   `json:"visible"`         // Whether to show in the UI
+	CheckType      string `json:"check_type"`      // http, tcp, dns, minecraft, a2s, always_up
+	CheckInterval  int    `json:"check_interval"`  // Seconds between checks
+	Timeout        int    `json:"timeout"`         // Timeout in seconds
+	ExpectedMin    int    `json:"expected_min"`    // Min HTTP status code for OK
+	ExpectedMax    int    `json:"expected_max"`    // Max HTTP status code for OK
+	DependsOn      string `json:"depends_on"`      // Comma-separated keys of upstream dependencies
+	ConnectedTo    string `json:"connected_to"`    // Comma-separated keys of connected/integrated services
+	DegradedPhase  string `json:"degraded_phase"`  // Latency used for degraded: "" (total), dns, connect, tls, ttfb, transfer
+	ContentCheck   bool   `json:"content_check"`   // Hash the HTTP response body and alert when it changes
+	ContentIgnore  string `json:"content_ignore"`  // Newline-separated regexes stripped from the body before hashing
+	AppHealth      bool   `json:"app_health"`      // Query the app's health endpoint; warnings mark the service degraded
+	NotifyChannels string `json:"notify_channels"` // Comma-separated notification channel IDs; empty = all enabled channels
+	NotifyEvents   string `json:"notify_events"`   // Comma-separated alert events (down, degraded, up, changed); empty = global default
+	Critical       bool   `json:"critical"`        // Alerts bypass quiet hours
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+
+	EntityChecks []EntityCheck      `json:"entity_checks"`     // Home Assistant entity rules (stored as JSON)
+	Metrics      map[string]float64 `json:"metrics,omitempty"` // Latest extra metrics (not stored on the service row)
 }
 
 // ServiceTemplate defines a preset for common services
@@ -50,19 +60,70 @@ type ServiceTemplate struct {
 	RequiresToken bool   `json:"requires_token"` // Whether API token is needed
 	TokenHeader   string `json:"token_header"`   // e.g., X-Plex-Token, X-Api-Key
 	HelpText      string `json:"help_text"`
+	AppHealth     bool   `json:"app_health"` // Supports the app health check mode
 }
 
 // LiveResult represents the current status of a service
 type LiveResult struct {
-	Label       string `json:"label"`
-	OK          bool   `json:"ok"`
-	Status      int    `json:"status"`
-	MS          *int   `json:"ms,omitempty"`
-	Disabled    bool   `json:"disabled"`
-	Degraded    bool   `json:"degraded"`
-	CheckType   string `json:"check_type,omitempty"`
-	DependsOn   string `json:"depends_on,omitempty"`   // Comma-separated upstream dependency keys
-	ConnectedTo string `json:"connected_to,omitempty"` // Comma-separated connected/integrated service keys
+	Label       string             `json:"label"`
+	OK          bool               `json:"ok"`
+	Status      int                `json:"status"`
+	MS          *int               `json:"ms,omitempty"`
+	Disabled    bool               `json:"disabled"`
+	Degraded    bool               `json:"degraded"`
+	Flapping    bool               `json:"flapping"`             // Status keeps changing; individual alerts are paused
+	Impacted    bool               `json:"impacted"`             // Down because an upstream dependency is down (OK stays false)
+	RootCause   string             `json:"root_cause,omitempty"` // Comma-separated keys of the failing upstream services behind an impacted status
+	CheckType   string             `json:"check_type,omitempty"`
+	DependsOn   string             `json:"depends_on,omitempty"`   // Comma-separated upstream dependency keys
+	ConnectedTo string             `json:"connected_to,omitempty"` // Comma-separated connected/integrated service keys
+	Timings     *HTTPTimings       `json:"timings,omitempty"`      // Per-phase HTTP timings (HTTP checks only)
+	Game        *GameInfo          `json:"game,omitempty"`         // Game server details (minecraft/a2s checks only)
+	Metrics     map[string]float64 `json:"metrics,omitempty"`      // Extra metrics reported by the check, e.g. players
+	Warnings    []string           `json:"warnings,omitempty"`     // App health warnings that caused a degraded state
+	Entities    []EntityResult     `json:"entities,omitempty"`     // Home Assistant entity rule results
+}
+
+// EntityCheck is a rule evaluated against a Home Assistant entity's state or attribute
+type EntityCheck struct {
+	EntityID  string `json:"entity_id"`           // e.g. sensor.ups_status
+	Attribute string `json:"attribute,omitempty"` // Attribute to compare instead of the state
+	Operator  string `json:"operator"`            // ==, != (text) or >, >=, <, <= (numeric)
+	Value     string `json:"value"`               // Expected value or threshold
+	Severity  string `json:"severity"`            // Status when the rule fails: "down" or "degraded"
+}
+
+// EntityResult is the outcome of one EntityCheck
+type EntityResult struct {
+	EntityID string `json:"entity_id"`
+	Value    string `json:"value"` // Actual state/attribute value ("" if unavailable)
+	OK       bool   `json:"ok"`
+	Severity string `json:"severity,omitempty"` // Set when the rule failed
+	Message  string `json:"message,omitempty"`
+}
+
+// GameInfo holds the details reported by a game server query
+type GameInfo struct {
+	Name       string `json:"name,omitempty"` // Server name or MOTD
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"max_players"`
+	Version    string `json:"version,omitempty"`
+	Map        string `json:"map,omitempty"`
+}
+
+// HTTPTimings breaks the latency of an HTTP check down by phase (milliseconds)
+type HTTPTimings struct {
+	DNSMs      int `json:"dns_ms"`      // Name resolution
+	ConnectMs  int `json:"connect_ms"`  // TCP connect
+	TLSMs      int `json:"tls_ms"`      // TLS handshake (0 for plain HTTP)
+	TTFBMs     int `json:"ttfb_ms"`     // Request written to first response byte (server processing)
+	TransferMs int `json:"transfer_ms"` // First response byte to end of body (0 when the body is not read)
+}
+
+// ContentChange records when a service's response body hash changed
+type ContentChange struct {
+	Hash   string `json:"hash"`
+	SeenAt string `json:"seen_at"`
 }
 
 // LivePayload represents a collection of service statuses
@@ -71,6 +132,34 @@ type LivePayload struct {
 	Status map[string]LiveResult `json:"status"`
 }
 
+// Topology is the service dependency graph with each service's last known status
+type Topology struct {
+	T          time.Time           `json:"t"`
+	Nodes      []TopologyNode      `json:"nodes"`
+	Edges      []TopologyEdge      `json:"edges"`
+	RootCauses []TopologyRootCause `json:"root_causes"`
+}
+
+// TopologyNode is a service in the topology
+type TopologyNode struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // up, down, impacted, disabled or unknown
+}
+
+// TopologyEdge is a relationship between two services
+type TopologyEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // depends_on (From depends on To) or connected_to
+}
+
+// TopologyRootCause is a failing service together with the downstream services it takes down
+type TopologyRootCause struct {
+	Key      string   `json:"key"`
+	Impacted []string `json:"impacted"`
+}
+
 // AlertConfig stores alert configuration (multi-channel)
 type AlertConfig struct {
 	Enabled         bool   `json:"enabled"`
@@ -86,7 +175,41 @@ type AlertConfig struct {
 	AlertOnDegraded bool   `json:"alert_on_degraded"`
 	AlertOnUp       bool   `json:"alert_on_up"`
 
-	// Multi-channel notification fields
+	// Ongoing outage policy: repeat the down alert every RepeatIntervalMinutes and, once a
+	// service has been down for EscalateAfterMinutes, also notify EscalationChannels (comma-separated IDs).
+	// Zero disables either step.
+	RepeatIntervalMinutes int    `json:"repeat_interval_minutes"`
+	EscalateAfterMinutes  int    `json:"escalate_after_minutes"`
+	EscalationChannels    string `json:"escalation_channels"`
+
+	// Down and recovery alerts raised within GroupWindowSeconds of each other are sent as one
+	// digest per channel. Zero sends every alert on its own.
+	GroupWindowSeconds int `json:"group_window_seconds"`
+
+	// A service with more than FlapThreshold status changes in FlapWindowMinutes is flapping:
+	// one alert is sent and its transitions are suppressed until it has been stable for
+	// FlapStableMinutes. Zero threshold disables detection.
+	FlapThreshold     int `json:"flap_threshold"`
+	FlapWindowMinutes int `json:"flap_window_minutes"`
+	FlapStableMinutes int `json:"flap_stable_minutes"`
+
+	// A latency anomaly is raised when a service's latency stays more than LatencySigma
+	// deviations above its baseline for LatencySustainMinutes. Zero sigma disables it.
+	LatencySigma          float64 `json:"latency_sigma"`
+	LatencySustainMinutes int     `json:"latency_sustain_minutes"`
+
+	// Inbound alert webhooks (Alertmanager, Grafana, generic JSON) authenticate with
+	// InboundToken; empty disables them. InboundLabels lists the alert labels checked, in
+	// order, for a service key or name (empty = service,job), and InboundBanners posts a
+	// status banner for each firing alert.
+	InboundToken   string `json:"inbound_token"`
+	InboundLabels  string `json:"inbound_labels"`
+	InboundBanners bool   `json:"inbound_banners"`
+
+	// Do-not-disturb schedule for every channel without one of its own
+	QuietHours QuietHours `json:"quiet_hours"`
+
+	// Legacy single-target channel fields; migrated into notification_channels at startup
 	DiscordWebhookURL string `json:"discord_webhook_url"`
 	DiscordEnabled    bool   `json:"discord_enabled"`
 	TelegramBotToken  string `json:"telegram_bot_token"`
@@ -97,6 +220,89 @@ type AlertConfig struct {
 	WebhookEnabled    bool   `json:"webhook_enabled"`
 }
 
+// QuietHours is a weekly do-not-disturb schedule for alerts
+type QuietHours struct {
+	Enabled  bool              `json:"enabled"`
+	Timezone string            `json:"timezone"` // IANA zone, e.g. Europe/London; empty = server local time
+	Days     string            `json:"days"`     // Comma-separated weekdays the window starts on (mon..sun); empty = every day
+	Start    string            `json:"start"`    // HH:MM
+	End      string            `json:"end"`      // HH:MM; earlier than Start for windows that cross midnight
+	Actions  map[string]string `json:"actions"`  // Alert event (down, degraded, up, changed) -> suppress, delay or deliver
+}
+
+// Notification channel types
+const (
+	ChannelEmail    = "email"
+	ChannelDiscord  = "discord"
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+	ChannelSlack    = "slack"
+	ChannelTeams    = "teams"
+	ChannelNtfy     = "ntfy"
+	ChannelGotify   = "gotify"
+	ChannelPushover = "pushover"
+	ChannelMatrix   = "matrix"
+)
+
+// NotificationChannel is a single notification target (one email server, Discord webhook, etc.)
+type NotificationChannel struct {
+	ID         int               `json:"id"`
+	Type       string            `json:"type"` // email, discord, telegram, webhook, slack, teams, ntfy, gotify, pushover, matrix
+	Name       string            `json:"name"`
+	Settings   map[string]string `json:"settings"` // Type-specific settings, stored encrypted
+	Enabled    bool              `json:"enabled"`
+	QuietHours QuietHours        `json:"quiet_hours"` // Overrides the global quiet hours when enabled
+	CreatedAt  string            `json:"created_at"`
+	UpdatedAt  string            `json:"updated_at"`
+}
+
+// Notification delivery states in the outbox
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// NotificationDelivery is one queued notification for one channel
+type NotificationDelivery struct {
+	ID            int64  `json:"id"`
+	ChannelID     int    `json:"channel_id"`
+	ChannelType   string `json:"channel_type"`
+	ChannelName   string `json:"channel_name"`
+	ServiceKey    string `json:"service_key"`
+	Subject       string `json:"subject"`
+	Payload       string `json:"-"`      // JSON-encoded alerts.Notification
+	Status        string `json:"status"` // pending, delivered, dead
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error"`
+	NextAttemptAt string `json:"next_attempt_at"`
+	CreatedAt     string `json:"created_at"`
+	DeliveredAt   string `json:"delivered_at"`
+}
+
+// NotificationTemplate overrides the subject and message of one alert event, either for
+// one channel type or, with an empty ChannelType, for every channel
+type NotificationTemplate struct {
+	Event       string `json:"event"`
+	ChannelType string `json:"channel_type"`
+	Subject     string `json:"subject"` // text/template source; empty keeps the default
+	Body        string `json:"body"`    // text/template source producing an HTML fragment; empty keeps the default
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// Incident is one outage of a service in the incident timeline, from going down until recovery
+type Incident struct {
+	ID             int64  `json:"id"`
+	ServiceKey     string `json:"service_key"`
+	ServiceName    string `json:"service_name"`
+	EventType      string `json:"event_type"`
+	StartedAt      string `json:"started_at"`
+	ResolvedAt     string `json:"resolved_at"` // Empty while ongoing
+	DurationS      int64  `json:"duration_s"`
+	AcknowledgedAt string `json:"acknowledged_at"`
+	AcknowledgedBy string `json:"acknowledged_by"`
+}
+
 // ResourcesUIConfig stores admin configuration for the Resources section/widgets
 type ResourcesUIConfig struct {
 	Enabled    bool   `json:"enabled"`
@@ -137,6 +343,49 @@ type StatusAlert struct {
 	CreatedAt  string `json:"created_at"`
 }
 
+// MaintenanceWindow silences a service's alerts between its start and end time, such as a
+// pause from the Telegram bot. An empty service key covers every service.
+type MaintenanceWindow struct {
+	ID         int64  `json:"id"`
+	ServiceKey string `json:"service_key"`
+	Title      string `json:"title"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// InboundAlert is an alert received from Alertmanager, Grafana or a generic webhook and
+// mapped to a service. While firing it takes the service down (critical) or degrades it (warning).
+type InboundAlert struct {
+	ID            int64  `json:"id"`
+	Source        string `json:"source"`      // alertmanager, grafana or generic
+	Fingerprint   string `json:"fingerprint"` // Identifies the alert across its firing and resolved notifications
+	ServiceKey    string `json:"service_key"`
+	Name          string `json:"name"`
+	Severity      string `json:"severity"` // critical or warning
+	Status        string `json:"status"`   // firing or resolved
+	Summary       string `json:"summary"`
+	StatusAlertID string `json:"status_alert_id,omitempty"` // Banner posted while the alert fires
+	StartedAt     string `json:"started_at"`
+	UpdatedAt     string `json:"updated_at"`
+	ResolvedAt    string `json:"resolved_at"` // Empty while firing
+}
+
+// ResourceAlertRule is a threshold on a Glances resource metric, such as
+// "fs_used_percent > 90 for 10m", or a check that a container is running
+type ResourceAlertRule struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	Metric     string  `json:"metric"`   // Snapshot metric, e.g. fs_used_percent, or "container"
+	Operator   string  `json:"operator"` // >, >=, <, <= (numeric metrics)
+	Threshold  float64 `json:"threshold"`
+	Hysteresis float64 `json:"hysteresis"`          // How far back past the threshold the value must go to clear
+	ForMinutes int     `json:"for_minutes"`         // How long the condition must hold before alerting
+	Container  string  `json:"container,omitempty"` // Container name (container rules)
+	Enabled    bool    `json:"enabled"`
+	CreatedAt  string  `json:"created_at"`
+}
+
 // AppSettings stores application configuration including auth credentials
 type AppSettings struct {
 	SetupComplete bool   `json:"setup_complete"`
@@ -49,3 +49,10 @@ func (t *FailureTracker) Prune(validKeys map[string]struct{}) {
 		}
 	}
 }
+
+// Failures returns the current consecutive failure count for a service.
+func (t *FailureTracker) Failures(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.counts[key]
+}
@@ -82,6 +82,23 @@ func TestReset_NonexistentKey(t *testing.T) {
 	ft.Reset("nonexistent") // should not panic
 }
 
+func TestFailures(t *testing.T) {
+	ft := NewFailureTracker()
+
+	if n := ft.Failures("svc1"); n != 0 {
+		t.Errorf("expected 0 for an unknown key, got %d", n)
+	}
+	ft.Update("svc1", false)
+	ft.Update("svc1", false)
+	if n := ft.Failures("svc1"); n != 2 {
+		t.Errorf("expected 2, got %d", n)
+	}
+	// Reading the count does not change it
+	if n := ft.Update("svc1", false); n != 3 {
+		t.Errorf("expected 3, got %d", n)
+	}
+}
+
 func TestPrune(t *testing.T) {
 	ft := NewFailureTracker()
 
@@ -1114,3 +1114,50 @@ func TestFetchSnapshot_EmptyContainers(t *testing.T) {
 		t.Errorf("container count should be nil for empty, got %v", *snap.ContainerCount)
 	}
 }
+
+// --------------- Alert rule helper tests ---------------
+
+func TestAPIURL(t *testing.T) {
+	cases := map[string]string{
+		"192.168.1.5:61208":      "http://192.168.1.5:61208/api/4",
+		"https://glances.lan/":   "https://glances.lan/api/4",
+		"http://localhost:61208": "http://localhost:61208/api/4",
+	}
+	for in, want := range cases {
+		if got := APIURL(in); got != want {
+			t.Errorf("APIURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSnapshot_MetricValue(t *testing.T) {
+	fs, temp := 93.5, 71.0
+	snap := Snapshot{FSUsedPercent: &fs, TempC: &temp}
+
+	if v, ok := snap.MetricValue("fs_used_percent"); !ok || v != 93.5 {
+		t.Errorf("fs_used_percent = %v (ok=%v), want 93.5", v, ok)
+	}
+	if v, ok := snap.MetricValue("temp_c"); !ok || v != 71 {
+		t.Errorf("temp_c = %v (ok=%v), want 71", v, ok)
+	}
+	if _, ok := snap.MetricValue("mem_percent"); ok {
+		t.Error("unreported metric should not be ok")
+	}
+	if _, ok := snap.MetricValue("bogus"); ok {
+		t.Error("unknown metric should not be ok")
+	}
+}
+
+func TestSnapshot_ContainerStatus(t *testing.T) {
+	snap := Snapshot{Containers: []ContainerInfo{{Name: "Plex", Status: "Running"}, {Name: "sonarr", Status: "exited"}}}
+
+	if got := snap.ContainerStatus("plex"); got != "running" {
+		t.Errorf("expected running, got %q", got)
+	}
+	if got := snap.ContainerStatus("sonarr"); got != "exited" {
+		t.Errorf("expected exited, got %q", got)
+	}
+	if got := snap.ContainerStatus("radarr"); got != "" {
+		t.Errorf("expected missing container to be empty, got %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package resources
+
+import "strings"
+
+// Metrics lists the numeric snapshot values resource alert rules can test, by JSON name
+var Metrics = []string{
+	"cpu_percent", "load_1", "load_5", "load_15", "mem_percent", "swap_percent",
+	"temp_c", "fs_used_percent", "gpu_percent", "gpu_mem_percent", "gpu_temp_c",
+}
+
+// MetricContainer is the pseudo-metric of rules that check a container is running
+const MetricContainer = "container"
+
+// APIURL builds the Glances v4 API base URL from a configured host:port or URL
+func APIURL(glancesURL string) string {
+	if !strings.HasPrefix(glancesURL, "http://") && !strings.HasPrefix(glancesURL, "https://") {
+		glancesURL = "http://" + glancesURL
+	}
+	return strings.TrimSuffix(glancesURL, "/") + "/api/4"
+}
+
+// MetricValue returns a numeric snapshot value by its JSON name. ok is false when the
+// metric is unknown or Glances did not report it.
+func (s Snapshot) MetricValue(metric string) (value float64, ok bool) {
+	var v *float64
+	switch metric {
+	case "cpu_percent":
+		v = s.CPUPercent
+	case "load_1":
+		v = s.Load1
+	case "load_5":
+		v = s.Load5
+	case "load_15":
+		v = s.Load15
+	case "mem_percent":
+		v = s.MemPercent
+	case "swap_percent":
+		v = s.SwapPercent
+	case "temp_c":
+		v = s.TempC
+	case "fs_used_percent":
+		v = s.FSUsedPercent
+	case "gpu_percent":
+		v = s.GPUPercent
+	case "gpu_mem_percent":
+		v = s.GPUMemPct
+	case "gpu_temp_c":
+		v = s.GPUTempC
+	}
+	if v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+// ContainerStatus returns the status of the named container (case-insensitive), or ""
+// when Glances does not list it
+func (s Snapshot) ContainerStatus(name string) string {
+	for _, c := range s.Containers {
+		if strings.EqualFold(c.Name, name) {
+			return strings.ToLower(c.Status)
+		}
+	}
+	return ""
+}
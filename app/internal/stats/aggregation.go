@@ -70,7 +70,18 @@ CREATE INDEX IF NOT EXISTS idx_heartbeats_key ON heartbeats(service_key);
 CREATE INDEX IF NOT EXISTS idx_heartbeats_time ON heartbeats(time);
 CREATE INDEX IF NOT EXISTS idx_heartbeats_important ON heartbeats(important);
 `)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// HTTP phase timings (added later; ignore "duplicate column" on existing installs)
+	_, _ = database.DB.Exec(`ALTER TABLE heartbeats ADD COLUMN dns_ms INTEGER;`)
+	_, _ = database.DB.Exec(`ALTER TABLE heartbeats ADD COLUMN connect_ms INTEGER;`)
+	_, _ = database.DB.Exec(`ALTER TABLE heartbeats ADD COLUMN tls_ms INTEGER;`)
+	_, _ = database.DB.Exec(`ALTER TABLE heartbeats ADD COLUMN ttfb_ms INTEGER;`)
+	_, _ = database.DB.Exec(`ALTER TABLE heartbeats ADD COLUMN transfer_ms INTEGER;`)
+
+	return nil
 }
 
 // aggregatedRow holds one aggregation result for batch processing.
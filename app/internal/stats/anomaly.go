@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"math"
+	"slices"
+	"status/app/internal/database"
+	"time"
+)
+
+// minBaselineSamples is how many per-minute (or per-hour) pings a baseline needs to be trusted
+const minBaselineSamples = 30
+
+// madScale turns a median absolute deviation into an estimate of the standard deviation
+const madScale = 1.4826
+
+// Baseline is a service's normal latency: the median of its recent per-minute average pings
+// and a robust spread (the scaled median absolute deviation), so past outages and slow
+// spikes do not skew it.
+type Baseline struct {
+	Median  float64 // Typical latency in ms
+	Sigma   float64 // Spread in ms, never below 5% of the median or 1ms
+	Samples int     // Pings the baseline was computed from
+}
+
+// Deviation returns how many sigmas ms is above the baseline
+func (b Baseline) Deviation(ms float64) float64 {
+	return (ms - b.Median) / b.Sigma
+}
+
+// LatencyBaseline computes a service's latency baseline from the minutely stats of the
+// 24 hours before until, falling back to the hourly stats of the week before when the
+// service has too little recent history. ok is false when neither has enough samples.
+func LatencyBaseline(serviceKey string, until time.Time) (b Baseline, ok bool) {
+	pings := statPings("stat_minutely", serviceKey, until.Add(-24*time.Hour), until)
+	if len(pings) < minBaselineSamples {
+		pings = statPings("stat_hourly", serviceKey, until.Add(-7*24*time.Hour), until)
+	}
+	if len(pings) < minBaselineSamples {
+		return Baseline{}, false
+	}
+
+	median := medianOf(pings)
+	deviations := make([]float64, len(pings))
+	for i, p := range pings {
+		deviations[i] = math.Abs(p - median)
+	}
+	sigma := max(medianOf(deviations)*madScale, median*0.05, 1)
+	return Baseline{Median: median, Sigma: sigma, Samples: len(pings)}, true
+}
+
+// statPings returns the average pings recorded in a stats table between from and until
+func statPings(table, serviceKey string, from, until time.Time) []float64 {
+	// #nosec G202 -- table is one of the fixed stats table names
+	rows, err := database.DB.Query(`SELECT ping FROM `+table+`
+		WHERE service_key = ? AND timestamp >= ? AND timestamp < ? AND ping IS NOT NULL AND up > 0`,
+		serviceKey, from.Unix(), until.Unix())
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var pings []float64
+	for rows.Next() {
+		var p float64
+		if rows.Scan(&p) == nil {
+			pings = append(pings, p)
+		}
+	}
+	return pings
+}
+
+// medianOf returns the median of values, sorting them in place
+func medianOf(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
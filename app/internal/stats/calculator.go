@@ -14,6 +14,7 @@ type UptimeCalculator struct {
 
 	// Recent heartbeats (last 100)
 	recentHeartbeats []Heartbeat
+	flapping         bool // See FlapPolicy
 	mu               sync.RWMutex
 }
 
@@ -48,21 +49,24 @@ func RemoveCalculator(serviceKey string) {
 
 // AddHeartbeat records a new heartbeat and returns whether it represents a status change.
 func (c *UptimeCalculator) AddHeartbeat(status int, ping *int, httpStatus int, msg string) bool {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	hb := Heartbeat{
+	return c.addHeartbeat(Heartbeat{
 		Status:     status,
 		Time:       time.Now().UTC(),
 		Ping:       ping,
 		HTTPStatus: httpStatus,
 		Msg:        msg,
-	}
+	})
+}
+
+// addHeartbeat appends a fully populated heartbeat and returns whether it represents a status change.
+func (c *UptimeCalculator) addHeartbeat(hb Heartbeat) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	// Check if this is a status change (important event)
 	if len(c.recentHeartbeats) > 0 {
 		lastStatus := c.recentHeartbeats[len(c.recentHeartbeats)-1].Status
-		hb.Important = lastStatus != status
+		hb.Important = lastStatus != hb.Status
 	} else {
 		hb.Important = true // First heartbeat is always important
 	}
@@ -72,6 +76,7 @@ func (c *UptimeCalculator) AddHeartbeat(status int, ping *int, httpStatus int, m
 	if len(c.recentHeartbeats) > 100 {
 		c.recentHeartbeats = c.recentHeartbeats[1:]
 	}
+	c.updateFlapping(hb.Time)
 
 	// Invalidate cache
 	cache.StatsCache.Delete("uptime:" + c.ServiceKey)
@@ -157,6 +162,27 @@ func (c *UptimeCalculator) GetAverageLatency(duration time.Duration) float64 {
 	return avgMs.Float64
 }
 
+// LastHeartbeat returns a service's most recent stored heartbeat; ok is false when it has none
+func LastHeartbeat(serviceKey string) (hb Heartbeat, ok bool) {
+	var ts string
+	var msg sql.NullString
+	var ping, httpStatus sql.NullInt64
+	err := database.DB.QueryRow(`SELECT status, time, msg, ping, http_status FROM heartbeats
+		WHERE service_key = ? ORDER BY time DESC, id DESC LIMIT 1`, serviceKey).
+		Scan(&hb.Status, &ts, &msg, &ping, &httpStatus)
+	if err != nil {
+		return Heartbeat{}, false
+	}
+	hb.Time, _ = time.Parse(time.RFC3339, ts)
+	hb.Msg = msg.String
+	hb.HTTPStatus = int(httpStatus.Int64)
+	if ping.Valid {
+		p := int(ping.Int64)
+		hb.Ping = &p
+	}
+	return hb, true
+}
+
 // GetUptimeStats returns computed uptime statistics for a service
 func GetUptimeStats(serviceKey string) *UptimeStats {
 	cacheKey := "uptime_stats:" + serviceKey
@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+// FlapPolicy defines when a service is flapping: more than Threshold status changes within
+// Window. It stays flapping until its status has not changed for Stable. Detection only sees
+// the heartbeats kept in memory, so Window should fit within the last 100 checks.
+type FlapPolicy struct {
+	Threshold int
+	Window    time.Duration
+	Stable    time.Duration
+}
+
+var (
+	flapPolicy   FlapPolicy
+	flapPolicyMu sync.RWMutex
+)
+
+// SetFlapPolicy replaces the flapping policy; a zero Threshold disables detection
+func SetFlapPolicy(p FlapPolicy) {
+	flapPolicyMu.Lock()
+	defer flapPolicyMu.Unlock()
+	flapPolicy = p
+}
+
+// GetFlapPolicy returns the current flapping policy
+func GetFlapPolicy() FlapPolicy {
+	flapPolicyMu.RLock()
+	defer flapPolicyMu.RUnlock()
+	return flapPolicy
+}
+
+// transitionsSince counts the status changes among the recent heartbeats at or after since.
+// The caller must hold c.mu.
+func (c *UptimeCalculator) transitionsSince(since time.Time) int {
+	n := 0
+	for i := 1; i < len(c.recentHeartbeats); i++ {
+		hb := c.recentHeartbeats[i]
+		if !hb.Time.Before(since) && hb.Status != c.recentHeartbeats[i-1].Status {
+			n++
+		}
+	}
+	return n
+}
+
+// lastTransition returns when the status last changed, or the zero time. The caller must hold c.mu.
+func (c *UptimeCalculator) lastTransition() time.Time {
+	for i := len(c.recentHeartbeats) - 1; i > 0; i-- {
+		if c.recentHeartbeats[i].Status != c.recentHeartbeats[i-1].Status {
+			return c.recentHeartbeats[i].Time
+		}
+	}
+	return time.Time{}
+}
+
+// updateFlapping re-evaluates the flapping state after a heartbeat at now. The caller must hold c.mu.
+func (c *UptimeCalculator) updateFlapping(now time.Time) {
+	p := GetFlapPolicy()
+	switch {
+	case p.Threshold <= 0:
+		c.flapping = false
+	case c.flapping:
+		c.flapping = now.Sub(c.lastTransition()) < p.Stable
+	default:
+		c.flapping = c.transitionsSince(now.Add(-p.Window)) > p.Threshold
+	}
+}
+
+// Flapping reports whether the service is flapping. A flapping service that has since been
+// stable long enough reads as settled even before its next heartbeat.
+func (c *UptimeCalculator) Flapping() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p := GetFlapPolicy()
+	if !c.flapping || p.Threshold <= 0 {
+		return false
+	}
+	return time.Since(c.lastTransition()) < p.Stable
+}
+
+// TransitionsSince returns how many times the status changed at or after since
+func (c *UptimeCalculator) TransitionsSince(since time.Time) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.transitionsSince(since)
+}
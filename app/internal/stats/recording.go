@@ -4,11 +4,17 @@ import (
 	"log"
 	"status/app/internal/checker"
 	"status/app/internal/database"
+	"status/app/internal/models"
 	"time"
 )
 
 // RecordHeartbeat stores a heartbeat and updates statistics
 func RecordHeartbeat(serviceKey string, ok bool, ping *int, httpStatus int, errMsg string) {
+	RecordHeartbeatWithTimings(serviceKey, ok, ping, httpStatus, errMsg, nil)
+}
+
+// RecordHeartbeatWithTimings stores a heartbeat along with its HTTP phase timings (may be nil)
+func RecordHeartbeatWithTimings(serviceKey string, ok bool, ping *int, httpStatus int, errMsg string, timings *models.HTTPTimings) {
 	calc := GetCalculator(serviceKey)
 
 	// Sanitize error message before storing — prevents leaking URLs/tokens
@@ -19,7 +25,14 @@ func RecordHeartbeat(serviceKey string, ok bool, ping *int, httpStatus int, errM
 		status = 1
 	}
 
-	important := calc.AddHeartbeat(status, ping, httpStatus, safeMsg)
+	important := calc.addHeartbeat(Heartbeat{
+		Status:     status,
+		Time:       time.Now().UTC(),
+		Ping:       ping,
+		HTTPStatus: httpStatus,
+		Msg:        safeMsg,
+		Timings:    timings,
+	})
 
 	// Store in heartbeats table
 	importantInt := 0
@@ -27,10 +40,18 @@ func RecordHeartbeat(serviceKey string, ok bool, ping *int, httpStatus int, errM
 		importantInt = 1
 	}
 
+	var dnsMs, connectMs, tlsMs, ttfbMs, transferMs any
+	if timings != nil {
+		dnsMs, connectMs, tlsMs = timings.DNSMs, timings.ConnectMs, timings.TLSMs
+		ttfbMs, transferMs = timings.TTFBMs, timings.TransferMs
+	}
+
 	_, err := database.DB.Exec(`
-		INSERT INTO heartbeats (service_key, status, time, msg, ping, http_status, important)
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		serviceKey, status, time.Now().UTC().Format(time.RFC3339), safeMsg, ping, httpStatus, importantInt)
+		INSERT INTO heartbeats (service_key, status, time, msg, ping, http_status, important,
+		                        dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		serviceKey, status, time.Now().UTC().Format(time.RFC3339), safeMsg, ping, httpStatus, importantInt,
+		dnsMs, connectMs, tlsMs, ttfbMs, transferMs)
 
 	if err != nil {
 		log.Printf("Error recording heartbeat: %v", err)
@@ -1,8 +1,10 @@
 package stats
 
 import (
+	"database/sql"
 	"status/app/internal/cache"
 	"status/app/internal/database"
+	"status/app/internal/models"
 	"testing"
 	"time"
 )
@@ -209,6 +211,67 @@ func TestGetRecentHeartbeats_MoreThanAvailable(t *testing.T) {
 	}
 }
 
+// --------------- Flapping ---------------
+
+// addStatuses records heartbeats with the given statuses one minute apart, ending at end.
+func addStatuses(calc *UptimeCalculator, end time.Time, statuses ...int) {
+	for i, st := range statuses {
+		calc.addHeartbeat(Heartbeat{Status: st, Time: end.Add(time.Duration(i-len(statuses)+1) * time.Minute)})
+	}
+}
+
+func TestFlapping_DetectsAndSettles(t *testing.T) {
+	initTestDB(t)
+	SetFlapPolicy(FlapPolicy{Threshold: 4, Window: 10 * time.Minute, Stable: 15 * time.Minute})
+	defer SetFlapPolicy(FlapPolicy{})
+
+	now := time.Now().UTC()
+	calc := GetCalculator("svc-flap")
+	addStatuses(calc, now, 1, 0, 1, 0, 1)
+	if calc.Flapping() {
+		t.Fatal("4 changes should not exceed a threshold of 4")
+	}
+	addStatuses(calc, now.Add(time.Minute), 0)
+	if !calc.Flapping() {
+		t.Fatal("5 changes within 10 minutes should be flapping")
+	}
+	if got := calc.TransitionsSince(now.Add(-10 * time.Minute)); got != 5 {
+		t.Errorf("transitions = %d, want 5", got)
+	}
+
+	// Stays flapping while stable for less than 15 minutes, even once the window has passed
+	calc.addHeartbeat(Heartbeat{Status: 0, Time: now.Add(12 * time.Minute)})
+	if !calc.flapping {
+		t.Error("should stay flapping until stable for the full period")
+	}
+	calc.addHeartbeat(Heartbeat{Status: 0, Time: now.Add(17 * time.Minute)})
+	if calc.flapping {
+		t.Error("should settle after 15 minutes without a change")
+	}
+}
+
+func TestFlapping_SettlesWithoutHeartbeat(t *testing.T) {
+	initTestDB(t)
+	SetFlapPolicy(FlapPolicy{Threshold: 2, Window: 10 * time.Minute, Stable: 5 * time.Minute})
+	defer SetFlapPolicy(FlapPolicy{})
+
+	calc := GetCalculator("svc-flap-old")
+	addStatuses(calc, time.Now().UTC().Add(-6*time.Minute), 1, 0, 1, 0)
+	if !calc.flapping {
+		t.Fatal("expected flapping after the last heartbeat")
+	}
+	if calc.Flapping() {
+		t.Error("a service stable for longer than the stable period reads as settled")
+	}
+
+	SetFlapPolicy(FlapPolicy{})
+	calc2 := GetCalculator("svc-flap-off")
+	addStatuses(calc2, time.Now().UTC(), 1, 0, 1, 0, 1, 0)
+	if calc2.Flapping() {
+		t.Error("detection is disabled without a threshold")
+	}
+}
+
 // --------------- GetUptime ---------------
 
 func TestGetUptime_NoData(t *testing.T) {
@@ -306,6 +369,70 @@ func TestGetAverageLatency_WithData(t *testing.T) {
 	}
 }
 
+// --------------- LatencyBaseline ---------------
+
+// insertPings records one stats row per ping, step apart and ending before until
+func insertPings(t *testing.T, table, key string, until time.Time, step time.Duration, pings ...float64) {
+	t.Helper()
+	for i, p := range pings {
+		ts := until.Add(-time.Duration(len(pings)-i) * step).Unix()
+		if _, err := database.DB.Exec(`INSERT INTO `+table+` (service_key, timestamp, up, down, ping) VALUES (?, ?, 1, 0, ?)`, key, ts, p); err != nil {
+			t.Fatalf("insert %s: %v", table, err)
+		}
+	}
+}
+
+func TestLatencyBaseline_MedianAndMAD(t *testing.T) {
+	initTestDB(t)
+	now := time.Now().UTC()
+
+	// Steady 14-16ms with two slow spikes that must not skew the baseline
+	var pings []float64
+	for i := 0; i < 40; i++ {
+		pings = append(pings, float64(14+i%3))
+	}
+	pings[10], pings[20] = 900, 1200
+	insertPings(t, "stat_minutely", "svc", now, time.Minute, pings...)
+
+	b, ok := LatencyBaseline("svc", now)
+	if !ok {
+		t.Fatal("expected a baseline")
+	}
+	if b.Median != 15 || b.Samples != 40 {
+		t.Errorf("expected median 15 from 40 samples, got %v from %d", b.Median, b.Samples)
+	}
+	if b.Sigma < 1 || b.Sigma > 2 {
+		t.Errorf("expected sigma around 1.5ms, got %v", b.Sigma)
+	}
+	if d := b.Deviation(150); d < 50 {
+		t.Errorf("expected 150ms to be far above baseline, got %.1f sigma", d)
+	}
+}
+
+func TestLatencyBaseline_FallsBackToHourly(t *testing.T) {
+	initTestDB(t)
+	now := time.Now().UTC()
+
+	if _, ok := LatencyBaseline("svc", now); ok {
+		t.Error("expected no baseline without history")
+	}
+
+	insertPings(t, "stat_minutely", "svc", now, time.Minute, 40, 40, 40)
+	hourly := make([]float64, 48)
+	for i := range hourly {
+		hourly[i] = 20
+	}
+	insertPings(t, "stat_hourly", "svc", now, time.Hour, hourly...)
+
+	b, ok := LatencyBaseline("svc", now)
+	if !ok || b.Median != 20 || b.Samples != 48 {
+		t.Errorf("expected the hourly baseline of 20ms, got %+v (ok=%v)", b, ok)
+	}
+	if b.Sigma != 1 {
+		t.Errorf("expected the 1ms sigma floor, got %v", b.Sigma)
+	}
+}
+
 // --------------- GetUptimeStats ---------------
 
 func TestGetUptimeStats(t *testing.T) {
@@ -382,6 +509,38 @@ func TestRecordHeartbeat_DownUpdatesMinutely(t *testing.T) {
 	}
 }
 
+func TestRecordHeartbeatWithTimings(t *testing.T) {
+	initTestDB(t)
+	ping := 120
+	timings := &models.HTTPTimings{DNSMs: 3, ConnectMs: 4, TLSMs: 10, TTFBMs: 95, TransferMs: 8}
+	RecordHeartbeatWithTimings("svc-timed", true, &ping, 200, "", timings)
+
+	var dns, ttfb int
+	err := database.DB.QueryRow(`SELECT dns_ms, ttfb_ms FROM heartbeats WHERE service_key = 'svc-timed'`).Scan(&dns, &ttfb)
+	if err != nil {
+		t.Fatalf("query error: %v", err)
+	}
+	if dns != 3 || ttfb != 95 {
+		t.Errorf("dns_ms=%d, ttfb_ms=%d, want 3, 95", dns, ttfb)
+	}
+
+	hbs := GetCalculator("svc-timed").GetRecentHeartbeats(1)
+	if len(hbs) != 1 || hbs[0].Timings == nil || hbs[0].Timings.TTFBMs != 95 {
+		t.Errorf("expected timings on in-memory heartbeat, got %+v", hbs)
+	}
+}
+
+func TestRecordHeartbeat_NoTimingsStoresNull(t *testing.T) {
+	initTestDB(t)
+	RecordHeartbeat("svc-untimed", true, nil, 0, "")
+
+	var ttfb sql.NullInt64
+	database.DB.QueryRow(`SELECT ttfb_ms FROM heartbeats WHERE service_key = 'svc-untimed'`).Scan(&ttfb)
+	if ttfb.Valid {
+		t.Errorf("expected NULL ttfb_ms without timings, got %d", ttfb.Int64)
+	}
+}
+
 // --------------- AggregateHourlyStats ---------------
 
 func TestAggregateHourlyStats(t *testing.T) {
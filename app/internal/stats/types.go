@@ -1,6 +1,9 @@
 package stats
 
-import "time"
+import (
+	"status/app/internal/models"
+	"time"
+)
 
 // Heartbeat represents a single health check result
 type Heartbeat struct {
@@ -12,6 +15,8 @@ type Heartbeat struct {
 	Important  bool      `json:"important"` // Status change events
 	Duration   int       `json:"duration"`  // Duration of this status in seconds
 	HTTPStatus int       `json:"http_status"`
+
+	Timings *models.HTTPTimings `json:"timings,omitempty"` // Per-phase HTTP timings, if captured
 }
 
 // StatEntry represents aggregated statistics for a time period
@@ -0,0 +1,163 @@
+package topology
+
+import (
+	"fmt"
+	"slices"
+	"status/app/internal/models"
+	"strings"
+)
+
+// Graph is the depends_on graph between services. Edges point from a service to the
+// upstream services it depends on; references to unknown services are ignored.
+type Graph struct {
+	keys []string            // Service keys in display order
+	deps map[string][]string // Service key -> direct upstream keys
+}
+
+// SplitKeys parses a comma-separated list of service keys, dropping blanks and duplicates
+func SplitKeys(s string) []string {
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		k = strings.TrimSpace(k)
+		if k != "" && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// New builds the dependency graph of the given services
+func New(services []models.ServiceConfig) *Graph {
+	g := &Graph{deps: make(map[string][]string, len(services))}
+	for _, s := range services {
+		g.keys = append(g.keys, s.Key)
+		g.deps[s.Key] = nil
+	}
+	for _, s := range services {
+		for _, dep := range SplitKeys(s.DependsOn) {
+			if _, ok := g.deps[dep]; ok {
+				g.deps[s.Key] = append(g.deps[s.Key], dep)
+			}
+		}
+	}
+	return g
+}
+
+// Keys returns the services in the graph
+func (g *Graph) Keys() []string {
+	return g.keys
+}
+
+// DependsOn returns the direct upstream dependencies of a service
+func (g *Graph) DependsOn(key string) []string {
+	return g.deps[key]
+}
+
+// Upstream returns every service a service depends on, directly or transitively, nearest first
+func (g *Graph) Upstream(key string) []string {
+	return g.walk(key, func(k string) []string { return g.deps[k] })
+}
+
+// Downstream returns every service that depends on a service, directly or transitively, nearest first
+func (g *Graph) Downstream(key string) []string {
+	return g.walk(key, func(k string) []string {
+		var dependents []string
+		for _, other := range g.keys {
+			if slices.Contains(g.deps[other], k) {
+				dependents = append(dependents, other)
+			}
+		}
+		return dependents
+	})
+}
+
+// walk visits the services reachable from key breadth-first. It stops at services already
+// seen, so it terminates even on a graph saved before cycles were rejected.
+func (g *Graph) walk(key string, next func(string) []string) []string {
+	var out []string
+	seen := map[string]bool{key: true}
+	queue := []string{key}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for _, n := range next(k) {
+			if !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+				queue = append(queue, n)
+			}
+		}
+	}
+	return out
+}
+
+// RootCauses returns the failing upstream services of key that are not explained by a
+// failure further upstream. down reports whether a service is currently failing.
+func (g *Graph) RootCauses(key string, down func(string) bool) []string {
+	var roots []string
+	for _, up := range g.Upstream(key) {
+		if down(up) && !slices.ContainsFunc(g.Upstream(up), down) {
+			roots = append(roots, up)
+		}
+	}
+	return roots
+}
+
+// CycleThrough returns a dependency cycle through key as a path that starts and ends on it,
+// or nil when key is not on a cycle. Cycles elsewhere in the graph are ignored.
+func (g *Graph) CycleThrough(key string) []string {
+	seen := map[string]bool{key: true}
+	var path []string
+	var visit func(string) []string
+	visit = func(k string) []string {
+		path = append(path, k)
+		for _, dep := range g.deps[k] {
+			if dep == key {
+				return append(slices.Clone(path), key)
+			}
+			if !seen[dep] {
+				seen[dep] = true
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+	return visit(key)
+}
+
+// Validate checks a service's dependencies against the other services: every key must
+// exist, a service cannot depend on itself, and s must not end up on a cycle. A cycle saved
+// earlier between other services does not block the save, so it can still be fixed.
+// It normalises s.DependsOn.
+func Validate(services []models.ServiceConfig, s *models.ServiceConfig) error {
+	deps := SplitKeys(s.DependsOn)
+	s.DependsOn = strings.Join(deps, ",")
+
+	known := make(map[string]bool, len(services))
+	for _, other := range services {
+		known[other.Key] = true
+	}
+	for _, dep := range deps {
+		if dep == s.Key {
+			return fmt.Errorf("a service cannot depend on itself")
+		}
+		if !known[dep] {
+			return fmt.Errorf("unknown dependency %q", dep)
+		}
+	}
+
+	// Check the graph as it will be once s is saved
+	next := []models.ServiceConfig{*s}
+	for _, other := range services {
+		if other.Key != s.Key {
+			next = append(next, other)
+		}
+	}
+	if cycle := New(next).CycleThrough(s.Key); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package topology
+
+import (
+	"reflect"
+	"status/app/internal/models"
+	"strings"
+	"testing"
+)
+
+// homelab is router <- nas <- plex, with sonarr depending on both nas and router
+func homelab() []models.ServiceConfig {
+	return []models.ServiceConfig{
+		{Key: "router"},
+		{Key: "nas", DependsOn: "router"},
+		{Key: "plex", DependsOn: "nas"},
+		{Key: "sonarr", DependsOn: "nas, router,"},
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	got := SplitKeys(" nas, router,,nas ")
+	if !reflect.DeepEqual(got, []string{"nas", "router"}) {
+		t.Errorf("expected [nas router], got %v", got)
+	}
+	if SplitKeys("") != nil {
+		t.Error("expected no keys for an empty list")
+	}
+}
+
+func TestUpstreamAndDownstream(t *testing.T) {
+	g := New(homelab())
+
+	if got := g.Upstream("plex"); !reflect.DeepEqual(got, []string{"nas", "router"}) {
+		t.Errorf("expected plex upstream [nas router], got %v", got)
+	}
+	if got := g.Downstream("router"); !reflect.DeepEqual(got, []string{"nas", "sonarr", "plex"}) {
+		t.Errorf("expected router downstream [nas sonarr plex], got %v", got)
+	}
+	if got := g.Upstream("router"); got != nil {
+		t.Errorf("expected router to have no upstream, got %v", got)
+	}
+}
+
+func TestNew_IgnoresUnknownKeys(t *testing.T) {
+	g := New([]models.ServiceConfig{{Key: "plex", DependsOn: "deleted,nas"}, {Key: "nas"}})
+	if got := g.DependsOn("plex"); !reflect.DeepEqual(got, []string{"nas"}) {
+		t.Errorf("expected [nas], got %v", got)
+	}
+}
+
+func TestRootCauses(t *testing.T) {
+	g := New(homelab())
+	down := map[string]bool{"router": true, "nas": true, "plex": true}
+	isDown := func(k string) bool { return down[k] }
+
+	if got := g.RootCauses("plex", isDown); !reflect.DeepEqual(got, []string{"router"}) {
+		t.Errorf("expected router as plex's root cause, got %v", got)
+	}
+
+	// With the router back up, the NAS is the root cause
+	down["router"] = false
+	if got := g.RootCauses("plex", isDown); !reflect.DeepEqual(got, []string{"nas"}) {
+		t.Errorf("expected nas as plex's root cause, got %v", got)
+	}
+	if got := g.RootCauses("nas", isDown); got != nil {
+		t.Errorf("expected nas to have no root cause, got %v", got)
+	}
+}
+
+func TestCycleThrough(t *testing.T) {
+	if cycle := New(homelab()).CycleThrough("plex"); cycle != nil {
+		t.Errorf("expected no cycle, got %v", cycle)
+	}
+
+	services := append(homelab(), models.ServiceConfig{Key: "dns", DependsOn: "plex"})
+	services[0].DependsOn = "dns"
+	if got := New(services).CycleThrough("nas"); !reflect.DeepEqual(got, []string{"nas", "router", "dns", "plex", "nas"}) {
+		t.Errorf("expected the closed cycle through nas, got %v", got)
+	}
+	if cycle := New(services).CycleThrough("sonarr"); cycle != nil {
+		t.Errorf("sonarr depends on the cycle but is not on it, got %v", cycle)
+	}
+
+	// Traversal still terminates on a cyclic graph
+	if got := New(services).Upstream("plex"); !reflect.DeepEqual(got, []string{"nas", "router", "dns"}) {
+		t.Errorf("expected [nas router dns], got %v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	services := homelab()
+
+	s := models.ServiceConfig{Key: "radarr", DependsOn: " nas ,nas"}
+	if err := Validate(services, &s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.DependsOn != "nas" {
+		t.Errorf("expected normalised depends_on, got %q", s.DependsOn)
+	}
+
+	s = models.ServiceConfig{Key: "radarr", DependsOn: "missing"}
+	if err := Validate(services, &s); err == nil || !strings.Contains(err.Error(), "unknown dependency") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+
+	s = models.ServiceConfig{Key: "nas", DependsOn: "nas"}
+	if err := Validate(services, &s); err == nil || !strings.Contains(err.Error(), "itself") {
+		t.Errorf("expected self-dependency error, got %v", err)
+	}
+
+	// Making the router depend on plex closes router <- nas <- plex
+	s = models.ServiceConfig{Key: "router", DependsOn: "plex"}
+	err := Validate(services, &s)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Fatalf("expected cycle error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "router → plex → nas → router") {
+		t.Errorf("expected the cycle path in the error, got %v", err)
+	}
+
+	// A cycle saved earlier between other services does not block unrelated edits
+	cyclic := append(homelab(), models.ServiceConfig{Key: "dns", DependsOn: "vpn"}, models.ServiceConfig{Key: "vpn", DependsOn: "dns"})
+	s = models.ServiceConfig{Key: "plex", DependsOn: "router"}
+	if err := Validate(cyclic, &s); err != nil {
+		t.Errorf("unrelated edit rejected: %v", err)
+	}
+	s = models.ServiceConfig{Key: "dns", DependsOn: "router"}
+	if err := Validate(cyclic, &s); err != nil {
+		t.Errorf("breaking the existing cycle should be allowed: %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -48,8 +49,19 @@ func main() {
 	// Check if setup is complete and load auth accordingly
 	authMgr := createAuthManager(cfg)
 
+	// Move legacy single-target alert channels into notification_channels (runs once)
+	if n, err := database.MigrateAlertConfigChannels(); err != nil {
+		log.Printf("Warning: Failed to migrate notification channels: %v", err)
+	} else if n > 0 {
+		log.Printf("Migrated %d notification channel(s) from alert settings", n)
+	}
+
 	// Create alert manager (loads config from database)
 	alertMgr := alerts.NewManager(cfg.StatusPageURL)
+	alertMgr.SetTokenSigner(authMgr) // Signs the acknowledgement links in alerts
+	alertMgr.StartDeliveryWorker()
+	alertMgr.StartResourceAlerts(30 * time.Second) // Glances threshold rules
+	alertMgr.StartTelegramBot()                    // Commands in Telegram chats with commands enabled
 
 	// Migrate services from environment config if needed
 	migrateServicesFromEnv(cfg)
@@ -312,34 +324,35 @@ func runScheduler(alertMgr *alerts.Manager, defaultInterval time.Duration, track
 				continue
 			}
 
-			timeout := time.Duration(sc.Timeout) * time.Second
-			if timeout == 0 {
-				timeout = 5 * time.Second
-			}
-
 			// Perform health check
-			checkOK, code, msPtr, errMsg := checker.Check(checker.CheckOptions{
-				URL:         sc.URL,
-				Timeout:     timeout,
-				ExpectedMin: sc.ExpectedMin,
-				ExpectedMax: sc.ExpectedMax,
-				CheckType:   sc.CheckType,
-				ServiceType: sc.ServiceType,
-				APIToken:    sc.APIToken,
-			})
+			res := checker.Run(checker.OptionsForService(&sc))
+			code, msPtr, errMsg := res.Code, res.MS, res.Err
 
 			// Track consecutive failures
-			consecutiveFailures := tracker.Update(sc.Key, checkOK)
+			consecutiveFailures := tracker.Update(sc.Key, res.OK)
 
 			// OK if check passed OR haven't hit 2 consecutive failures yet
-			ok := checkOK || consecutiveFailures < 2
+			ok := res.OK || consecutiveFailures < 2
+
+			// Degraded = responding but slow (overall or in the configured phase)
+			degraded := ok && checker.IsDegraded(res, sc.DegradedPhase)
 
-			// Degraded = responding but slow
-			degraded := ok && msPtr != nil && *msPtr > 200
+			// Firing inbound alerts (Alertmanager, Grafana, webhooks) take the service down or degrade it
+			ok, degraded, inbound := alerts.InboundOverride(sc.Key, ok, degraded)
+			if inbound != "" && !ok && errMsg == "" {
+				errMsg = inbound
+			} else if inbound != "" && degraded {
+				res.Warnings = append(res.Warnings, inbound)
+			}
 
 			// Record stats
-			stats.RecordHeartbeat(sc.Key, ok, msPtr, code, errMsg)
+			stats.RecordHeartbeatWithTimings(sc.Key, ok, msPtr, code, errMsg, res.Timings)
 			database.InsertSample(now, sc.Key, ok, code, msPtr)
+			if len(res.Metrics) > 0 {
+				if err := database.SaveServiceMetrics(sc.Key, res.Metrics); err != nil {
+					log.Printf("save metrics for %s: %v", sc.Key, err)
+				}
+			}
 
 			// Log the check result
 			logLevel := database.LogLevelInfo
@@ -358,6 +371,10 @@ func runScheduler(alertMgr *alerts.Manager, defaultInterval time.Duration, track
 				if errMsg != "" {
 					logDetails += ", error=" + errMsg
 				}
+			} else if degraded && len(res.Warnings) > 0 {
+				logLevel = database.LogLevelWarn
+				logMsg = "Service degraded (health warnings)"
+				logDetails += ", warnings=" + strings.Join(res.Warnings, "; ")
 			} else if degraded {
 				logLevel = database.LogLevelWarn
 				logMsg = "Service degraded (slow response)"
@@ -374,7 +391,13 @@ func runScheduler(alertMgr *alerts.Manager, defaultInterval time.Duration, track
 			if name == "" {
 				name = sc.Key
 			}
-			alertMgr.CheckAndSendAlerts(sc.Key, name, ok, degraded)
+			details := alerts.CheckDetails{Error: errMsg, Warnings: res.Warnings}
+			if msPtr != nil {
+				details.LatencyMS = *msPtr
+			}
+			alertMgr.CheckAndSendAlertsWithDetails(sc.Key, name, ok, degraded, details)
+			alertMgr.CheckContentChange(sc.Key, name, res.ContentHash)
+			alertMgr.CheckLatencyAnomaly(sc.Key, name, ok, details.LatencyMS)
 		}
 
 		// Prune old logs every 5 minutes
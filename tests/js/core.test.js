@@ -27,6 +27,19 @@ describe('fmtMs', () => {
   });
 });
 
+/* ── fmtPlayers ─────────────────────────────────────────── */
+describe('fmtPlayers', () => {
+  test('players with max', () => {
+    expect(fmtPlayers(3, 20)).toBe('3/20');
+  });
+  test('missing max shows count only', () => {
+    expect(fmtPlayers(5)).toBe('5');
+  });
+  test('missing players treated as zero', () => {
+    expect(fmtPlayers(undefined, 10)).toBe('0/10');
+  });
+});
+
 /* ── cls ────────────────────────────────────────────────── */
 describe('cls', () => {
   test('not ok → pill down', () => {
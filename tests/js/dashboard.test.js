@@ -53,6 +53,23 @@ describe('updCard', () => {
     expect(pill.className).toBe('pill warn');
   });
 
+  test('shows FLAPPING pill', () => {
+    const id = buildCard('plex');
+    updCard(id, { ok: false, status: 0, flapping: true, ms: null });
+    const pill = document.querySelector(`#${id} .pill`);
+    expect(pill.textContent).toBe('FLAPPING');
+    expect(pill.className).toBe('pill warn');
+  });
+
+  test('shows IMPACTED pill naming the root cause', () => {
+    const id = buildCard('plex');
+    updCard(id, { ok: false, status: 0, impacted: true, root_cause: 'router', ms: null });
+    const pill = document.querySelector(`#${id} .pill`);
+    expect(pill.textContent).toBe('IMPACTED');
+    expect(pill.className).toBe('pill warn');
+    expect(pill.title).toBe('Upstream down: router');
+  });
+
   test('shows DISABLED state', () => {
     const id = buildCard('test');
     updCard(id, { ok: false, disabled: true });
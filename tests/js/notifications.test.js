@@ -0,0 +1,214 @@
+/**
+ * Tests for notifications.js – collectChannelSettings, channelSummary, renderChannelChecklist,
+ * incident timeline helpers, message template helpers.
+ */
+const { loadSource } = require('./test-helpers');
+
+beforeAll(() => {
+  loadSource('core.js', 'utils.js', 'notifications.js');
+});
+
+/* ── collectChannelSettings ─────────────────────────────── */
+describe('collectChannelSettings', () => {
+  test('reads text inputs and checkboxes marked with data-setting', () => {
+    const form = document.createElement('form');
+    form.innerHTML = `
+      <input type="text" name="name" value="Ignored">
+      <input type="text" data-setting="smtp_host" value="  smtp.example.com ">
+      <input type="number" data-setting="smtp_port" value="465">
+      <input type="checkbox" data-setting="smtp_skip_verify" checked>`;
+    expect(collectChannelSettings(form)).toEqual({
+      smtp_host: 'smtp.example.com',
+      smtp_port: '465',
+      smtp_skip_verify: 'true'
+    });
+  });
+
+  test('unchecked checkbox → "false"', () => {
+    const form = document.createElement('form');
+    form.innerHTML = '<input type="checkbox" data-setting="smtp_skip_verify">';
+    expect(collectChannelSettings(form).smtp_skip_verify).toBe('false');
+  });
+});
+
+/* ── channelSummary ─────────────────────────────────────── */
+describe('channelSummary', () => {
+  test('email shows recipients and server', () => {
+    const ch = { type: 'email', settings: { to: 'a@x.io, b@x.io', smtp_host: 'smtp.x.io', smtp_port: '587' } };
+    expect(channelSummary(ch)).toBe('a@x.io, b@x.io via smtp.x.io:587');
+  });
+
+  test('discord hides the webhook token', () => {
+    const ch = { type: 'discord', settings: { webhook_url: 'https://discord.com/api/webhooks/123/secret' } };
+    expect(channelSummary(ch)).toBe('https://discord.com/…');
+  });
+
+  test('slack and teams hide the webhook path', () => {
+    expect(channelSummary({ type: 'slack', settings: { webhook_url: 'https://hooks.slack.com/services/T0/B0/x' } })).toBe('https://hooks.slack.com/…');
+    expect(channelSummary({ type: 'teams', settings: { webhook_url: 'https://prod.logic.azure.com/workflows/abc' } })).toBe('https://prod.logic.azure.com/…');
+  });
+
+  test('push channels show where they deliver', () => {
+    expect(channelSummary({ type: 'ntfy', settings: { topic: 'alerts' } })).toBe('https://ntfy.sh/alerts');
+    expect(channelSummary({ type: 'ntfy', settings: { server_url: 'https://ntfy.lan/', topic: 'ops' } })).toBe('https://ntfy.lan/ops');
+    expect(channelSummary({ type: 'gotify', settings: { server_url: 'https://gotify.lan' } })).toBe('https://gotify.lan');
+    expect(channelSummary({ type: 'pushover', settings: { device: 'phone' } })).toBe('Device phone');
+    expect(channelSummary({ type: 'pushover', settings: {} })).toBe('All devices');
+  });
+
+  test('matrix shows room and homeserver', () => {
+    expect(channelSummary({ type: 'matrix', settings: { room_id: '!abc:hs.io', homeserver: 'https://matrix.hs.io/' } })).toBe('!abc:hs.io on matrix.hs.io');
+  });
+
+  test('telegram shows chat id', () => {
+    expect(channelSummary({ type: 'telegram', settings: { chat_id: '-100' } })).toBe('Chat -100');
+  });
+
+  test('webhook shows url', () => {
+    expect(channelSummary({ type: 'webhook', settings: { url: 'https://hook.io/x' } })).toBe('https://hook.io/x');
+  });
+
+  test('unknown type → empty string', () => {
+    expect(channelSummary({ type: 'pager' })).toBe('');
+  });
+});
+
+/* ── renderChannelChecklist ─────────────────────────────── */
+describe('renderChannelChecklist', () => {
+  afterEach(() => {
+    notificationChannels = [];
+  });
+
+  test('renders one checkbox per channel and checks the selected IDs', () => {
+    notificationChannels = [
+      { id: 1, name: 'Ops mail', type: 'email' },
+      { id: 2, name: 'On-call', type: 'telegram' }
+    ];
+    const container = document.createElement('div');
+    renderChannelChecklist(container, ' 2 ,');
+    const boxes = Array.from(container.querySelectorAll('.notify-channel-cb'));
+    expect(boxes.map(cb => cb.value)).toEqual(['1', '2']);
+    expect(boxes.map(cb => cb.checked)).toEqual([false, true]);
+    expect(container.textContent).toContain('On-call (telegram)');
+  });
+
+  test('shows a hint when no channels exist', () => {
+    const container = document.createElement('div');
+    renderChannelChecklist(container, '1');
+    expect(container.querySelector('input')).toBeNull();
+    expect(container.textContent).toContain('No notification channels configured');
+  });
+});
+
+/* ── channelUrlExportText ───────────────────────────────── */
+describe('channelUrlExportText', () => {
+  test('lists each channel with its URL or error', () => {
+    const text = channelUrlExportText([
+      { name: 'Ops', type: 'discord', enabled: true, url: 'discord://t@1' },
+      { name: 'Old', type: 'slack', enabled: false, error: 'bad webhook' }
+    ]);
+    expect(text).toBe('# Ops (discord)\ndiscord://t@1\n# Old (slack) [disabled]\n# bad webhook');
+  });
+
+  test('empty list → empty string', () => {
+    expect(channelUrlExportText([])).toBe('');
+  });
+});
+
+/* ── incident timeline ──────────────────────────────────── */
+describe('incidentDuration', () => {
+  test('formats seconds, minutes and hours', () => {
+    expect(incidentDuration(40)).toBe('40s');
+    expect(incidentDuration(45 * 60)).toBe('45m');
+    expect(incidentDuration(2 * 3600)).toBe('2h');
+    expect(incidentDuration(2 * 3600 + 5 * 60)).toBe('2h 5m');
+  });
+});
+
+describe('incidentStateHtml', () => {
+  test('resolved wins over acknowledged', () => {
+    expect(incidentStateHtml({ resolved_at: 'x', acknowledged_at: 'y' })).toContain('RESOLVED');
+  });
+
+  test('acknowledged while ongoing', () => {
+    expect(incidentStateHtml({ resolved_at: '', acknowledged_at: 'y' })).toContain('ACKNOWLEDGED');
+  });
+
+  test('unacknowledged ongoing incident', () => {
+    expect(incidentStateHtml({ resolved_at: '', acknowledged_at: '' })).toContain('ONGOING');
+  });
+});
+
+describe('incidentSummary', () => {
+  test('includes who acknowledged', () => {
+    const text = incidentSummary({
+      started_at: '2026-01-01T10:00:00Z',
+      resolved_at: '2026-01-01T10:30:00Z',
+      duration_s: 1800,
+      acknowledged_at: '2026-01-01T10:05:00Z',
+      acknowledged_by: 'admin'
+    });
+    expect(text).toContain('lasted 30m');
+    expect(text).toContain('acknowledged by admin');
+  });
+});
+
+/* ── message templates ──────────────────────────────────── */
+describe('findTemplateOverride', () => {
+  const templates = [
+    { event: 'down', channel_type: '', subject: 'all' },
+    { event: 'down', channel_type: 'telegram', subject: 'tg' }
+  ];
+
+  test('matches event and channel type exactly', () => {
+    expect(findTemplateOverride(templates, 'down', 'telegram').subject).toBe('tg');
+    expect(findTemplateOverride(templates, 'down', '').subject).toBe('all');
+  });
+
+  test('no override → null', () => {
+    expect(findTemplateOverride(templates, 'down', 'email')).toBeNull();
+    expect(findTemplateOverride(undefined, 'up', '')).toBeNull();
+  });
+});
+
+describe('templatePreviewHtml', () => {
+  test('escapes the rendered subject and body', () => {
+    const html = templatePreviewHtml({ subject: 'Down: <b>Plex</b>', body: 'The service <strong>Plex</strong>' });
+    expect(html).toContain('Down: &lt;b&gt;Plex&lt;/b&gt;');
+    expect(html).toContain('&lt;strong&gt;Plex&lt;/strong&gt;');
+  });
+
+  test('omits an empty subject', () => {
+    expect(templatePreviewHtml({ subject: '', body: 'x' })).not.toContain('template-preview-subject');
+  });
+});
+
+/* ── quiet hours ────────────────────────────────────────── */
+describe('quiet hours editor', () => {
+  test('fill then read round-trips a schedule', () => {
+    const el = document.createElement('div');
+    el.className = 'quiet-hours-editor';
+    const q = { enabled: true, timezone: 'Europe/London', days: 'fri,sat', start: '23:00', end: '08:00',
+      actions: { down: 'deliver', degraded: 'suppress', up: 'delay', changed: 'suppress' } };
+    fillQuietHours(el, q);
+    expect(readQuietHours(el)).toEqual(q);
+  });
+
+  test('null resets to defaults', () => {
+    const el = document.createElement('div');
+    fillQuietHours(el, null);
+    const q = readQuietHours(el);
+    expect(q.enabled).toBe(false);
+    expect(q.start).toBe('22:00');
+    expect(q.days).toBe('');
+    expect(q.actions).toEqual(QUIET_DEFAULT_ACTIONS);
+  });
+
+  test('summary describes enabled schedules only', () => {
+    expect(quietHoursSummary({ enabled: true, start: '22:00', end: '07:00', days: 'mon,tue', timezone: 'UTC' }))
+      .toBe('Quiet 22:00–07:00 Mon, Tue (UTC)');
+    expect(quietHoursSummary({ enabled: true, start: '22:00', end: '07:00', days: '' })).toBe('Quiet 22:00–07:00 daily');
+    expect(quietHoursSummary({ enabled: false, start: '22:00', end: '07:00' })).toBe('');
+    expect(quietHoursSummary(null)).toBe('');
+  });
+});
@@ -119,3 +119,37 @@ describe('generateServiceKey', () => {
     expect(generateServiceKey('my-service-name')).toBe('my-service-name');
   });
 });
+
+/* ── parseEntityChecks / formatEntityChecks ─────────────── */
+describe('parseEntityChecks', () => {
+  test('parses state and attribute rules', () => {
+    const rules = parseEntityChecks('sensor.ups_status == online\nsensor.ups:battery_level < 30 degraded\n\n');
+    expect(rules).toEqual([
+      { entity_id: 'sensor.ups_status', attribute: '', operator: '==', value: 'online', severity: 'down' },
+      { entity_id: 'sensor.ups', attribute: 'battery_level', operator: '<', value: '30', severity: 'degraded' },
+    ]);
+  });
+
+  test('accepts rules without spaces', () => {
+    expect(parseEntityChecks('sensor.temp>=18')[0]).toMatchObject({ entity_id: 'sensor.temp', operator: '>=', value: '18' });
+  });
+
+  test('throws on malformed rule', () => {
+    expect(() => parseEntityChecks('sensor.ups_status')).toThrow('Invalid entity check');
+  });
+
+  test('empty input returns no rules', () => {
+    expect(parseEntityChecks('')).toEqual([]);
+  });
+});
+
+describe('formatEntityChecks', () => {
+  test('round-trips parsed rules', () => {
+    const text = 'sensor.ups_status == online\nsensor.ups:battery_level < 30 degraded';
+    expect(formatEntityChecks(parseEntityChecks(text))).toBe(text);
+  });
+
+  test('null returns empty string', () => {
+    expect(formatEntityChecks(null)).toBe('');
+  });
+});
@@ -4,26 +4,23 @@
 
   const config = {
     enabled: $('#alertsEnabled').checked,
-    smtp_host: $('#smtpHost').value,
-    smtp_port: parseInt($('#smtpPort').value) || 587,
-    smtp_user: $('#smtpUser').value,
-    smtp_password: $('#smtpPassword').value,
-    alert_email: $('#alertEmail').value,
-    from_email: $('#alertFromEmail').value,
     status_page_url: $('#statusPageUrl').value.trim(),
-    smtp_skip_verify: $('#smtpSkipVerify').checked,
     alert_on_down: $('#alertOnDown').checked,
     alert_on_degraded: $('#alertOnDegraded').checked,
     alert_on_up: $('#alertOnUp').checked,
-    // Multi-channel
-    discord_webhook_url: $('#discordWebhookUrl') ? $('#discordWebhookUrl').value : '',
-    discord_enabled: $('#discordEnabled') ? $('#discordEnabled').checked : false,
-    telegram_bot_token: $('#telegramBotToken') ? $('#telegramBotToken').value : '',
-    telegram_chat_id: $('#telegramChatId') ? $('#telegramChatId').value : '',
-    telegram_enabled: $('#telegramEnabled') ? $('#telegramEnabled').checked : false,
-    webhook_url: $('#webhookUrl') ? $('#webhookUrl').value : '',
-    webhook_secret: $('#webhookSecret') ? $('#webhookSecret').value : '',
-    webhook_enabled: $('#webhookEnabled') ? $('#webhookEnabled').checked : false
+    repeat_interval_minutes: parseInt($('#repeatIntervalMinutes').value, 10) || 0,
+    escalate_after_minutes: parseInt($('#escalateAfterMinutes').value, 10) || 0,
+    group_window_seconds: parseInt($('#groupWindowSeconds').value, 10) || 0,
+    flap_threshold: parseInt($('#flapThreshold').value, 10) || 0,
+    flap_window_minutes: parseInt($('#flapWindowMinutes').value, 10) || 0,
+    flap_stable_minutes: parseInt($('#flapStableMinutes').value, 10) || 0,
+    latency_sigma: parseFloat($('#latencySigma').value) || 0,
+    latency_sustain_minutes: parseInt($('#latencySustainMinutes').value, 10) || 0,
+    inbound_token: $('#inboundToken').value.trim(),
+    inbound_labels: $('#inboundLabels').value.trim(),
+    inbound_banners: $('#inboundBanners').checked,
+    quiet_hours: readQuietHours($('#globalQuietHours')),
+    escalation_channels: $$('#escalationChannelsList .notify-channel-cb:checked').map(cb => cb.value).join(',')
   };
 
   await handleButtonAction(
@@ -47,56 +44,38 @@
   );
 }
 
-async function sendTestEmail() {
-  const statusEl = $('#alertStatus');
-  const btn = $('#testEmail');
-
-  await handleButtonAction(
-    btn,
-    async () => {
-      const result = await j('/api/admin/alerts/test', {
-        method: 'POST',
-        headers: { 'X-CSRF-Token': getCsrf() }
-      });
-
-      statusEl.textContent = result.message || 'Test email sent successfully';
-      statusEl.className = 'status-message success';
-      statusEl.classList.remove('hidden');
-      setTimeout(() => statusEl.classList.add('hidden'), 5000);
-    },
-    'Test email sent'
-  );
-}
-
 async function loadAlertsConfig() {
   try {
     const config = await j('/api/admin/alerts/config');
     if (config) {
       $('#alertsEnabled').checked = config.enabled || false;
-      $('#smtpHost').value = config.smtp_host || '';
-      $('#smtpPort').value = config.smtp_port || 587;
-      $('#smtpUser').value = config.smtp_user || '';
-      $('#smtpPassword').value = config.smtp_password || '';
-      $('#alertEmail').value = config.alert_email || '';
-      $('#alertFromEmail').value = config.from_email || '';
       $('#statusPageUrl').value = config.status_page_url || '';
-      $('#smtpSkipVerify').checked = config.smtp_skip_verify || false;
       $('#alertOnDown').checked = config.alert_on_down !== false;
       $('#alertOnDegraded').checked = config.alert_on_degraded !== false;
       $('#alertOnUp').checked = config.alert_on_up || false;
-      // Multi-channel
-      if ($('#discordWebhookUrl')) $('#discordWebhookUrl').value = config.discord_webhook_url || '';
-      if ($('#discordEnabled')) $('#discordEnabled').checked = config.discord_enabled || false;
-      if ($('#telegramBotToken')) $('#telegramBotToken').value = config.telegram_bot_token || '';
-      if ($('#telegramChatId')) $('#telegramChatId').value = config.telegram_chat_id || '';
-      if ($('#telegramEnabled')) $('#telegramEnabled').checked = config.telegram_enabled || false;
-      if ($('#webhookUrl')) $('#webhookUrl').value = config.webhook_url || '';
-      if ($('#webhookSecret')) $('#webhookSecret').value = config.webhook_secret || '';
-      if ($('#webhookEnabled')) $('#webhookEnabled').checked = config.webhook_enabled || false;
+      $('#repeatIntervalMinutes').value = config.repeat_interval_minutes || '';
+      $('#escalateAfterMinutes').value = config.escalate_after_minutes || '';
+      $('#groupWindowSeconds').value = config.group_window_seconds || '';
+      $('#flapThreshold').value = config.flap_threshold || '';
+      $('#flapWindowMinutes').value = config.flap_window_minutes || '';
+      $('#flapStableMinutes').value = config.flap_stable_minutes || '';
+      $('#latencySigma').value = config.latency_sigma || '';
+      $('#latencySustainMinutes').value = config.latency_sustain_minutes || '';
+      $('#inboundToken').value = config.inbound_token || '';
+      $('#inboundLabels').value = config.inbound_labels || '';
+      $('#inboundBanners').checked = !!config.inbound_banners;
+      fillQuietHours($('#globalQuietHours'), config.quiet_hours);
+      escalationChannelIds = config.escalation_channels || '';
     }
   } catch (err) {
     // No alerts config available
   }
+  await loadNotificationChannels();
+  loadNotificationTemplates();
+  loadIncidents();
+  loadInboundAlerts();
+  loadNotificationDeliveries();
+  fillSimulationServices();
 }
 
 // ============ Service Dependencies ============
@@ -235,31 +214,7 @@ window.addEventListener('load', async () => {
   if (saveAlertsBtn) {
     saveAlertsBtn.addEventListener('click', saveAlertsConfig);
   }
-  // Also wire up all save-alerts-btn buttons in channel panels
-  $$('.save-alerts-btn').forEach(btn => {
-    btn.addEventListener('click', saveAlertsConfig);
-  });
-  // Test channel buttons
-  $$('.test-channel-btn').forEach(btn => {
-    btn.addEventListener('click', async function() {
-      const channel = this.getAttribute('data-channel');
-      try {
-        const result = await j('/api/admin/alerts/test-channel', {
-          method: 'POST',
-          headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
-          body: JSON.stringify({ channel })
-        });
-        alert(result.message || `Test ${channel} notification sent`);
-      } catch (err) {
-        alert(`Failed to send test: ${err.message || err}`);
-      }
-    });
-  });
-
-  const testEmailBtn = $('#testEmail');
-  if (testEmailBtn) {
-    testEmailBtn.addEventListener('click', sendTestEmail);
-  }
+  initNotificationChannels();
 
   // Resources config handlers
   const saveResourcesBtn = $('#saveResources');
@@ -271,6 +226,7 @@ window.addEventListener('load', async () => {
   if (testGlancesBtn) {
     testGlancesBtn.addEventListener('click', testGlancesConnection);
   }
+  initResourceAlerts();
 
   // Security tab handlers
   const resetBlocksBtn = $('#resetBlocks');
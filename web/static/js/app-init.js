@@ -18,6 +18,7 @@ window.addEventListener('load', async () => {
 
   // Initialize view toggle (Cards / Hive)
   initViewToggle();
+  openDayDetailFromLink();
 
   // Now start refresh — cards are guaranteed to be in the DOM
   refresh();
@@ -19,6 +19,8 @@ async function whoami() {
       document.dispatchEvent(loginStateChanged);
       loadAlertsConfig();
       loadResourcesConfig();
+      loadResourceAlertRules();
+      acknowledgeFromLink();
     } else {
       isAdminUser = false;
       $('#welcome').textContent = 'Public view';
@@ -42,6 +44,9 @@ async function whoami() {
         $('#u', dlg).value = '';
         $('#p', dlg).value = '';
       }
+
+      // Alert acknowledgement links need a signed-in admin; the page reloads after login
+      if (new URLSearchParams(window.location.search).has('ack')) doLoginFlow();
     }
   } catch (e) {
     console.error('Failed to fetch user info:', e.message);
@@ -5,6 +5,7 @@ let isAdminUser = false;
 const $ = (s, r = document) => r.querySelector(s);
 const $$ = (s, r = document) => Array.from(r.querySelectorAll(s));
 const fmtMs = ms => ms == null ? '—' : ms + ' ms';
+const fmtPlayers = (players, max) => max ? `${Number(players) || 0}/${Number(max)}` : String(Number(players) || 0);
 const cls = (ok, status, degraded) => {
   if (!ok) return 'pill down'; // Down = red
   if (degraded) return 'pill warn'; // Degraded = amber
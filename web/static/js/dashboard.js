@@ -23,6 +23,7 @@ function updCard(id, data) {
   if (data.disabled) {
     pill.textContent = 'DISABLED';
     pill.className = 'pill warn';
+    pill.title = '';
     el.classList.remove('status-up', 'status-down', 'status-degraded');
     el.classList.add('status-disabled');
     k.textContent = '—';
@@ -30,12 +31,17 @@ function updCard(id, data) {
     return;
   }
 
-  if (data.degraded) {
+  if (data.flapping) {
+    pill.textContent = 'FLAPPING';
+  } else if (data.impacted) {
+    pill.textContent = 'IMPACTED';
+  } else if (data.degraded) {
     pill.textContent = 'DEGRADED';
   } else {
     pill.textContent = data.ok ? 'UP' : 'DOWN';
   }
-  pill.className = cls(data.ok, data.status, data.degraded);
+  pill.className = data.flapping || data.impacted ? 'pill warn' : cls(data.ok, data.status, data.degraded);
+  pill.title = data.impacted && data.root_cause ? `Upstream down: ${data.root_cause}` : '';
 
   // Update the left accent bar
   el.classList.remove('status-up', 'status-down', 'status-degraded', 'status-disabled');
@@ -52,8 +58,23 @@ function updCard(id, data) {
     h.textContent = data.ok ? 'Port open' : 'Connection refused';
   } else if (checkType === 'dns') {
     h.textContent = data.ok ? 'DNS resolved' : 'Lookup failed';
+  } else if (checkType === 'minecraft' || checkType === 'a2s') {
+    const game = data.game;
+    h.textContent = data.ok && game ? `${fmtPlayers(game.players, game.max_players)} players` : 'No response';
+    const playersEl = $(`#players-${id.split('-').pop()}`);
+    if (playersEl && game) playersEl.textContent = fmtPlayers(game.players, game.max_players);
+  } else if (data.entities && data.entities.length) {
+    // Home Assistant entity rules
+    const passing = data.entities.filter(e => e.ok).length;
+    h.textContent = `${passing}/${data.entities.length} entities OK`;
+    h.title = data.entities.filter(e => !e.ok).map(e => e.message).join('\n');
+  } else if (data.warnings && data.warnings.length) {
+    // App health warnings (e.g. *arr indexers down)
+    h.textContent = data.warnings.length === 1 ? '1 health warning' : `${data.warnings.length} health warnings`;
+    h.title = data.warnings.join('\n');
   } else {
     // HTTP/HTTPS
+    h.title = '';
     if (typeof data.status === 'number' && data.status > 0) {
       h.textContent = 'HTTP ' + data.status;
     } else if (data.status === 0 && !data.ok) {
@@ -63,6 +84,11 @@ function updCard(id, data) {
     }
   }
 
+  const streamsEl = $(`#streams-${id.split('-').pop()}`);
+  if (streamsEl && data.metrics && data.metrics.streams !== undefined) {
+    streamsEl.textContent = Number(data.metrics.streams) || 0;
+  }
+
   // Update last check time
   const lastCheckEl = $(`#last-check-${id.split('-').pop()}`);
   if (lastCheckEl) {
@@ -135,6 +135,20 @@ function renderDayDetailEvents(events, container) {
   container.appendChild(list);
 }
 
+// Opens the day detail linked from a notification (/?service=<key>&day=YYYY-MM-DD)
+function openDayDetailFromLink() {
+  const params = new URLSearchParams(window.location.search);
+  const serviceKey = params.get('service');
+  const day = params.get('day');
+  if (!serviceKey || !/^\d{4}-\d{2}-\d{2}$/.test(day || '')) return;
+
+  params.delete('service');
+  params.delete('day');
+  const query = params.toString();
+  history.replaceState(null, '', window.location.pathname + (query ? `?${query}` : '') + window.location.hash);
+  openDayDetail(serviceKey, day);
+}
+
 // Close day detail dialog
 document.addEventListener('DOMContentLoaded', () => {
   const closeBtn = $('#closeDayDetail');
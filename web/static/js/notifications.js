@@ -0,0 +1,742 @@
+// ============ Notification Channels ============
+
+let notificationChannels = [];
+// Comma-separated channel IDs selected for escalation (alert config's escalation_channels)
+let escalationChannelIds = '';
+
+// Reads the type-specific settings from a channel form (inputs marked with data-setting)
+function collectChannelSettings(form) {
+  const settings = {};
+  $$('[data-setting]', form).forEach(input => {
+    const key = input.getAttribute('data-setting');
+    settings[key] = input.type === 'checkbox' ? String(input.checked) : input.value.trim();
+  });
+  return settings;
+}
+
+// Short description of where a channel delivers, shown in the channel list
+function channelSummary(ch) {
+  const s = ch.settings || {};
+  switch (ch.type) {
+    case 'email':
+      return `${s.to || ''} via ${s.smtp_host || '?'}${s.smtp_port ? ':' + s.smtp_port : ''}`;
+    case 'discord':
+    case 'slack':
+    case 'teams':
+      return (s.webhook_url || '').replace(/^(https?:\/\/[^/]+).*$/, '$1/…');
+    case 'telegram':
+      return `Chat ${s.chat_id || '?'}${s.commands === 'true' ? ' • bot commands' : ''}`;
+    case 'webhook':
+      return s.url || '';
+    case 'ntfy':
+      return `${(s.server_url || 'https://ntfy.sh').replace(/\/+$/, '')}/${s.topic || '?'}`;
+    case 'gotify':
+      return s.server_url || '';
+    case 'pushover':
+      return s.device ? `Device ${s.device}` : 'All devices';
+    case 'matrix':
+      return `${s.room_id || '?'} on ${(s.homeserver || '').replace(/^https?:\/\//, '').replace(/\/+$/, '')}`;
+    default:
+      return '';
+  }
+}
+
+async function loadNotificationChannels() {
+  try {
+    const data = await j('/api/admin/notifications');
+    notificationChannels = data.channels || [];
+  } catch (err) {
+    notificationChannels = [];
+  }
+  $$('.channel-list').forEach(renderChannelList);
+  const escalation = $('#escalationChannelsList');
+  if (escalation) renderChannelChecklist(escalation, escalationChannelIds);
+}
+
+function renderChannelList(container) {
+  const type = container.getAttribute('data-type');
+  const list = notificationChannels.filter(ch => ch.type === type);
+  if (list.length === 0) {
+    container.innerHTML = '<div class="muted">No channels configured</div>';
+    return;
+  }
+  container.innerHTML = list.map(ch => `
+    <div class="block-item">
+      <div class="block-info">
+        <strong>${escapeHtml(ch.name)}</strong>${ch.enabled ? '' : ' <span class="muted">(disabled)</span>'}
+        <span class="muted">${escapeHtml(channelSummary(ch))}</span>
+        ${ch.quiet_hours && ch.quiet_hours.enabled ? `<span class="muted">${escapeHtml(quietHoursSummary(ch.quiet_hours))}</span>` : ''}
+      </div>
+      <div class="ops">
+        <button class="btn ghost small" data-action="test-channel" data-id="${ch.id}">Test</button>
+        <button class="btn ghost small" data-action="edit-channel" data-id="${ch.id}">Edit</button>
+        <button class="btn danger small" data-action="delete-channel" data-id="${ch.id}">Delete</button>
+      </div>
+    </div>
+  `).join('');
+}
+
+function resetChannelForm(form) {
+  form.reset();
+  form.elements.id.value = '';
+  fillQuietHours($('.quiet-hours-editor', form), null);
+  $('.channel-form-title', form).textContent = `Add ${$('input[name="name"]', form).placeholder} Channel`;
+  $('.cancel-channel-btn', form).classList.add('hidden');
+}
+
+function editChannel(id) {
+  const ch = notificationChannels.find(c => c.id === id);
+  if (!ch) return;
+  const form = $(`.channel-form[data-type="${ch.type}"]`);
+  if (!form) return;
+
+  form.elements.id.value = ch.id;
+  form.elements.name.value = ch.name;
+  form.elements.enabled.checked = ch.enabled;
+  $$('[data-setting]', form).forEach(input => {
+    const value = (ch.settings || {})[input.getAttribute('data-setting')] || '';
+    if (input.type === 'checkbox') {
+      input.checked = value === 'true';
+    } else if (input.tagName === 'SELECT') {
+      input.value = value || input.options[0].value;
+    } else {
+      input.value = value;
+    }
+  });
+  fillQuietHours($('.quiet-hours-editor', form), ch.quiet_hours);
+  $('.quiet-hours', form).open = !!(ch.quiet_hours && ch.quiet_hours.enabled);
+  $('.channel-form-title', form).textContent = `Edit ${ch.name}`;
+  $('.cancel-channel-btn', form).classList.remove('hidden');
+  form.scrollIntoView({ behavior: 'smooth', block: 'nearest' });
+}
+
+async function saveChannel(form, btn) {
+  const id = form.elements.id.value;
+  const type = form.getAttribute('data-type');
+  const channel = {
+    type,
+    name: form.elements.name.value.trim() || form.elements.name.placeholder,
+    enabled: form.elements.enabled.checked,
+    settings: collectChannelSettings(form),
+    quiet_hours: readQuietHours($('.quiet-hours-editor', form))
+  };
+
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(id ? `/api/admin/notifications/${id}` : '/api/admin/notifications', {
+        method: id ? 'PUT' : 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+        body: JSON.stringify(channel)
+      });
+      resetChannelForm(form);
+      await loadNotificationChannels();
+    },
+    id ? 'Channel updated' : 'Channel added'
+  );
+}
+
+async function deleteChannel(id, btn) {
+  const ch = notificationChannels.find(c => c.id === id);
+  if (!ch || !confirm(`Delete notification channel "${ch.name}"?`)) return;
+
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(`/api/admin/notifications/${id}`, {
+        method: 'DELETE',
+        headers: { 'X-CSRF-Token': getCsrf() }
+      });
+      await loadNotificationChannels();
+    },
+    'Channel deleted'
+  );
+}
+
+async function testChannel(id, btn) {
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(`/api/admin/notifications/${id}/test`, {
+        method: 'POST',
+        headers: { 'X-CSRF-Token': getCsrf() }
+      });
+    },
+    'Test notification sent'
+  );
+}
+
+function initNotificationChannels() {
+  $$('.channel-form').forEach(form => {
+    const quiet = document.createElement('details');
+    quiet.className = 'form-group quiet-hours';
+    quiet.innerHTML = '<summary>Quiet hours</summary><div class="quiet-hours-editor" data-note="Overrides the global quiet hours while enabled."></div>';
+    $('.ops', form).before(quiet);
+    renderQuietHoursEditor($('.quiet-hours-editor', quiet));
+
+    $('.save-channel-btn', form).addEventListener('click', e => saveChannel(form, e.currentTarget));
+    $('.cancel-channel-btn', form).addEventListener('click', () => resetChannelForm(form));
+  });
+
+  $('#escalationChannelsList')?.addEventListener('change', e => {
+    escalationChannelIds = $$('.notify-channel-cb:checked', e.currentTarget).map(cb => cb.value).join(',');
+  });
+
+  $('#addChannelUrl')?.addEventListener('click', e => addChannelFromUrl(e.currentTarget));
+  $('#exportChannelUrls')?.addEventListener('click', e => exportChannelUrls(e.currentTarget));
+
+  $('#templateEvent')?.addEventListener('change', showNotificationTemplate);
+  $('#templateChannelType')?.addEventListener('change', showNotificationTemplate);
+  $('#previewTemplate')?.addEventListener('click', e => previewNotificationTemplate(e.currentTarget));
+  $('#saveTemplate')?.addEventListener('click', e => saveNotificationTemplate(e.currentTarget));
+  $('#resetTemplate')?.addEventListener('click', e => resetNotificationTemplate(e.currentTarget));
+
+  $('#refreshIncidents')?.addEventListener('click', loadIncidents);
+  $('#incidentList')?.addEventListener('click', e => {
+    const btn = e.target.closest('[data-action="ack-incident"]');
+    if (btn) acknowledgeIncident(parseInt(btn.getAttribute('data-id'), 10), btn);
+  });
+
+  $('#runSimulation')?.addEventListener('click', e => runAlertSimulation(e.target));
+  $('#simService')?.addEventListener('focus', fillSimulationServices);
+  $('#refreshInboundAlerts')?.addEventListener('click', loadInboundAlerts);
+  $('#generateInboundToken')?.addEventListener('click', () => {
+    const bytes = crypto.getRandomValues(new Uint8Array(24));
+    $('#inboundToken').value = Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('');
+  });
+
+  $('#refreshDeliveries')?.addEventListener('click', loadNotificationDeliveries);
+  $('#deliveryStatusFilter')?.addEventListener('change', loadNotificationDeliveries);
+  $('#deliveryList')?.addEventListener('click', e => {
+    const btn = e.target.closest('[data-action="resend-delivery"]');
+    if (btn) resendDelivery(parseInt(btn.getAttribute('data-id'), 10), btn);
+  });
+
+  $$('.channel-list').forEach(container => {
+    container.addEventListener('click', e => {
+      const btn = e.target.closest('[data-action]');
+      if (!btn) return;
+      const id = parseInt(btn.getAttribute('data-id'), 10);
+      const action = btn.getAttribute('data-action');
+      if (action === 'edit-channel') editChannel(id);
+      else if (action === 'delete-channel') deleteChannel(id, btn);
+      else if (action === 'test-channel') testChannel(id, btn);
+    });
+  });
+}
+
+async function addChannelFromUrl(btn) {
+  const url = $('#channelUrl').value.trim();
+  if (!url) {
+    showToast('Enter a notification URL', 'error');
+    return;
+  }
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j('/api/admin/notifications', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+        body: JSON.stringify({ name: $('#channelUrlName').value.trim(), url, enabled: true })
+      });
+      $('#channelUrl').value = '';
+      $('#channelUrlName').value = '';
+      await loadNotificationChannels();
+    },
+    'Channel added'
+  );
+}
+
+// One line per exported channel: "# Name (type)" followed by its URL, or the reason it has none
+function channelUrlExportText(channels) {
+  return (channels || [])
+    .map(ch => `# ${ch.name} (${ch.type})${ch.enabled ? '' : ' [disabled]'}\n${ch.url || '# ' + (ch.error || 'no URL form')}`)
+    .join('\n');
+}
+
+async function exportChannelUrls(btn) {
+  await handleButtonAction(
+    btn,
+    async () => {
+      const data = await j('/api/admin/notifications/urls');
+      const out = $('#channelUrlExport');
+      out.value = channelUrlExportText(data.channels) || '# No channels configured';
+      out.classList.remove('hidden');
+    },
+    'Exported - the URLs contain secrets'
+  );
+}
+
+// Fills the service modal's channel checklist; selected is the service's notify_channels list
+async function populateNotifyChannelsList(selected) {
+  const container = $('#serviceNotifyChannelsList');
+  if (!container) return;
+  if (notificationChannels.length === 0) await loadNotificationChannels();
+  renderChannelChecklist(container, selected);
+}
+
+// Renders one checkbox per channel into container, checking the IDs in the comma-separated selected list
+function renderChannelChecklist(container, selected) {
+  const ids = (selected || '').split(',').map(s => s.trim()).filter(Boolean);
+  container.innerHTML = '';
+  if (notificationChannels.length === 0) {
+    container.innerHTML = '<span class="muted" style="font-size:12px;">No notification channels configured</span>';
+    return;
+  }
+  notificationChannels.forEach(ch => {
+    const label = document.createElement('label');
+    label.className = 'depends-on-option';
+    const cb = document.createElement('input');
+    cb.type = 'checkbox';
+    cb.value = String(ch.id);
+    cb.className = 'notify-channel-cb';
+    cb.checked = ids.includes(String(ch.id));
+    label.appendChild(cb);
+    label.appendChild(document.createTextNode(` ${ch.name} (${ch.type})`));
+    container.appendChild(label);
+  });
+}
+
+// ============ Delivery Log ============
+
+function deliveryStatusHtml(d) {
+  const label = d.status === 'dead' ? 'DEAD' : d.status.toUpperCase();
+  return `<span class="badge ${escapeHtml(d.status)}">${label}</span>`;
+}
+
+async function loadNotificationDeliveries() {
+  const container = $('#deliveryList');
+  if (!container) return;
+  const status = $('#deliveryStatusFilter')?.value || '';
+
+  try {
+    const data = await j('/api/admin/notifications/deliveries' + (status ? `?status=${encodeURIComponent(status)}` : ''));
+    const list = data.deliveries || [];
+    if (list.length === 0) {
+      container.innerHTML = '<div class="muted">No deliveries yet</div>';
+      return;
+    }
+    container.innerHTML = list.map(d => `
+      <div class="block-item">
+        <div class="block-info">
+          <span>${escapeHtml(d.subject)}${deliveryStatusHtml(d)}</span>
+          <span class="muted">${escapeHtml(d.channel_name)} (${escapeHtml(d.channel_type)}) • ${new Date(d.created_at).toLocaleString()} • ${d.attempts} attempt${d.attempts === 1 ? '' : 's'}${d.last_error ? ' • ' + escapeHtml(d.last_error) : ''}</span>
+        </div>
+        ${d.status === 'pending' ? '' : `<button class="btn ghost small" data-action="resend-delivery" data-id="${d.id}">Resend</button>`}
+      </div>
+    `).join('');
+  } catch (err) {
+    container.innerHTML = '<div class="muted">Failed to load deliveries</div>';
+  }
+}
+
+async function resendDelivery(id, btn) {
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(`/api/admin/notifications/deliveries/${id}/resend`, {
+        method: 'POST',
+        headers: { 'X-CSRF-Token': getCsrf() }
+      });
+      await loadNotificationDeliveries();
+    },
+    'Notification queued'
+  );
+}
+
+// ============ Incident Timeline ============
+
+function incidentStateHtml(inc) {
+  if (inc.resolved_at) return '<span class="badge resolved">RESOLVED</span>';
+  if (inc.acknowledged_at) return '<span class="badge acknowledged">ACKNOWLEDGED</span>';
+  return '<span class="badge">ONGOING</span>';
+}
+
+// Renders an incident duration in seconds as e.g. "40s", "45m" or "2h 5m"
+function incidentDuration(seconds) {
+  const s = Math.max(0, Number(seconds) || 0);
+  if (s < 60) return `${s}s`;
+  const mins = Math.round(s / 60);
+  if (mins < 60) return `${mins}m`;
+  return mins % 60 ? `${Math.floor(mins / 60)}h ${mins % 60}m` : `${mins / 60}h`;
+}
+
+// One-line description of an incident: when it started, how long it lasted and who acknowledged it
+function incidentSummary(inc) {
+  const parts = [new Date(inc.started_at).toLocaleString()];
+  if (inc.resolved_at) {
+    parts.push(`lasted ${incidentDuration(inc.duration_s)}`);
+  }
+  if (inc.acknowledged_at) {
+    parts.push(`acknowledged by ${inc.acknowledged_by || 'unknown'} at ${new Date(inc.acknowledged_at).toLocaleString()}`);
+  }
+  return parts.join(' • ');
+}
+
+async function loadIncidents() {
+  const container = $('#incidentList');
+  if (!container) return;
+
+  try {
+    const data = await j('/api/admin/alerts/incidents');
+    const list = data.incidents || [];
+    if (list.length === 0) {
+      container.innerHTML = '<div class="muted">No incidents recorded</div>';
+      return;
+    }
+    container.innerHTML = list.map(inc => `
+      <div class="block-item">
+        <div class="block-info">
+          <span>${escapeHtml(inc.service_name || inc.service_key)}${incidentStateHtml(inc)}</span>
+          <span class="muted">${escapeHtml(incidentSummary(inc))}</span>
+        </div>
+        ${inc.resolved_at || inc.acknowledged_at ? '' : `<button class="btn ghost small" data-action="ack-incident" data-id="${inc.id}">Acknowledge</button>`}
+      </div>
+    `).join('');
+  } catch (err) {
+    container.innerHTML = '<div class="muted">Failed to load incidents</div>';
+  }
+}
+
+async function acknowledgeIncident(id, btn) {
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j('/api/admin/alerts/ack', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+        body: JSON.stringify({ incident_id: id })
+      });
+      await loadIncidents();
+    },
+    'Incident acknowledged'
+  );
+}
+
+const INBOUND_SOURCES = { alertmanager: 'Alertmanager', grafana: 'Grafana', generic: 'Webhook' };
+
+async function loadInboundAlerts() {
+  const container = $('#inboundAlertList');
+  if (!container) return;
+
+  try {
+    const data = await j('/api/admin/alerts/inbound');
+    const list = data.alerts || [];
+    if (list.length === 0) {
+      container.innerHTML = '<div class="muted">No inbound alerts received</div>';
+      return;
+    }
+    container.innerHTML = list.map(a => {
+      const parts = [INBOUND_SOURCES[a.source] || a.source, a.severity, `since ${new Date(a.started_at).toLocaleString()}`];
+      if (a.resolved_at) parts.push(`resolved ${new Date(a.resolved_at).toLocaleString()}`);
+      if (a.summary) parts.push(a.summary);
+      return `
+        <div class="block-item">
+          <div class="block-info">
+            <span>${escapeHtml(a.name || a.fingerprint)} → ${escapeHtml(a.service_key)}${a.status === 'firing' ? ' <span class="badge">FIRING</span>' : ' <span class="badge resolved">RESOLVED</span>'}</span>
+            <span class="muted">${escapeHtml(parts.join(' • '))}</span>
+          </div>
+        </div>
+      `;
+    }).join('');
+  } catch (err) {
+    container.innerHTML = '<div class="muted">Failed to load inbound alerts</div>';
+  }
+}
+
+// Acknowledges the incident from an alert's ack link (/?ack=<token>) once signed in
+async function acknowledgeFromLink() {
+  const params = new URLSearchParams(window.location.search);
+  const token = params.get('ack');
+  if (!token) return;
+
+  params.delete('ack');
+  const query = params.toString();
+  history.replaceState(null, '', window.location.pathname + (query ? `?${query}` : '') + window.location.hash);
+
+  try {
+    const data = await j('/api/admin/alerts/ack', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+      body: JSON.stringify({ token })
+    });
+    const inc = data.incident || {};
+    showToast(`${inc.service_name || inc.service_key} outage acknowledged by ${inc.acknowledged_by}`);
+  } catch (err) {
+    showToast(typeof err?.body === 'string' ? err.body : 'Could not acknowledge the incident', 'error');
+  }
+  loadIncidents();
+}
+
+// ============ Quiet Hours ============
+
+const QUIET_DAYS = ['mon', 'tue', 'wed', 'thu', 'fri', 'sat', 'sun'];
+
+const QUIET_EVENTS = { down: 'Down', degraded: 'Degraded', up: 'Recovered', changed: 'Content changed', flapping: 'Flapping', latency: 'Latency anomaly' };
+
+const QUIET_ACTIONS = { delay: 'Delay until it ends', suppress: 'Suppress', deliver: 'Deliver anyway' };
+
+// Actions preselected for a new schedule: hold downs, drop the rest
+const QUIET_DEFAULT_ACTIONS = { down: 'delay', degraded: 'suppress', up: 'suppress', changed: 'delay', flapping: 'suppress', latency: 'suppress' };
+
+function capitalize(s) {
+  return s.charAt(0).toUpperCase() + s.slice(1);
+}
+
+// Fills an empty .quiet-hours-editor with the schedule inputs; its data-note is shown as help text
+function renderQuietHoursEditor(container) {
+  if (!container || $('[data-quiet="enabled"]', container)) return;
+  const note = container.getAttribute('data-note') || '';
+  container.innerHTML = `
+    <label><input type="checkbox" data-quiet="enabled"> Enable quiet hours</label>
+    <div class="quiet-hours-times">
+      <label>From <input type="time" data-quiet="start" value="22:00"></label>
+      <label>Until <input type="time" data-quiet="end" value="07:00"></label>
+      <label>Timezone <input type="text" data-quiet="timezone" placeholder="Server time, e.g. Europe/London"></label>
+    </div>
+    <div class="depends-on-checklist">
+      ${QUIET_DAYS.map(d => `<label class="depends-on-option"><input type="checkbox" data-quiet-day="${d}"> ${capitalize(d)}</label>`).join('')}
+    </div>
+    <div class="quiet-hours-actions">
+      ${Object.entries(QUIET_EVENTS).map(([event, label]) => `<label>${label}
+        <select data-quiet-action="${event}">
+          ${Object.entries(QUIET_ACTIONS).map(([value, text]) =>
+            `<option value="${value}"${QUIET_DEFAULT_ACTIONS[event] === value ? ' selected' : ''}>${text}</option>`).join('')}
+        </select></label>`).join('')}
+    </div>
+    <small class="help-text">Days are when the window starts; leave all unchecked for every day. Critical services always alert. ${escapeHtml(note)}</small>`;
+}
+
+// Reads a schedule (the quiet_hours JSON) from an editor
+function readQuietHours(container) {
+  renderQuietHoursEditor(container);
+  const field = name => $(`[data-quiet="${name}"]`, container);
+  const actions = {};
+  $$('[data-quiet-action]', container).forEach(sel => {
+    actions[sel.getAttribute('data-quiet-action')] = sel.value;
+  });
+  return {
+    enabled: field('enabled').checked,
+    timezone: field('timezone').value.trim(),
+    days: $$('[data-quiet-day]:checked', container).map(cb => cb.getAttribute('data-quiet-day')).join(','),
+    start: field('start').value,
+    end: field('end').value,
+    actions
+  };
+}
+
+// Shows a schedule in an editor; null resets it to the defaults
+function fillQuietHours(container, q) {
+  renderQuietHoursEditor(container);
+  q = q || {};
+  const field = name => $(`[data-quiet="${name}"]`, container);
+  field('enabled').checked = !!q.enabled;
+  field('timezone').value = q.timezone || '';
+  field('start').value = q.start || '22:00';
+  field('end').value = q.end || '07:00';
+  const days = (q.days || '').split(',').map(d => d.trim()).filter(Boolean);
+  $$('[data-quiet-day]', container).forEach(cb => {
+    cb.checked = days.includes(cb.getAttribute('data-quiet-day'));
+  });
+  $$('[data-quiet-action]', container).forEach(sel => {
+    const event = sel.getAttribute('data-quiet-action');
+    sel.value = (q.actions || {})[event] || QUIET_DEFAULT_ACTIONS[event];
+  });
+}
+
+// One-line description of an enabled schedule, e.g. "Quiet 22:00–07:00 Mon, Tue (Europe/London)"
+function quietHoursSummary(q) {
+  if (!q || !q.enabled) return '';
+  const days = q.days ? q.days.split(',').map(capitalize).join(', ') : 'daily';
+  return `Quiet ${q.start}–${q.end} ${days}${q.timezone ? ` (${q.timezone})` : ''}`;
+}
+
+// ============ Message Templates ============
+
+// Response of GET /api/admin/notifications/templates: overrides, defaults, events and variables
+let templateConfig = { templates: [], defaults: {}, events: [], variables: [] };
+
+const TEMPLATE_EVENT_LABELS = {
+  down: 'Service down',
+  degraded: 'Service degraded',
+  up: 'Service recovered',
+  changed: 'Content changed',
+  flapping: 'Service flapping',
+  latency: 'Latency anomaly',
+  repeat: 'Still down (repeat)',
+  escalation: 'Escalation',
+  digest_down: 'Outage digest',
+  digest_up: 'Recovery digest'
+};
+
+// Returns the saved override for an event and channel type ('' = all channels), or null
+function findTemplateOverride(templates, event, channelType) {
+  return (templates || []).find(t => t.event === event && (t.channel_type || '') === channelType) || null;
+}
+
+// Renders a preview response; the body keeps its line breaks and is shown as text
+function templatePreviewHtml(preview) {
+  const subject = preview.subject ? `<div class="template-preview-subject">${escapeHtml(preview.subject)}</div>` : '';
+  return `${subject}<div class="template-preview-body">${escapeHtml(preview.body || '')}</div>`;
+}
+
+async function loadNotificationTemplates() {
+  const select = $('#templateEvent');
+  if (!select) return;
+
+  try {
+    templateConfig = await j('/api/admin/notifications/templates');
+  } catch (err) {
+    return;
+  }
+  if (!select.options.length) {
+    select.innerHTML = (templateConfig.events || [])
+      .map(ev => `<option value="${escapeHtml(ev)}">${escapeHtml(TEMPLATE_EVENT_LABELS[ev] || ev)}</option>`)
+      .join('');
+  }
+  $('#templateVariables').textContent = 'Variables: ' + (templateConfig.variables || []).map(v => `{{${v}}}`).join(' ');
+  showNotificationTemplate();
+}
+
+// Fills the editor with the selected event/channel override; defaults are shown as placeholders
+function showNotificationTemplate() {
+  const event = $('#templateEvent').value;
+  const channelType = $('#templateChannelType').value;
+  const override = findTemplateOverride(templateConfig.templates, event, channelType);
+  const def = (templateConfig.defaults || {})[event] || {};
+
+  $('#templateSubject').value = override?.subject || '';
+  $('#templateSubject').placeholder = def.subject || '';
+  $('#templateBody').value = override?.body || '';
+  $('#templateBody').placeholder = def.body || '';
+  $('#templatePreview').classList.add('hidden');
+}
+
+function templateFormData() {
+  return {
+    event: $('#templateEvent').value,
+    channel_type: $('#templateChannelType').value,
+    subject: $('#templateSubject').value,
+    body: $('#templateBody').value
+  };
+}
+
+async function previewNotificationTemplate(btn) {
+  await handleButtonAction(
+    btn,
+    async () => {
+      const preview = await j('/api/admin/notifications/templates/preview', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+        body: JSON.stringify(templateFormData())
+      });
+      const out = $('#templatePreview');
+      out.innerHTML = templatePreviewHtml(preview);
+      out.classList.remove('hidden');
+    },
+    'Preview rendered with sample data'
+  );
+}
+
+async function saveNotificationTemplate(btn) {
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j('/api/admin/notifications/templates', {
+        method: 'PUT',
+        headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+        body: JSON.stringify(templateFormData())
+      });
+      await loadNotificationTemplates();
+    },
+    'Template saved'
+  );
+}
+
+async function resetNotificationTemplate(btn) {
+  const { event, channel_type } = templateFormData();
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(`/api/admin/notifications/templates?event=${encodeURIComponent(event)}&channel_type=${encodeURIComponent(channel_type)}`, {
+        method: 'DELETE',
+        headers: { 'X-CSRF-Token': getCsrf() }
+      });
+      await loadNotificationTemplates();
+    },
+    'Template reset to default'
+  );
+}
+
+// ============ Alert Simulation ============
+
+const SIM_DURATION_UNITS = { s: 1, m: 60, h: 3600, d: 86400 };
+
+function fillSimulationServices() {
+  const select = $('#simService');
+  if (!select) return;
+  const current = select.value;
+  select.innerHTML = servicesData
+    .map(svc => `<option value="${escapeHtml(svc.key)}">${escapeHtml(svc.name || svc.key)}</option>`)
+    .join('');
+  if (current) select.value = current;
+}
+
+// Parses "down, degraded +5m, plex:up +1h" into simulation steps; returns null when invalid
+function parseSimulationSteps(text) {
+  const steps = [];
+  for (const part of text.split(',').map(p => p.trim()).filter(Boolean)) {
+    const m = part.match(/^(?:([\w.-]+):)?(down|degraded|up)(?:\s*\+(\d+)([smhd]))?$/i);
+    if (!m) return null;
+    const step = { state: m[2].toLowerCase() };
+    if (m[1]) step.service = m[1];
+    if (m[3]) step.after_seconds = parseInt(m[3], 10) * SIM_DURATION_UNITS[m[4].toLowerCase()];
+    steps.push(step);
+  }
+  return steps.length ? steps : null;
+}
+
+function simulationResultHtml(result) {
+  const notifications = result.notifications || [];
+  const log = result.log || [];
+  const sent = notifications.length === 0
+    ? '<div class="muted">No notifications would be sent</div>'
+    : notifications.map(n => {
+      const parts = [`step ${n.step}`, `${n.channel_name} (${n.channel_type})`];
+      if (n.deferred_until) parts.push(`deferred until ${new Date(n.deferred_until).toLocaleString()}`);
+      return `
+        <div class="block-item">
+          <div class="block-info">
+            <span>${escapeHtml(n.subject)}</span>
+            <span class="muted">${escapeHtml(parts.join(' • '))}</span>
+          </div>
+        </div>
+      `;
+    }).join('');
+  const trace = log.map(l =>
+    `<div class="muted">${escapeHtml(`step ${l.step}: ${l.message}${l.details ? ' — ' + l.details : ''}`)}</div>`
+  ).join('');
+  return sent + trace;
+}
+
+async function runAlertSimulation(btn) {
+  const steps = parseSimulationSteps($('#simSteps').value);
+  if (!steps) {
+    showToast('Enter steps like "down, up +5m"', 'error');
+    return;
+  }
+  const live = $('#simLive').checked;
+  await handleButtonAction(
+    btn,
+    async () => {
+      const result = await j('/api/admin/alerts/simulate', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-CSRF-Token': getCsrf() },
+        body: JSON.stringify({ service: $('#simService').value, steps, live })
+      });
+      $('#simResult').innerHTML = simulationResultHtml(result);
+      if (live) loadNotificationDeliveries();
+    },
+    live ? 'Simulation sent' : 'Simulation complete'
+  );
+}
@@ -272,6 +272,142 @@ async function testGlancesConnection() {
   );
 }
 
+// ============ Resource Alerts ============
+
+let resourceAlertRules = [];
+
+function resourceRuleSummary(rule) {
+  let s = rule.metric === 'container'
+    ? `container ${rule.container} not running`
+    : `${rule.metric} ${rule.operator} ${rule.threshold}`;
+  if (rule.hysteresis) s += ` (clears ±${rule.hysteresis})`;
+  if (rule.for_minutes) s += ` for ${rule.for_minutes}m`;
+  return s;
+}
+
+async function loadResourceAlertRules() {
+  const list = $('#resourceAlertList');
+  if (!list) return;
+  try {
+    const data = await j('/api/admin/resources/alerts');
+    resourceAlertRules = data.rules || [];
+    fillResourceAlertSelect($('#resourceAlertMetric'), data.metrics || []);
+    fillResourceAlertSelect($('#resourceAlertOperator'), data.operators || []);
+    updateResourceAlertForm();
+  } catch (err) {
+    resourceAlertRules = [];
+  }
+  if (resourceAlertRules.length === 0) {
+    list.innerHTML = '<div class="muted">No resource alert rules</div>';
+    return;
+  }
+  list.innerHTML = resourceAlertRules.map(rule => `
+    <div class="block-item">
+      <div class="block-info">
+        <strong>${escapeHtml(rule.name)}</strong>${rule.enabled ? '' : ' <span class="muted">(disabled)</span>'}
+        <span class="muted">${escapeHtml(resourceRuleSummary(rule))}</span>
+      </div>
+      <div class="ops">
+        <button class="btn ghost small" data-action="toggle-resource-alert" data-id="${rule.id}">${rule.enabled ? 'Disable' : 'Enable'}</button>
+        <button class="btn danger small" data-action="delete-resource-alert" data-id="${rule.id}">Delete</button>
+      </div>
+    </div>
+  `).join('');
+}
+
+function fillResourceAlertSelect(select, values) {
+  if (!select || select.options.length > 0) return;
+  select.innerHTML = values.map(v => `<option value="${escapeHtml(v)}">${escapeHtml(v)}</option>`).join('');
+}
+
+// Container rules only need a container name; numeric rules need a threshold
+function updateResourceAlertForm() {
+  const isContainer = $('#resourceAlertMetric')?.value === 'container';
+  $('#resourceAlertThresholdRow')?.classList.toggle('hidden', isContainer);
+  $('#resourceAlertContainerGroup')?.classList.toggle('hidden', !isContainer);
+}
+
+async function createResourceAlertRule() {
+  const btn = $('#addResourceAlert');
+  const rule = {
+    name: $('#resourceAlertName').value.trim(),
+    metric: $('#resourceAlertMetric').value,
+    operator: $('#resourceAlertOperator').value,
+    threshold: parseFloat($('#resourceAlertThreshold').value) || 0,
+    hysteresis: parseFloat($('#resourceAlertHysteresis').value) || 0,
+    for_minutes: parseInt($('#resourceAlertFor').value, 10) || 0,
+    container: $('#resourceAlertContainer').value.trim(),
+    enabled: true
+  };
+
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j('/api/admin/resources/alerts', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-CSRF-Token': getCsrf()
+        },
+        body: JSON.stringify(rule)
+      });
+      $('#resourceAlertForm').reset();
+      updateResourceAlertForm();
+      await loadResourceAlertRules();
+    },
+    'Resource alert added'
+  );
+}
+
+async function toggleResourceAlertRule(id, btn) {
+  const rule = resourceAlertRules.find(r => r.id === id);
+  if (!rule) return;
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(`/api/admin/resources/alerts/${id}`, {
+        method: 'PUT',
+        headers: {
+          'Content-Type': 'application/json',
+          'X-CSRF-Token': getCsrf()
+        },
+        body: JSON.stringify({ ...rule, enabled: !rule.enabled })
+      });
+      await loadResourceAlertRules();
+    },
+    rule.enabled ? 'Resource alert disabled' : 'Resource alert enabled'
+  );
+}
+
+async function deleteResourceAlertRule(id, btn) {
+  const rule = resourceAlertRules.find(r => r.id === id);
+  if (!rule || !confirm(`Delete resource alert "${rule.name}"?`)) return;
+  await handleButtonAction(
+    btn,
+    async () => {
+      await j(`/api/admin/resources/alerts/${id}`, {
+        method: 'DELETE',
+        headers: { 'X-CSRF-Token': getCsrf() }
+      });
+      await loadResourceAlertRules();
+    },
+    'Resource alert deleted'
+  );
+}
+
+function initResourceAlerts() {
+  $('#resourceAlertMetric')?.addEventListener('change', updateResourceAlertForm);
+  $('#addResourceAlert')?.addEventListener('click', createResourceAlertRule);
+  $('#resourceAlertList')?.addEventListener('click', e => {
+    const btn = e.target.closest('[data-action]');
+    if (!btn) return;
+    const id = parseInt(btn.getAttribute('data-id'), 10);
+    const action = btn.getAttribute('data-action');
+    if (action === 'toggle-resource-alert') toggleResourceAlertRule(id, btn);
+    else if (action === 'delete-resource-alert') deleteResourceAlertRule(id, btn);
+  });
+}
+
 async function refreshResources() {
   const pill = document.getElementById('resources-pill');
   const section = document.getElementById('card-resources');
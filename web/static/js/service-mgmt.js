@@ -290,6 +290,11 @@ function openServiceModal(service = null) {
   $('#serviceInterval').value = service?.check_interval || 60;
   $('#serviceExpectedMin').value = service?.expected_min || 200;
   $('#serviceExpectedMax').value = service?.expected_max || 399;
+  $('#serviceDegradedPhase').value = service?.degraded_phase || '';
+  $('#serviceContentCheck').checked = !!service?.content_check;
+  $('#serviceContentIgnore').value = service?.content_ignore || '';
+  $('#serviceAppHealth').checked = !!service?.app_health;
+  $('#serviceEntityChecks').value = formatEntityChecks(service?.entity_checks);
   $('#serviceVisible').checked = service?.visible !== false;
   $('#serviceId').value = service?.id || '';
   $('#serviceType').value = service?.service_type || '';
@@ -326,6 +331,14 @@ function openServiceModal(service = null) {
     }
   }
 
+  // Notification routing
+  populateNotifyChannelsList(service?.notify_channels);
+  const events = (service?.notify_events || '').split(',').map(e => e.trim()).filter(Boolean);
+  $$('#serviceNotifyEventsList .notify-event-cb').forEach(cb => {
+    cb.checked = events.includes(cb.value);
+  });
+  $('#serviceCritical').checked = !!service?.critical;
+
   modal.showModal();
 }
 
@@ -478,6 +491,9 @@ async function saveService() {
     ? Array.from(connectedToContainer.querySelectorAll('.connected-to-cb:checked')).map(cb => cb.value).join(',')
     : '';
 
+  const notifyChannels = $$('#serviceNotifyChannelsList .notify-channel-cb:checked').map(cb => cb.value).join(',');
+  const notifyEvents = $$('#serviceNotifyEventsList .notify-event-cb:checked').map(cb => cb.value).join(',');
+
   const serviceData = {
     name: $('#serviceName').value.trim(),
     url: $('#serviceUrl').value.trim(),
@@ -491,8 +507,16 @@ async function saveService() {
     expected_min: parseInt($('#serviceExpectedMin').value) || 200,
     expected_max: parseInt($('#serviceExpectedMax').value) || 399,
     visible: $('#serviceVisible').checked,
+    degraded_phase: $('#serviceDegradedPhase').value,
+    content_check: $('#serviceContentCheck').checked,
+    content_ignore: $('#serviceContentIgnore').value.trim(),
+    app_health: $('#serviceAppHealth').checked,
+    entity_checks: [],
     depends_on: dependsOn,
-    connected_to: connectedTo
+    connected_to: connectedTo,
+    notify_channels: notifyChannels,
+    notify_events: notifyEvents,
+    critical: $('#serviceCritical').checked
   };
 
   if (!serviceData.name || !serviceData.url) {
@@ -504,6 +528,17 @@ async function saveService() {
     return;
   }
 
+  try {
+    serviceData.entity_checks = parseEntityChecks($('#serviceEntityChecks').value);
+  } catch (err) {
+    const errEl = $('#serviceError');
+    if (errEl) {
+      errEl.textContent = err.message;
+      errEl.classList.remove('hidden');
+    }
+    return;
+  }
+
   try {
     if (editingServiceId) {
       // Update existing service
@@ -570,6 +605,35 @@ async function deleteService() {
   }
 }
 
+// Home Assistant entity rules are edited one per line:
+//   entity_id[:attribute] <op> <value> [degraded]
+const ENTITY_RULE_RE = /^([^\s:=!<>]+)(?::([^\s=!<>]+))?\s*(==|!=|>=|<=|>|<)\s*(.+?)(?:\s+(degraded|down))?$/i;
+
+function parseEntityChecks(text) {
+  const rules = [];
+  for (const raw of String(text || '').split('\n')) {
+    const line = raw.trim();
+    if (!line) continue;
+    const m = line.match(ENTITY_RULE_RE);
+    if (!m) throw new Error(`Invalid entity check: ${line}`);
+    rules.push({
+      entity_id: m[1],
+      attribute: m[2] || '',
+      operator: m[3],
+      value: m[4],
+      severity: (m[5] || 'down').toLowerCase()
+    });
+  }
+  return rules;
+}
+
+function formatEntityChecks(rules) {
+  return (rules || []).map(r => {
+    const target = r.attribute ? `${r.entity_id}:${r.attribute}` : r.entity_id;
+    return `${target} ${r.operator} ${r.value}${r.severity === 'degraded' ? ' degraded' : ''}`;
+  }).join('\n');
+}
+
 function generateServiceKey(name) {
   return name.toLowerCase()
     .replace(/[^a-z0-9]+/g, '-')
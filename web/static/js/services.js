@@ -94,6 +94,12 @@ function getProtocolBadge(svc) {
   if (checkType === 'dns' || url.startsWith('dns://')) {
     return 'DNS';
   }
+  if (checkType === 'minecraft' || url.startsWith('minecraft://')) {
+    return 'MINECRAFT';
+  }
+  if (checkType === 'a2s' || url.startsWith('a2s://')) {
+    return 'A2S';
+  }
   if (url.startsWith('https://')) {
     return 'HTTPS';
   }
@@ -157,6 +163,28 @@ async function loadAllServices() {
   }
 }
 
+// Extra metric stats (game server players, media server streams), using the last stored metrics
+function metricStatsHtml(svc) {
+  const m = svc.metrics;
+  if (!m) return '';
+  let html = '';
+  if (m.players !== undefined) {
+    html += `
+          <div class="stat-item">
+            <div class="stat-label">Players</div>
+            <div class="stat-value" id="players-${svc.key}">${fmtPlayers(m.players, m.max_players)}</div>
+          </div>`;
+  }
+  if (m.streams !== undefined) {
+    html += `
+          <div class="stat-item">
+            <div class="stat-label">Streams</div>
+            <div class="stat-value" id="streams-${svc.key}">${Number(m.streams) || 0}</div>
+          </div>`;
+  }
+  return html;
+}
+
 function renderServiceCards(services) {
   const container = $('#services-container');
   if (!container) return;
@@ -206,7 +234,7 @@ function renderServiceCards(services) {
           <div class="stat-item">
             <div class="stat-label">Checked</div>
             <div class="stat-value" id="last-check-${svc.key}">—</div>
-          </div>
+          </div>${metricStatsHtml(svc)}
         </div>
       </div>
       